@@ -6,6 +6,7 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"mort/internal/mortcli"
 	"mort/internal/xtbmlcli"
 	"mort/tui"
 )
@@ -16,6 +17,9 @@ func main() {
 		code := xtbmlcli.Run(os.Args[2:], os.Stdout, os.Stderr)
 		os.Exit(code)
 	}
+	if len(os.Args) > 1 && mortcli.IsCommand(os.Args[1]) {
+		os.Exit(mortcli.Run(os.Args[1:], os.Stdout, os.Stderr))
+	}
 
 	jsonDir := os.Getenv("MORT_JSON_DIR")
 	model := tui.NewModel(jsonDir)
@@ -0,0 +1,288 @@
+// Package catalog summarises a library of converted tables: how many there
+// are by content type, nation, provider and shape, their age ranges and cell
+// counts, and which are largest or have missing data.
+package catalog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"mort/internal/xtbml"
+)
+
+// Count is the number of documents or rate tables sharing a value.
+type Count struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Document describes one converted file.
+type Document struct {
+	File          string `json:"file"`
+	Identifier    string `json:"identifier"`
+	TableIdentity string `json:"tableIdentity"`
+	Name          string `json:"name"`
+	Tables        int    `json:"tables"`
+	Cells         int    `json:"cells"`
+	MissingCells  int    `json:"missingCells"`
+}
+
+// Range is an inclusive span of ages.
+type Range struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+func (r Range) String() string {
+	return fmt.Sprintf("%d-%d", r.Min, r.Max)
+}
+
+// Catalog is the library summary. Counts by content type, nation, provider
+// and tables per document count documents; counts by axes and age range count
+// rate tables. A document with tables from several nations counts once for
+// each.
+type Catalog struct {
+	Documents    int `json:"documents"`
+	RateTables   int `json:"rateTables"`
+	Cells        int `json:"cells"`
+	MissingCells int `json:"missingCells"`
+	// MissingDocuments counts documents with at least one blank cell.
+	MissingDocuments int `json:"missingDocuments"`
+	// Ages spans every age in the library; nil when there are no cells.
+	Ages *Range `json:"ages,omitempty"`
+
+	ContentTypes      []Count `json:"contentTypes"`
+	Nations           []Count `json:"nations"`
+	Providers         []Count `json:"providers"`
+	TablesPerDocument []Count `json:"tablesPerDocument"`
+	Axes              []Count `json:"axes"`
+	AgeRanges         []Count `json:"ageRanges"`
+
+	// Largest lists the documents with the most cells.
+	Largest []Document `json:"largest"`
+	// Missing lists the documents with the most blank cells.
+	Missing []Document `json:"missing"`
+}
+
+// Filter selects the documents a catalogue covers. Text filters match labels
+// case-insensitively as substrings; empty filters match everything.
+type Filter struct {
+	ContentType string
+	Nation      string
+	Provider    string
+	// Axes keeps documents with at least one rate table of this many axes,
+	// e.g. 2 for select tables; zero keeps all.
+	Axes int
+}
+
+// Match reports whether table passes the filter.
+func (f Filter) Match(table *xtbml.ConvertedTable) bool {
+	class := table.Classification
+	if class == nil {
+		class = &xtbml.ClassificationPayload{}
+	}
+	if !contains(class.ContentType.Label, f.ContentType) || !contains(class.ProviderName, f.Provider) {
+		return false
+	}
+	if f.Nation != "" {
+		found := false
+		for _, n := range nations(table) {
+			found = found || contains(n, f.Nation)
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.Axes > 0 {
+		found := false
+		for _, tp := range table.Tables {
+			found = found || AxisCount(tp) == f.Axes
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(s, sub string) bool {
+	return sub == "" || strings.Contains(strings.ToLower(s), strings.ToLower(strings.TrimSpace(sub)))
+}
+
+// AxisCount returns the number of axes of a rate table: the declared axes, or
+// when metadata is missing, one for age plus one if any cell has a duration.
+func AxisCount(tp xtbml.TablePayload) int {
+	if tp.Metadata != nil && len(tp.Metadata.Axes) > 0 {
+		return len(tp.Metadata.Axes)
+	}
+	for _, entry := range tp.Rates {
+		if entry.Duration != nil {
+			return 2
+		}
+	}
+	return 1
+}
+
+func nations(table *xtbml.ConvertedTable) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, tp := range table.Tables {
+		if tp.Metadata == nil {
+			continue
+		}
+		if label := labelOr(tp.Metadata.Nation.Label, "Unspecified"); !seen[label] {
+			seen[label] = true
+			out = append(out, label)
+		}
+	}
+	if len(out) == 0 {
+		out = append(out, "Unspecified")
+	}
+	return out
+}
+
+func labelOr(label, fallback string) string {
+	if strings.TrimSpace(label) == "" {
+		return fallback
+	}
+	return label
+}
+
+// Builder accumulates documents into a Catalog.
+type Builder struct {
+	// Top bounds the Largest, Missing and AgeRanges lists; zero keeps 10.
+	// Age ranges past the bound are folded into one "other" count.
+	Top int
+
+	catalog      Catalog
+	contentTypes map[string]int
+	nations      map[string]int
+	providers    map[string]int
+	perDocument  map[string]int
+	axes         map[string]int
+	ageRanges    map[string]int
+	documents    []Document
+}
+
+// Add records one converted document read from file.
+func (b *Builder) Add(file string, table *xtbml.ConvertedTable) {
+	if b.contentTypes == nil {
+		b.contentTypes = map[string]int{}
+		b.nations = map[string]int{}
+		b.providers = map[string]int{}
+		b.perDocument = map[string]int{}
+		b.axes = map[string]int{}
+		b.ageRanges = map[string]int{}
+	}
+	c := &b.catalog
+	doc := Document{File: file, Identifier: table.Identifier, Tables: len(table.Tables)}
+	class := table.Classification
+	if class == nil {
+		class = &xtbml.ClassificationPayload{}
+	}
+	doc.TableIdentity = class.TableIdentity
+	doc.Name = class.TableName
+
+	c.Documents++
+	b.contentTypes[labelOr(class.ContentType.Label, "Unspecified")]++
+	b.providers[labelOr(class.ProviderName, "Unspecified")]++
+	for _, n := range nations(table) {
+		b.nations[n]++
+	}
+	b.perDocument[plural(len(table.Tables), "table")]++
+
+	for _, tp := range table.Tables {
+		c.RateTables++
+		b.axes[plural(AxisCount(tp), "axis")]++
+		var ages *Range
+		for _, entry := range tp.Rates {
+			if entry.Rate == nil {
+				doc.MissingCells++
+			} else {
+				doc.Cells++
+			}
+			if ages == nil {
+				ages = &Range{Min: entry.Age, Max: entry.Age}
+			}
+			ages.Min = min(ages.Min, entry.Age)
+			ages.Max = max(ages.Max, entry.Age)
+		}
+		if ages == nil {
+			b.ageRanges["no cells"]++
+			continue
+		}
+		b.ageRanges[ages.String()]++
+		if c.Ages == nil {
+			c.Ages = &Range{Min: ages.Min, Max: ages.Max}
+		}
+		c.Ages.Min = min(c.Ages.Min, ages.Min)
+		c.Ages.Max = max(c.Ages.Max, ages.Max)
+	}
+	c.Cells += doc.Cells
+	c.MissingCells += doc.MissingCells
+	b.documents = append(b.documents, doc)
+}
+
+// Catalog returns the summary of the documents added so far.
+func (b *Builder) Catalog() Catalog {
+	c := b.catalog
+	c.ContentTypes = sortedCounts(b.contentTypes)
+	c.Nations = sortedCounts(b.nations)
+	c.Providers = sortedCounts(b.providers)
+	c.TablesPerDocument = sortedCounts(b.perDocument)
+	c.Axes = sortedCounts(b.axes)
+
+	top := b.Top
+	if top <= 0 {
+		top = 10
+	}
+	c.AgeRanges = sortedCounts(b.ageRanges)
+	if len(c.AgeRanges) > top {
+		other := Count{Name: "other"}
+		for _, rest := range c.AgeRanges[top:] {
+			other.Count += rest.Count
+		}
+		c.AgeRanges = append(c.AgeRanges[:top], other)
+	}
+	docs := make([]Document, len(b.documents))
+	copy(docs, b.documents)
+	sort.SliceStable(docs, func(i, j int) bool { return docs[i].Cells > docs[j].Cells })
+	c.Largest = docs[:min(top, len(docs))]
+
+	c.Missing = []Document{}
+	for _, doc := range b.documents {
+		if doc.MissingCells > 0 {
+			c.Missing = append(c.Missing, doc)
+		}
+	}
+	sort.SliceStable(c.Missing, func(i, j int) bool { return c.Missing[i].MissingCells > c.Missing[j].MissingCells })
+	c.MissingDocuments = len(c.Missing)
+	c.Missing = c.Missing[:min(top, len(c.Missing))]
+	return c
+}
+
+// sortedCounts orders counts largest first, then by name.
+func sortedCounts(m map[string]int) []Count {
+	counts := make([]Count, 0, len(m))
+	for name, n := range m {
+		counts = append(counts, Count{Name: name, Count: n})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Name < counts[j].Name
+	})
+	return counts
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	if noun == "axis" {
+		return fmt.Sprintf("%d axes", n)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
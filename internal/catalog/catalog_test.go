@@ -0,0 +1,148 @@
+package catalog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"mort/internal/xtbml"
+)
+
+func ultimate(identity, nation, contentType string, firstAge int, rates ...float64) *xtbml.ConvertedTable {
+	tp := xtbml.TablePayload{Metadata: &xtbml.TableMetaPayload{
+		Nation: xtbml.ClassifiedValuePayload{Label: nation},
+		Axes:   []xtbml.AxisDefinitionPayload{{ID: "Age"}},
+	}}
+	for i, r := range rates {
+		tp.Rates = append(tp.Rates, xtbml.RateEntryPayload{Age: firstAge + i, Rate: &r})
+	}
+	return &xtbml.ConvertedTable{
+		Identifier: "table_" + identity,
+		Classification: &xtbml.ClassificationPayload{
+			TableIdentity: identity,
+			TableName:     "Table " + identity,
+			ProviderName:  "Society of Actuaries",
+			ContentType:   xtbml.ClassifiedValuePayload{Code: "85", Label: contentType},
+		},
+		Tables: []xtbml.TablePayload{tp},
+	}
+}
+
+// selectTable adds a two-axis select table, with one blank cell, ahead of the
+// ultimate table.
+func selectTable(identity, nation string) *xtbml.ConvertedTable {
+	table := ultimate(identity, nation, "CSO/CET", 20, 0.1, 0.2)
+	rate := 0.05
+	sel := xtbml.TablePayload{Metadata: &xtbml.TableMetaPayload{
+		Nation: xtbml.ClassifiedValuePayload{Label: nation},
+		Axes:   []xtbml.AxisDefinitionPayload{{ID: "Age"}, {ID: "Duration"}},
+	}}
+	for _, d := range []int{1, 2} {
+		sel.Rates = append(sel.Rates, xtbml.RateEntryPayload{Age: 18, Duration: &d, Rate: &rate})
+	}
+	sel.Rates[1].Rate = nil
+	table.Tables = append([]xtbml.TablePayload{sel}, table.Tables...)
+	return table
+}
+
+func build(top int, tables ...*xtbml.ConvertedTable) Catalog {
+	b := Builder{Top: top}
+	for _, tbl := range tables {
+		b.Add("t"+tbl.Classification.TableIdentity+".json", tbl)
+	}
+	return b.Catalog()
+}
+
+func TestBuilderCounts(t *testing.T) {
+	c := build(1,
+		ultimate("1", "United States of America", "CSO/CET", 0, 0.1, 0.2, 0.3),
+		ultimate("2", "Canada", "Annuitant Mortality", 50, 0.1),
+		selectTable("3", "Canada"),
+	)
+	if c.Documents != 3 || c.RateTables != 4 || c.Cells != 7 || c.MissingCells != 1 || c.MissingDocuments != 1 {
+		t.Fatalf("totals = %+v", c)
+	}
+	if c.Ages == nil || *c.Ages != (Range{Min: 0, Max: 50}) {
+		t.Fatalf("Ages = %v", c.Ages)
+	}
+	if want := []Count{{"Canada", 2}, {"United States of America", 1}}; !equalCounts(c.Nations, want) {
+		t.Fatalf("Nations = %v, want %v", c.Nations, want)
+	}
+	if want := []Count{{"CSO/CET", 2}, {"Annuitant Mortality", 1}}; !equalCounts(c.ContentTypes, want) {
+		t.Fatalf("ContentTypes = %v, want %v", c.ContentTypes, want)
+	}
+	if want := []Count{{"1 table", 2}, {"2 tables", 1}}; !equalCounts(c.TablesPerDocument, want) {
+		t.Fatalf("TablesPerDocument = %v, want %v", c.TablesPerDocument, want)
+	}
+	if want := []Count{{"1 axis", 3}, {"2 axes", 1}}; !equalCounts(c.Axes, want) {
+		t.Fatalf("Axes = %v, want %v", c.Axes, want)
+	}
+	if want := []Count{{"0-2", 1}, {"other", 3}}; !equalCounts(c.AgeRanges, want) {
+		t.Fatalf("AgeRanges = %v, want %v", c.AgeRanges, want)
+	}
+	if len(c.Largest) != 1 || c.Largest[0].TableIdentity != "1" {
+		t.Fatalf("Largest = %+v", c.Largest)
+	}
+	if len(c.Missing) != 1 || c.Missing[0].TableIdentity != "3" {
+		t.Fatalf("Missing = %+v", c.Missing)
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	us := ultimate("1", "United States of America", "CSO/CET", 0, 0.1)
+	canada := ultimate("2", "Canada", "CSO/CET", 0, 0.1)
+	canadaSelect := selectTable("3", "Canada")
+
+	cases := []struct {
+		name   string
+		filter Filter
+		want   []bool
+	}{
+		{"empty", Filter{}, []bool{true, true, true}},
+		{"nation", Filter{Nation: "canada"}, []bool{false, true, true}},
+		{"select for canada", Filter{Nation: "Canada", Axes: 2}, []bool{false, false, true}},
+		{"content type", Filter{ContentType: "annuitant"}, []bool{false, false, false}},
+		{"provider", Filter{Provider: "society"}, []bool{true, true, true}},
+	}
+	for _, tc := range cases {
+		for i, tbl := range []*xtbml.ConvertedTable{us, canada, canadaSelect} {
+			if got := tc.filter.Match(tbl); got != tc.want[i] {
+				t.Errorf("%s: Match(%s) = %v, want %v", tc.name, tbl.Identifier, got, tc.want[i])
+			}
+		}
+	}
+}
+
+func TestAxisCountWithoutMetadata(t *testing.T) {
+	d := 1
+	rate := 0.1
+	tp := xtbml.TablePayload{Rates: []xtbml.RateEntryPayload{{Age: 30, Rate: &rate}, {Age: 30, Duration: &d, Rate: &rate}}}
+	if got := AxisCount(tp); got != 2 {
+		t.Fatalf("AxisCount() = %d, want 2", got)
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteText(&buf, build(10, selectTable("3", "Canada"))); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"Documents:     1", "Cells:         3 (1 blank in 1 documents)", "Nations (documents):\n       1  Canada", "2 axes", "Largest tables:", "Tables with missing data (1 shown of 1):"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func equalCounts(got, want []Count) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,50 @@
+package catalog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// WriteText renders c for a terminal: totals, each breakdown with its counts,
+// then the largest tables and those with missing data.
+func WriteText(w io.Writer, c Catalog) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "Documents:     %d\n", c.Documents)
+	fmt.Fprintf(bw, "Rate tables:   %d\n", c.RateTables)
+	fmt.Fprintf(bw, "Cells:         %d (%d blank in %d documents)\n", c.Cells, c.MissingCells, c.MissingDocuments)
+	if c.Ages != nil {
+		fmt.Fprintf(bw, "Ages:          %s\n", c.Ages)
+	}
+
+	writeCounts(bw, "Content types (documents)", c.ContentTypes)
+	writeCounts(bw, "Nations (documents)", c.Nations)
+	writeCounts(bw, "Providers (documents)", c.Providers)
+	writeCounts(bw, "Tables per document", c.TablesPerDocument)
+	writeCounts(bw, "Axes (rate tables)", c.Axes)
+	writeCounts(bw, "Age ranges (rate tables)", c.AgeRanges)
+
+	if len(c.Largest) > 0 {
+		fmt.Fprintln(bw, "\nLargest tables:")
+		for _, doc := range c.Largest {
+			fmt.Fprintf(bw, "  %8d cells  %-12s %-8s %s\n", doc.Cells, doc.File, doc.TableIdentity, doc.Name)
+		}
+	}
+	if len(c.Missing) > 0 {
+		fmt.Fprintf(bw, "\nTables with missing data (%d shown of %d):\n", len(c.Missing), c.MissingDocuments)
+		for _, doc := range c.Missing {
+			fmt.Fprintf(bw, "  %8d blank  %-12s %-8s %s\n", doc.MissingCells, doc.File, doc.TableIdentity, doc.Name)
+		}
+	}
+	return bw.Flush()
+}
+
+func writeCounts(w io.Writer, title string, counts []Count) {
+	if len(counts) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n%s:\n", title)
+	for _, c := range counts {
+		fmt.Fprintf(w, "  %6d  %s\n", c.Count, c.Name)
+	}
+}
@@ -0,0 +1,131 @@
+package changelogsync
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"mort/internal/tablearchive"
+)
+
+// Archive actions recorded for revisions without a change log entry.
+const (
+	archiveBaseline  = "Baseline"
+	archiveManual    = "Manual download"
+	archiveReconcile = "Reconcile"
+)
+
+// storeTable verifies body, archives it as a new revision of tableID
+// attributed to entry, and then writes it to the XML mirror. Content failing
+// VerifyTable is quarantined and the mirror keeps its current copy. The first
+// time a table is archived, the local copy it replaces is archived as a
+// baseline revision so that revision is not lost. The baseline takes the log
+// date of the table's previous change log entry, so an -as-of lookup between
+// that entry and entry finds it.
+func (s *Syncer) storeTable(tableID int, body []byte, entry *Entry, fallbackAction string) error {
+	if err := VerifyTable(tableID, body); err != nil {
+		if qerr := s.quarantine(tableID, body, err); qerr != nil {
+			return fmt.Errorf("%w (quarantine failed: %v)", err, qerr)
+		}
+		return err
+	}
+	if s.Archive != nil {
+		if err := s.archiveBaseline(tableID, entry); err != nil {
+			return fmt.Errorf("archive t%d.xml: %w", tableID, err)
+		}
+		rev := tablearchive.Revision{Action: fallbackAction}
+		if entry != nil {
+			rev = revisionFor(*entry)
+		}
+		if _, err := s.Archive.Store(tableID, body, rev); err != nil {
+			return fmt.Errorf("archive t%d.xml: %w", tableID, err)
+		}
+	}
+	return writeFileAtomic(TablePath(s.XMLDir, tableID), body)
+}
+
+func (s *Syncer) archiveBaseline(tableID int, entry *Entry) error {
+	history, err := s.Archive.History(tableID)
+	if err != nil || len(history) > 0 {
+		return err
+	}
+	current, err := os.ReadFile(TablePath(s.XMLDir, tableID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	logDate, err := s.baselineDate(tableID, entry)
+	if err != nil {
+		return err
+	}
+	_, err = s.Archive.Store(tableID, current, tablearchive.Revision{Action: archiveBaseline, LogDate: logDate})
+	return err
+}
+
+// baselineDate returns the log date of the newest change log entry of tableID
+// logged before entry, or of any entry when entry is nil. It is zero when the
+// change log never mentions the table, and the baseline then falls back to its
+// archive time.
+func (s *Syncer) baselineDate(tableID int, entry *Entry) (time.Time, error) {
+	entries, err := s.changeLog.get(s.Client)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("fetch change log: %w", err)
+	}
+	var newest int64
+	for _, e := range entries {
+		ms := e.LogMillis()
+		if e.TableID == tableID && (entry == nil || ms < entry.LogMillis()) {
+			newest = max(newest, ms)
+		}
+	}
+	if newest <= 0 {
+		return time.Time{}, nil
+	}
+	return time.UnixMilli(newest).UTC(), nil
+}
+
+// changeLogCache holds the full change log for dating baselines. It is fetched
+// at most once per run, and only when a baseline is archived.
+type changeLogCache struct {
+	mu      sync.Mutex
+	entries []Entry
+	fetched bool
+}
+
+// reset forgets the log of a previous run; entries, when not nil, is the full
+// change log the caller already holds.
+func (c *changeLogCache) reset(entries []Entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries, c.fetched = entries, entries != nil
+}
+
+func (c *changeLogCache) get(client *Client) ([]Entry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.fetched {
+		entries, err := client.FetchAll()
+		if err != nil {
+			return nil, err
+		}
+		c.entries, c.fetched = entries, true
+	}
+	return c.entries, nil
+}
+
+func revisionFor(entry Entry) tablearchive.Revision {
+	rev := tablearchive.Revision{
+		LogID:   entry.TableIdentity,
+		Action:  entry.Action,
+		Comment: entry.Comment,
+		UserID:  entry.UserID,
+	}
+	if ms := entry.LogMillis(); ms > 0 {
+		rev.LogDate = time.UnixMilli(ms).UTC()
+	}
+	return rev
+}
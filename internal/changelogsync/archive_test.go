@@ -0,0 +1,59 @@
+package changelogsync
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"mort/internal/changelogsync/soatest"
+	"mort/internal/tablearchive"
+)
+
+func TestSyncArchivesRevisions(t *testing.T) {
+	srv := soatest.NewServer()
+	defer srv.Close()
+	srv.AddEntries(
+		soatest.Entry{TableIdentity: 40, TableID: 1, Action: "Add", LogMillis: 1600000000000},
+		soatest.Entry{TableIdentity: 50, TableID: 1, Action: "Update", Comment: "Corrected select rates", UserID: "jdoe", LogMillis: 1700000000000},
+	)
+	srv.SetTable(1, tableXML(1, "v2"))
+
+	syncer, _ := newTestSyncer(t, srv, 0)
+	syncer.Archive = tablearchive.Open(t.TempDir())
+	if err := os.MkdirAll(syncer.XMLDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(TablePath(syncer.XMLDir, 1), []byte("<XTbML>v1</XTbML>"), 0o644); err != nil {
+		t.Fatalf("seed t1: %v", err)
+	}
+	// The mirror already holds the table as added by log #40.
+	if err := SaveState(syncer.StatePath, &State{LastLogMillis: 1600000000000}); err != nil {
+		t.Fatalf("seed state: %v", err)
+	}
+
+	if _, err := syncer.Sync(); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	history, err := syncer.Archive.History(1)
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("len(history) = %d, want baseline plus update", len(history))
+	}
+	if history[0].Action != archiveBaseline || history[0].LogDate.UnixMilli() != 1600000000000 {
+		t.Fatalf("first revision = %+v, want baseline of the local copy dated by log #40", history[0])
+	}
+	asOf, err := syncer.Archive.AsOf(1, time.UnixMilli(1650000000000))
+	if err != nil || asOf.Number != history[0].Number {
+		t.Fatalf("AsOf() between the entries = %+v, %v; want the baseline", asOf, err)
+	}
+	rev := history[1]
+	if rev.LogID != 50 || rev.Action != "Update" || rev.Comment != "Corrected select rates" || rev.UserID != "jdoe" || rev.LogDate.UnixMilli() != 1700000000000 {
+		t.Fatalf("update revision lost change log details: %+v", rev)
+	}
+	body, err := syncer.Archive.Read(history[0])
+	if err != nil || string(body) != "<XTbML>v1</XTbML>" {
+		t.Fatalf("baseline body = %q, %v", body, err)
+	}
+}
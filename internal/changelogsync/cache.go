@@ -0,0 +1,157 @@
+package changelogsync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrNotModified is returned by FetchTableIfChanged when the service answers
+// 304 Not Modified.
+var ErrNotModified = errors.New("not modified")
+
+// httpCacheName is the file, beside the state file, that holds the HTTP cache
+// validators of downloaded tables. It stays out of the XML directory, which is
+// committed as a whole.
+const httpCacheName = "changelog_http_cache.json"
+
+// Validators are the HTTP cache validators the service returned for a table.
+type Validators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// CacheEntry ties the validators of a download to the SHA-256 of the body
+// they describe, so they are only sent while the local copy still holds that
+// body.
+type CacheEntry struct {
+	Validators
+	SHA256 string `json:"sha256"`
+}
+
+// HTTPCache maps table ids to the validators of their last download. It is
+// safe for concurrent use.
+type HTTPCache struct {
+	mu      sync.Mutex
+	tables  map[int]CacheEntry
+	changed bool
+}
+
+// HTTPCachePath returns the default cache kept beside the state file at
+// statePath.
+func HTTPCachePath(statePath string) string {
+	return filepath.Join(filepath.Dir(statePath), httpCacheName)
+}
+
+// LoadHTTPCache reads the cache at path. A missing file yields an empty cache.
+func LoadHTTPCache(path string) (*HTTPCache, error) {
+	cache := &HTTPCache{tables: make(map[int]CacheEntry)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &cache.tables); err != nil {
+		return nil, err
+	}
+	return cache, nil
+}
+
+// Save atomically writes the cache to path if it changed since it was loaded.
+func (c *HTTPCache) Save(path string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.changed {
+		return nil
+	}
+	data, err := json.MarshalIndent(c.tables, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return err
+	}
+	c.changed = false
+	return nil
+}
+
+// Validators returns the validators to send for tableID, or zero ones when
+// none are cached or local no longer matches the cached body.
+func (c *HTTPCache) Validators(tableID int, local []byte) Validators {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.tables[tableID]
+	if !ok || entry.SHA256 != contentHash(local) {
+		return Validators{}
+	}
+	return entry.Validators
+}
+
+// Remember records v as the validators of body for tableID. Responses
+// without validators drop any cached entry.
+func (c *HTTPCache) Remember(tableID int, body []byte, v Validators) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v == (Validators{}) {
+		if _, ok := c.tables[tableID]; ok {
+			delete(c.tables, tableID)
+			c.changed = true
+		}
+		return
+	}
+	entry := CacheEntry{Validators: v, SHA256: contentHash(body)}
+	if c.tables[tableID] != entry {
+		c.tables[tableID] = entry
+		c.changed = true
+	}
+}
+
+// Forget drops the cached validators of tableID.
+func (c *HTTPCache) Forget(tableID int) {
+	c.Remember(tableID, nil, Validators{})
+}
+
+func contentHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// tableFetch is the result of a possibly conditional table download.
+type tableFetch struct {
+	Body       []byte
+	Validators Validators
+	// NotModified reports a 304: the local copy is current and Body is nil.
+	NotModified bool
+}
+
+// fetchTable downloads tableID, sending the cached validators when HTTPCache
+// is set and the local copy still matches them.
+func (s *Syncer) fetchTable(tableID int) (tableFetch, error) {
+	var v Validators
+	if s.HTTPCache != nil {
+		if local, err := os.ReadFile(TablePath(s.XMLDir, tableID)); err == nil {
+			v = s.HTTPCache.Validators(tableID, local)
+		}
+	}
+	body, next, err := s.Client.FetchTableIfChanged(tableID, v)
+	if errors.Is(err, ErrNotModified) {
+		return tableFetch{NotModified: true}, nil
+	}
+	if err != nil {
+		return tableFetch{}, err
+	}
+	return tableFetch{Body: body, Validators: next}, nil
+}
+
+// remember caches the validators of a fetch whose body is now the local copy.
+func (s *Syncer) remember(tableID int, fetched tableFetch) {
+	if s.HTTPCache != nil && !fetched.NotModified {
+		s.HTTPCache.Remember(tableID, fetched.Body, fetched.Validators)
+	}
+}
@@ -0,0 +1,134 @@
+package changelogsync
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mort/internal/changelogsync/soatest"
+)
+
+func TestReconcileSendsConditionalRequests(t *testing.T) {
+	srv, syncer, _ := seedMirror(t)
+	cachePath := HTTPCachePath(syncer.StatePath)
+	cache, err := LoadHTTPCache(cachePath)
+	if err != nil {
+		t.Fatalf("LoadHTTPCache() error = %v", err)
+	}
+	syncer.HTTPCache = cache
+	if _, err := syncer.Reconcile(ReconcileOptions{Prune: true}); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if err := cache.Save(cachePath); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if srv.NotModified() != 0 {
+		t.Fatalf("NotModified() = %d after the first run, want 0", srv.NotModified())
+	}
+
+	// A fresh load must see the validators of tables 1, 2 and 5.
+	cache, err = LoadHTTPCache(cachePath)
+	if err != nil {
+		t.Fatalf("LoadHTTPCache() error = %v", err)
+	}
+	syncer.HTTPCache = cache
+	report, err := syncer.Reconcile(ReconcileOptions{Prune: true})
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if got := srv.NotModified(); got != 3 {
+		t.Fatalf("NotModified() = %d, want 3", got)
+	}
+	if report.Count(OutcomeUnchanged) != 3 || len(report.Results) != 3 {
+		t.Fatalf("results = %+v, want three unchanged", report.Results)
+	}
+}
+
+func TestReconcileIgnoresCacheForDriftedCopy(t *testing.T) {
+	srv, syncer, _ := seedMirror(t)
+	cache, err := LoadHTTPCache(HTTPCachePath(syncer.StatePath))
+	if err != nil {
+		t.Fatalf("LoadHTTPCache() error = %v", err)
+	}
+	syncer.HTTPCache = cache
+	if _, err := syncer.Reconcile(ReconcileOptions{}); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if err := os.WriteFile(TablePath(syncer.XMLDir, 1), tableXML(1, "edited"), 0o644); err != nil {
+		t.Fatalf("edit t1: %v", err)
+	}
+
+	report, err := syncer.Reconcile(ReconcileOptions{})
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if report.Results[0].TableID != 1 || report.Results[0].Outcome != OutcomeChanged {
+		t.Fatalf("t1 result = %+v, want changed", report.Results[0])
+	}
+	data, err := os.ReadFile(TablePath(syncer.XMLDir, 1))
+	if err != nil || !bytes.Equal(data, tableXML(1, "one")) {
+		t.Fatalf("t1.xml = %q, %v; want upstream restored", data, err)
+	}
+	if got := srv.NotModified(); got != 2 {
+		t.Fatalf("NotModified() = %d, want 2 (tables 2 and 5)", got)
+	}
+}
+
+func TestRunSingleTableUsesHTTPCache(t *testing.T) {
+	srv := soatest.NewServer()
+	defer srv.Close()
+	srv.SetTable(42, tableXML(42, ""))
+
+	xmlDir, stateDir := t.TempDir(), t.TempDir()
+	statePath := filepath.Join(stateDir, "changelog_state.json")
+	args := []string{"-base-url", srv.URL, "-xml-dir", xmlDir, "-state", statePath, "-archive-dir", t.TempDir(), "-table", "42"}
+	for i, want := range []string{"downloaded t42.xml\n", "t42.xml not modified upstream\n"} {
+		var stdout, stderr bytes.Buffer
+		if code := Run(args, &stdout, &stderr); code != 0 {
+			t.Fatalf("run %d: exit code = %d, stderr = %s", i, code, stderr.String())
+		}
+		if stdout.String() != want {
+			t.Fatalf("run %d: stdout = %q, want %q", i, stdout.String(), want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(xmlDir, httpCacheName)); !os.IsNotExist(err) {
+		t.Fatalf("cache written to the XML directory, stat err = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(stateDir, httpCacheName))
+	if err != nil || !strings.Contains(string(data), `"etag"`) {
+		t.Fatalf("cache = %s, %v", data, err)
+	}
+}
+
+func TestHTTPCacheRemember(t *testing.T) {
+	cache, err := LoadHTTPCache(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("LoadHTTPCache() error = %v", err)
+	}
+	body := []byte("<XTbML/>")
+	cache.Remember(1, body, Validators{ETag: `"abc"`})
+	if got := cache.Validators(1, body); got.ETag != `"abc"` {
+		t.Fatalf("Validators() = %+v", got)
+	}
+	if got := cache.Validators(1, []byte("other")); got != (Validators{}) {
+		t.Fatalf("Validators() for a different body = %+v, want none", got)
+	}
+	cache.Remember(1, body, Validators{})
+	if got := cache.Validators(1, body); got != (Validators{}) {
+		t.Fatalf("Validators() after a response without validators = %+v", got)
+	}
+}
+
+func TestRunReconcileDryRunLeavesHTTPCache(t *testing.T) {
+	srv, syncer, _ := seedMirror(t)
+	args := []string{"-base-url", srv.URL, "-xml-dir", syncer.XMLDir, "-state", syncer.StatePath, "-reconcile", "-dry-run"}
+	var stdout, stderr bytes.Buffer
+	if code := Run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("Run() exit code = %d, stderr = %s", code, stderr.String())
+	}
+	if _, err := os.Stat(HTTPCachePath(syncer.StatePath)); !os.IsNotExist(err) {
+		t.Fatalf("dry run wrote the http cache, stat err = %v", err)
+	}
+}
@@ -0,0 +1,217 @@
+package changelogsync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBaseURL is the SOA mortality table service.
+	DefaultBaseURL = "https://mort.soa.org"
+
+	changeLogPath = "/WebService.asmx/GetListOfChangeLogs"
+	tablePathFmt  = "/data/t%d.xml"
+
+	defaultPageSize = 1000
+)
+
+// Client talks to the SOA change log and table download endpoints.
+type Client struct {
+	// BaseURL is the service root; DefaultBaseURL when empty.
+	BaseURL string
+	// HTTP performs requests; a client with a 30 second timeout when nil.
+	HTTP *http.Client
+	// PageSize is the number of change log rows requested per page.
+	PageSize int
+	// Retry governs retries of failed requests; DefaultRetryPolicy when zero.
+	Retry RetryPolicy
+	// RequestsPerSecond caps the request rate across goroutines; 0 is unlimited.
+	RequestsPerSecond float64
+
+	sleep       func(time.Duration) // test hook; time.Sleep when nil
+	limiterOnce sync.Once
+	rate        *rateLimiter
+}
+
+// NewClient returns a Client for baseURL using the default HTTP client settings.
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: baseURL}
+}
+
+func (c *Client) baseURL() string {
+	if c.BaseURL == "" {
+		return DefaultBaseURL
+	}
+	return strings.TrimSuffix(c.BaseURL, "/")
+}
+
+var defaultHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTP == nil {
+		return defaultHTTPClient
+	}
+	return c.HTTP
+}
+
+// ChangeLogURL returns the change log endpoint.
+func (c *Client) ChangeLogURL() string {
+	return c.baseURL() + changeLogPath
+}
+
+// TableURL returns the XML download URL for tableID.
+func (c *Client) TableURL(tableID int) string {
+	return c.baseURL() + fmt.Sprintf(tablePathFmt, tableID)
+}
+
+// FetchEntriesSince pages through the change log, newest first, and returns
+// every entry logged after lastLogMillis. Paging stops at the first entry at or
+// before lastLogMillis or after the last page.
+func (c *Client) FetchEntriesSince(lastLogMillis int64) ([]Entry, error) {
+	return c.fetchUntil(func(e Entry) bool { return e.LogMillis() <= lastLogMillis }, nil)
+}
+
+// FetchAll returns every entry in the change log.
+func (c *Client) FetchAll() ([]Entry, error) {
+	return c.fetchUntil(func(Entry) bool { return false }, nil)
+}
+
+// FetchPending returns the entries state has not applied yet. Unlike
+// FetchEntriesSince it also revisits entries logged exactly at
+// state.LastLogMillis, skipping those recorded in state.AppliedLogIDs.
+func (c *Client) FetchPending(state *State) ([]Entry, error) {
+	return c.fetchUntil(state.behind, state.Applied)
+}
+
+// fetchUntil pages through the change log, newest first, until stop reports
+// true for an entry or the pages run out. Entries for which skip reports true
+// are dropped.
+func (c *Client) fetchUntil(stop, skip func(Entry) bool) ([]Entry, error) {
+	rows := c.PageSize
+	if rows <= 0 {
+		rows = defaultPageSize
+	}
+
+	var collected []Entry
+	for page := 1; ; page++ {
+		parsed, err := c.fetchPage(page, rows)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range parsed.D.Rows {
+			if stop(entry) {
+				return collected, nil
+			}
+			if skip != nil && skip(entry) {
+				continue
+			}
+			collected = append(collected, entry)
+		}
+		if page >= parsed.D.Total || len(parsed.D.Rows) == 0 {
+			return collected, nil
+		}
+	}
+}
+
+func (c *Client) fetchPage(page, rows int) (*changeLogResponse, error) {
+	var respBody []byte
+	err := c.withRetry(func() error {
+		body := fmt.Sprintf(`{"page":%d,"rows":%d,"sidx":"sDate","sord":"desc"}`, page, rows)
+		req, err := http.NewRequest(http.MethodPost, c.ChangeLogURL(), strings.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := c.httpClient().Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return newStatusError(resp, time.Now())
+		}
+		respBody, err = io.ReadAll(resp.Body)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed changeLogResponse
+	if err := json.Unmarshal(respBody, &parsed); err != nil {
+		return nil, fmt.Errorf("decode change log page %d: %w", page, err)
+	}
+	return &parsed, nil
+}
+
+// FetchTable returns the upstream XML for tableID, retrying transient failures
+// under c.Retry.
+func (c *Client) FetchTable(tableID int) ([]byte, error) {
+	body, _, err := c.FetchTableIfChanged(tableID, Validators{})
+	return body, err
+}
+
+// FetchTableIfChanged is FetchTable as a conditional request: the non-empty
+// fields of v are sent as If-None-Match and If-Modified-Since, and a 304
+// answer returns ErrNotModified. On success it also returns the validators
+// of the new content.
+func (c *Client) FetchTableIfChanged(tableID int, v Validators) ([]byte, Validators, error) {
+	var body []byte
+	var next Validators
+	err := c.withRetry(func() error {
+		req, err := http.NewRequest(http.MethodGet, c.TableURL(tableID), nil)
+		if err != nil {
+			return err
+		}
+		if v.ETag != "" {
+			req.Header.Set("If-None-Match", v.ETag)
+		}
+		if v.LastModified != "" {
+			req.Header.Set("If-Modified-Since", v.LastModified)
+		}
+		resp, err := c.httpClient().Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusNotModified {
+			return ErrNotModified
+		}
+		if resp.StatusCode != http.StatusOK {
+			return newStatusError(resp, time.Now())
+		}
+		next = Validators{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+		body, err = io.ReadAll(resp.Body)
+		return err
+	})
+	return body, next, err
+}
+
+// IsNotFound reports whether err is a 404 from the service.
+func IsNotFound(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound
+}
+
+// TablePath returns the local path of t<tableID>.xml inside xmlDir.
+func TablePath(xmlDir string, tableID int) string {
+	return filepath.Join(xmlDir, fmt.Sprintf("t%d.xml", tableID))
+}
+
+// RemoveTable deletes t<tableID>.xml from xmlDir; a missing file is not an error.
+func RemoveTable(tableID int, xmlDir string) error {
+	err := os.Remove(TablePath(xmlDir, tableID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,44 @@
+package changelogsync
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Entry is one row of the SOA change log.
+type Entry struct {
+	TableIdentity int    `json:"TableIdentity"`
+	TableID       int    `json:"FkXtbml"`
+	Comment       string `json:"Comment"`
+	SDate         string `json:"sDate"`
+	LogDate       string `json:"LogDate"`
+	UserID        string `json:"UserID"`
+	Action        string `json:"Action"`
+}
+
+// LogMillis parses the "/Date(ms)/" LogDate into Unix milliseconds, returning
+// 0 when the value is malformed.
+func (e Entry) LogMillis() int64 {
+	s := strings.TrimSpace(e.LogDate)
+	s = strings.TrimPrefix(s, "/Date(")
+	s = strings.TrimSuffix(s, ")/")
+	ms, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return ms
+}
+
+// IsDelete reports whether the entry removes its table upstream.
+func (e Entry) IsDelete() bool {
+	return strings.Contains(strings.ToLower(e.Action), "delete")
+}
+
+type changeLogResponse struct {
+	D struct {
+		Total   int     `json:"total"`
+		Page    int     `json:"page"`
+		Records int     `json:"records"`
+		Rows    []Entry `json:"rows"`
+	} `json:"d"`
+}
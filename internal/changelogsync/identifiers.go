@@ -0,0 +1,113 @@
+package changelogsync
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"mort/internal/xtbml"
+)
+
+// identifierPlan holds the identifiers xtbmlconvert assigns over the whole
+// mirror, so a table converted by the sync keeps the unique identifier a
+// directory conversion gives it instead of a base identifier another table
+// already uses.
+type identifierPlan struct {
+	jsonDir string
+	// assigned maps table ids to identifiers; current holds every one.
+	assigned map[int]string
+	current  map[string]bool
+}
+
+// planIdentifiers assigns identifiers over the tables in XMLDir, leaving out
+// those in removed, which the run is about to delete.
+func (s *Syncer) planIdentifiers(removed map[int]bool) (*identifierPlan, error) {
+	sources, err := xtbml.ReadIdentifierSources(s.XMLDir)
+	if err != nil {
+		return nil, fmt.Errorf("assign identifiers: %w", err)
+	}
+	kept := sources[:0]
+	ids := make(map[string]int, len(sources))
+	for _, src := range sources {
+		m := tableFileName.FindStringSubmatch(filepath.Base(src.File))
+		if m == nil {
+			continue
+		}
+		id, err := strconv.Atoi(m[1])
+		if err != nil || removed[id] {
+			continue
+		}
+		ids[src.File] = id
+		kept = append(kept, src)
+	}
+	assigned, _ := xtbml.AssignIdentifiers(kept)
+
+	plan := &identifierPlan{jsonDir: s.JSONDir, assigned: make(map[int]string, len(assigned)), current: make(map[string]bool, len(assigned))}
+	for file, identifier := range assigned {
+		plan.assigned[ids[file]] = identifier
+		plan.current[identifier] = true
+	}
+	return plan, nil
+}
+
+// convert converts t<tableID>.xml with its planned identifier. When the
+// payload it replaces carried another identifier, that one is recorded as an
+// alias so existing links still resolve.
+func (p *identifierPlan) convert(xmlDir string, tableID int) error {
+	identifier := p.assigned[tableID]
+	previous := xtbml.PayloadIdentifier(JSONPath(p.jsonDir, tableID))
+	if err := ConvertTable(xmlDir, p.jsonDir, tableID, identifier); err != nil {
+		return err
+	}
+	if previous == "" || previous == identifier || p.current[previous] {
+		return nil
+	}
+	aliases, err := xtbml.LoadAliases(p.jsonDir)
+	if err != nil {
+		return err
+	}
+	if aliases.Update(map[string]string{previous: identifier}, p.current) {
+		return aliases.Save(p.jsonDir)
+	}
+	return nil
+}
+
+// stale lists, in order, the converted tables outside skip whose payload
+// carries an identifier other than the planned one: tables that now collide
+// with a new or renamed table, or no longer collide with a removed one.
+func (p *identifierPlan) stale(skip map[int]bool) []int {
+	var ids []int
+	for id, identifier := range p.assigned {
+		if skip[id] {
+			continue
+		}
+		if previous := xtbml.PayloadIdentifier(JSONPath(p.jsonDir, id)); previous != "" && previous != identifier {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// convertTables converts the given tables with their planned identifiers and
+// then reconverts the other tables whose identifier the change moved.
+func (s *Syncer) convertTables(ids []int) error {
+	plan, err := s.planIdentifiers(nil)
+	if err != nil {
+		return err
+	}
+	converted := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if err := plan.convert(s.XMLDir, id); err != nil {
+			return fmt.Errorf("convert t%d.xml: %w", id, err)
+		}
+		converted[id] = true
+	}
+	for _, id := range plan.stale(converted) {
+		if err := plan.convert(s.XMLDir, id); err != nil {
+			return fmt.Errorf("convert t%d.xml: %w", id, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package changelogsync
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// tempPrefix names the temporary files behind atomic writes; Reconcile removes
+// any left behind by a crash.
+const tempPrefix = ".mort-tmp-"
+
+// Journal operations.
+const (
+	OpDownload   = "download"
+	OpRemove     = "remove"
+	OpConvert    = "convert"
+	OpRemoveJSON = "remove-json"
+)
+
+// JournalRecord is one line of the sync journal. Every action is logged once
+// when it starts and again with Done set when it completes. LogID is the
+// change log entry the action applies.
+type JournalRecord struct {
+	Op      string `json:"op"`
+	TableID int    `json:"table"`
+	LogID   int    `json:"log,omitempty"`
+	Done    bool   `json:"done,omitempty"`
+}
+
+// JournalPath returns the journal kept beside the state file at statePath.
+func JournalPath(statePath string) string {
+	return statePath + ".journal"
+}
+
+// journal appends records to the in-flight action log. It is safe for
+// concurrent use.
+type journal struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func createJournal(path string) (*journal, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &journal{file: f}, nil
+}
+
+func (j *journal) append(rec JournalRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, err := j.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return j.file.Sync()
+}
+
+// track journals op on the table of entry around action.
+func (j *journal) track(op string, entry Entry, action func() error) error {
+	rec := JournalRecord{Op: op, TableID: entry.TableID, LogID: entry.TableIdentity}
+	if err := j.append(rec); err != nil {
+		return fmt.Errorf("write journal: %w", err)
+	}
+	if err := action(); err != nil {
+		return err
+	}
+	rec.Done = true
+	if err := j.append(rec); err != nil {
+		return fmt.Errorf("write journal: %w", err)
+	}
+	return nil
+}
+
+func (j *journal) close() error {
+	return j.file.Close()
+}
+
+// ReadJournal loads the journal at path. A missing journal yields no records
+// and a torn final line from a crash is ignored.
+func ReadJournal(path string) ([]JournalRecord, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []JournalRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec JournalRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
+
+// Recovery summarises what an interrupted sync left behind.
+type Recovery struct {
+	// InFlight lists actions that started but never completed.
+	InFlight []JournalRecord
+	// Downloaded and Converted map tables whose download or conversion
+	// completed, and whose output is still on disk, to the change log entry
+	// it applied. A resumed sync reuses them only for that same entry.
+	Downloaded map[int]int
+	Converted  map[int]int
+	// TempFiles lists the partial files that were removed.
+	TempFiles []string
+}
+
+// Reconcile compares journal records with the files in xmlDir and jsonDir,
+// deletes partial temporary files, and reports which completed work can be
+// reused.
+func Reconcile(records []JournalRecord, xmlDir, jsonDir string) (*Recovery, error) {
+	rec := &Recovery{Downloaded: map[int]int{}, Converted: map[int]int{}}
+	pending := map[JournalRecord]bool{}
+	for _, r := range records {
+		key := JournalRecord{Op: r.Op, TableID: r.TableID, LogID: r.LogID}
+		if r.Done {
+			delete(pending, key)
+			switch r.Op {
+			case OpDownload:
+				rec.Downloaded[r.TableID] = r.LogID
+			case OpConvert:
+				rec.Converted[r.TableID] = r.LogID
+			}
+			continue
+		}
+		pending[key] = true
+		switch r.Op {
+		case OpRemove, OpDownload:
+			delete(rec.Downloaded, r.TableID)
+			delete(rec.Converted, r.TableID)
+		case OpRemoveJSON, OpConvert:
+			delete(rec.Converted, r.TableID)
+		}
+	}
+	for key := range pending {
+		rec.InFlight = append(rec.InFlight, key)
+	}
+	sort.Slice(rec.InFlight, func(i, j int) bool {
+		a, b := rec.InFlight[i], rec.InFlight[j]
+		if a.TableID != b.TableID {
+			return a.TableID < b.TableID
+		}
+		return a.Op < b.Op
+	})
+
+	for id := range rec.Downloaded {
+		if !fileExists(TablePath(xmlDir, id)) {
+			delete(rec.Downloaded, id)
+		}
+	}
+	for id := range rec.Converted {
+		if jsonDir == "" || !fileExists(JSONPath(jsonDir, id)) {
+			delete(rec.Converted, id)
+		}
+	}
+
+	for _, dir := range []string{xmlDir, jsonDir} {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasPrefix(entry.Name(), tempPrefix) {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+			rec.TempFiles = append(rec.TempFiles, path)
+		}
+	}
+	return rec, nil
+}
+
+// downloaded reports whether the journal shows entry's table as downloaded
+// for entry itself rather than an earlier change.
+func (r *Recovery) downloaded(entry Entry) bool {
+	logID, ok := r.Downloaded[entry.TableID]
+	return ok && logID == entry.TableIdentity
+}
+
+// converted is downloaded for conversions.
+func (r *Recovery) converted(entry Entry) bool {
+	logID, ok := r.Converted[entry.TableID]
+	return ok && logID == entry.TableIdentity
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,232 @@
+package changelogsync
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mort/internal/changelogsync/soatest"
+)
+
+func TestSaveStateIsAtomic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "changelog_state.json")
+	if err := SaveState(path, &State{LastLogMillis: 5, AppliedLogIDs: []int{9}}); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("state dir holds %d files, want only the state file", len(entries))
+	}
+	state, err := LoadState(path)
+	if err != nil || state.LastLogMillis != 5 || len(state.AppliedLogIDs) != 1 {
+		t.Fatalf("LoadState() = %+v, %v", state, err)
+	}
+}
+
+func TestStateAppliedAtSharedTimestamp(t *testing.T) {
+	a := Entry{TableIdentity: 1, LogDate: "/Date(1000)/"}
+	b := Entry{TableIdentity: 2, LogDate: "/Date(1000)/"}
+	older := Entry{TableIdentity: 3, LogDate: "/Date(900)/"}
+
+	state := &State{}
+	state.Checkpoint(a)
+	if !state.Applied(a) || state.Applied(b) || !state.Applied(older) {
+		t.Fatalf("after checkpointing a: %+v", state)
+	}
+	state.Checkpoint(b)
+	if !state.Applied(b) {
+		t.Fatalf("after checkpointing b: %+v", state)
+	}
+
+	legacy := &State{LastLogMillis: 1000}
+	if !legacy.Applied(a) || !legacy.Applied(b) {
+		t.Fatal("legacy state without applied ids should cover its whole timestamp")
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	xmlDir := t.TempDir()
+	jsonDir := t.TempDir()
+	for _, path := range []string{TablePath(xmlDir, 1), TablePath(xmlDir, 2), JSONPath(jsonDir, 1)} {
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatalf("seed %s: %v", path, err)
+		}
+	}
+	partial := filepath.Join(jsonDir, tempPrefix+"123.json")
+	if err := os.WriteFile(partial, []byte("{"), 0o644); err != nil {
+		t.Fatalf("seed partial: %v", err)
+	}
+
+	records := []JournalRecord{
+		{Op: OpDownload, TableID: 1, LogID: 11},
+		{Op: OpDownload, TableID: 2, LogID: 12},
+		{Op: OpDownload, TableID: 3, LogID: 13},
+		{Op: OpDownload, TableID: 1, LogID: 11, Done: true},
+		{Op: OpDownload, TableID: 3, LogID: 13, Done: true},
+		{Op: OpConvert, TableID: 1, LogID: 11},
+		{Op: OpConvert, TableID: 1, LogID: 11, Done: true},
+		{Op: OpConvert, TableID: 2, LogID: 12},
+	}
+	rec, err := Reconcile(records, xmlDir, jsonDir)
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if len(rec.Downloaded) != 1 || rec.Downloaded[1] != 11 {
+		t.Fatalf("Downloaded = %v, want only t1 at log 11 (t2 unfinished, t3 missing on disk)", rec.Downloaded)
+	}
+	if len(rec.Converted) != 1 || rec.Converted[1] != 11 {
+		t.Fatalf("Converted = %v, want only t1 at log 11", rec.Converted)
+	}
+	if !rec.downloaded(Entry{TableID: 1, TableIdentity: 11}) || rec.downloaded(Entry{TableID: 1, TableIdentity: 14}) {
+		t.Fatal("a journaled download should only be reused for the entry it applied")
+	}
+	want := []JournalRecord{{Op: OpConvert, TableID: 2, LogID: 12}, {Op: OpDownload, TableID: 2, LogID: 12}}
+	if len(rec.InFlight) != len(want) || rec.InFlight[0] != want[0] || rec.InFlight[1] != want[1] {
+		t.Fatalf("InFlight = %+v, want %+v", rec.InFlight, want)
+	}
+	if len(rec.TempFiles) != 1 || fileExists(partial) {
+		t.Fatalf("partial file not cleaned up: %v", rec.TempFiles)
+	}
+}
+
+func TestSyncRequiresResumeAfterInterruption(t *testing.T) {
+	srv := soatest.NewServer()
+	defer srv.Close()
+	srv.AddEntries(
+		soatest.Entry{TableIdentity: 1, TableID: 1, Action: "Update", LogMillis: 1000},
+		soatest.Entry{TableIdentity: 2, TableID: 2, Action: "Update", LogMillis: 1000},
+		soatest.Entry{TableIdentity: 3, TableID: 3, Action: "Update", LogMillis: 2000},
+	)
+	for _, id := range []int{1, 2, 3} {
+		srv.SetTable(id, tableXML(id, ""))
+	}
+
+	syncer, out := newTestSyncer(t, srv, 0)
+	// Simulate a crash after t1 was applied and checkpointed, while t2 was
+	// downloaded but not yet checkpointed.
+	state := &State{}
+	state.Checkpoint(Entry{TableIdentity: 1, LogDate: "/Date(1000)/"})
+	if err := SaveState(syncer.StatePath, state); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+	if err := os.MkdirAll(syncer.XMLDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(TablePath(syncer.XMLDir, 2), []byte("<XTbML/>"), 0o644); err != nil {
+		t.Fatalf("seed t2: %v", err)
+	}
+	journal := strings.Join([]string{
+		`{"op":"download","table":2,"log":2}`,
+		`{"op":"download","table":2,"log":2,"done":true}`,
+		`{"op":"download","table":3,"log":3}`,
+		`{"op":"downl`,
+	}, "\n")
+	if err := os.WriteFile(JournalPath(syncer.StatePath), []byte(journal), 0o644); err != nil {
+		t.Fatalf("seed journal: %v", err)
+	}
+
+	if _, err := syncer.Sync(); !errors.Is(err, ErrInterrupted) {
+		t.Fatalf("Sync() error = %v, want ErrInterrupted", err)
+	}
+
+	syncer.Resume = true
+	processed, err := syncer.Sync()
+	if err != nil {
+		t.Fatalf("resumed Sync() error = %v", err)
+	}
+	if processed != 2 {
+		t.Fatalf("resumed Sync() processed = %d, want 2 (t2 and t3)", processed)
+	}
+	if got := srv.CountRequests("/data/t1.xml"); got != 0 {
+		t.Fatalf("checkpointed t1 downloaded %d times", got)
+	}
+	if got := srv.CountRequests("/data/t2.xml"); got != 0 {
+		t.Fatalf("journaled t2 downloaded %d times, want reuse", got)
+	}
+	if got := srv.CountRequests("/data/t3.xml"); got != 1 {
+		t.Fatalf("interrupted t3 downloaded %d times, want 1", got)
+	}
+	if !strings.Contains(out.String(), "interrupted download of t3 will be redone") {
+		t.Fatalf("missing recovery note:\n%s", out.String())
+	}
+	if fileExists(JournalPath(syncer.StatePath)) {
+		t.Fatal("journal should be removed after a completed sync")
+	}
+	final, err := LoadState(syncer.StatePath)
+	if err != nil || final.LastLogMillis != 2000 {
+		t.Fatalf("final state = %+v, %v", final, err)
+	}
+}
+
+func TestSyncAfterCleanFailureNeedsNoResume(t *testing.T) {
+	srv := soatest.NewServer()
+	defer srv.Close()
+	srv.AddEntries(
+		soatest.Entry{TableIdentity: 1, TableID: 1, Action: "Update", LogMillis: 1000},
+		soatest.Entry{TableIdentity: 2, TableID: 2, Action: "Update", LogMillis: 2000},
+	)
+	srv.SetTable(1, tableXML(1, ""))
+
+	syncer, _ := newTestSyncer(t, srv, 0)
+	if _, err := syncer.Sync(); err == nil {
+		t.Fatal("Sync() should fail while t2.xml is missing upstream")
+	}
+	if fileExists(JournalPath(syncer.StatePath)) {
+		t.Fatal("a cleanly failed sync should not leave a journal behind")
+	}
+
+	srv.SetTable(2, tableXML(2, ""))
+	processed, err := syncer.Sync()
+	if err != nil {
+		t.Fatalf("second Sync() error = %v, want success without -resume", err)
+	}
+	if processed != 1 {
+		t.Fatalf("second Sync() processed = %d, want 1 (t2)", processed)
+	}
+	if !fileExists(TablePath(syncer.XMLDir, 2)) {
+		t.Fatal("t2.xml should be downloaded by the second run")
+	}
+}
+
+func TestResumeRedownloadsTableWithNewerEntry(t *testing.T) {
+	srv := soatest.NewServer()
+	defer srv.Close()
+	srv.AddEntries(
+		soatest.Entry{TableIdentity: 1, TableID: 1, Action: "Update", LogMillis: 1000},
+		soatest.Entry{TableIdentity: 2, TableID: 1, Action: "Update", LogMillis: 2000},
+	)
+	srv.SetTable(1, tableXML(1, "newer"))
+
+	syncer, _ := newTestSyncer(t, srv, 0)
+	syncer.Resume = true
+	for _, dir := range []string{syncer.XMLDir, filepath.Dir(syncer.StatePath)} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+	// A crashed run downloaded t1 for log #1; the change log has since gained
+	// log #2 for the same table.
+	if err := os.WriteFile(TablePath(syncer.XMLDir, 1), tableXML(1, "older"), 0o644); err != nil {
+		t.Fatalf("seed t1: %v", err)
+	}
+	journal := `{"op":"download","table":1,"log":1}` + "\n" + `{"op":"download","table":1,"log":1,"done":true}` + "\n"
+	if err := os.WriteFile(JournalPath(syncer.StatePath), []byte(journal), 0o644); err != nil {
+		t.Fatalf("seed journal: %v", err)
+	}
+
+	if _, err := syncer.Sync(); err != nil {
+		t.Fatalf("resumed Sync() error = %v", err)
+	}
+	if got := srv.CountRequests("/data/t1.xml"); got != 1 {
+		t.Fatalf("t1.xml downloaded %d times, want 1 for the newer entry", got)
+	}
+	data, err := os.ReadFile(TablePath(syncer.XMLDir, 1))
+	if err != nil || !strings.Contains(string(data), "newer") {
+		t.Fatalf("t1.xml should hold the newer revision: %v\n%s", err, data)
+	}
+}
@@ -0,0 +1,125 @@
+package changelogsync
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// Plan actions.
+const (
+	ActionDownload = "download" // table is new locally
+	ActionUpdate   = "update"   // table exists locally and is replaced
+	ActionRemove   = "remove"
+)
+
+// Plan lists what a sync would do, without touching disk or state.
+type Plan struct {
+	// Since is the state checkpoint the plan starts from.
+	Since   time.Time      `json:"since"`
+	Convert bool           `json:"convert"`
+	Steps   []PlannedEntry `json:"steps"`
+}
+
+// PlannedEntry is one change log entry and its local effect.
+type PlannedEntry struct {
+	Action   string    `json:"action"`
+	TableID  int       `json:"table"`
+	LogID    int       `json:"logId"`
+	Upstream string    `json:"upstreamAction"`
+	Comment  string    `json:"comment"`
+	UserID   string    `json:"user"`
+	Date     string    `json:"date"`
+	Logged   time.Time `json:"logged"`
+	// Superseded marks entries a later entry for the same table overrides;
+	// they are checkpointed without touching disk.
+	Superseded bool `json:"superseded,omitempty"`
+}
+
+// Plan fetches pending change log entries and describes how Sync would apply
+// them, in order.
+func (s *Syncer) Plan() (*Plan, error) {
+	state, err := LoadState(s.StatePath)
+	if err != nil {
+		return nil, fmt.Errorf("load state: %w", err)
+	}
+	entries, err := s.Client.FetchPending(state)
+	if err != nil {
+		return nil, fmt.Errorf("fetch change log: %w", err)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].LogMillis() < entries[j].LogMillis()
+	})
+
+	final := make(map[int]int, len(entries))
+	for i, entry := range entries {
+		final[entry.TableID] = i
+	}
+
+	plan := &Plan{Since: time.UnixMilli(state.LastLogMillis).UTC(), Convert: s.Convert, Steps: []PlannedEntry{}}
+	// present tracks whether each table would exist locally as the plan runs.
+	present := map[int]bool{}
+	for i, entry := range entries {
+		exists, seen := present[entry.TableID]
+		if !seen {
+			exists = fileExists(TablePath(s.XMLDir, entry.TableID))
+		}
+		action := ActionRemove
+		if !entry.IsDelete() {
+			action = ActionUpdate
+			if !exists {
+				action = ActionDownload
+			}
+		}
+		present[entry.TableID] = !entry.IsDelete()
+
+		plan.Steps = append(plan.Steps, PlannedEntry{
+			Action:     action,
+			TableID:    entry.TableID,
+			LogID:      entry.TableIdentity,
+			Upstream:   entry.Action,
+			Comment:    entry.Comment,
+			UserID:     entry.UserID,
+			Date:       entry.SDate,
+			Logged:     time.UnixMilli(entry.LogMillis()).UTC(),
+			Superseded: final[entry.TableID] != i,
+		})
+	}
+	return plan, nil
+}
+
+// WriteText renders the plan as one line per entry.
+func (p *Plan) WriteText(w io.Writer) error {
+	if len(p.Steps) == 0 {
+		_, err := fmt.Fprintln(w, "no new change log entries")
+		return err
+	}
+	fmt.Fprintf(w, "%d change log entries since %s:\n", len(p.Steps), p.Since.Format(time.RFC3339))
+	for _, step := range p.Steps {
+		note := ""
+		if step.Superseded {
+			note = " [superseded]"
+		}
+		user := step.UserID
+		if user == "" {
+			user = "unknown"
+		}
+		fmt.Fprintf(w, "  %-8s t%d.xml (log #%d on %s by %s: %s)%s\n", step.Action, step.TableID, step.LogID, step.Date, user, step.Upstream, note)
+		if step.Comment != "" {
+			fmt.Fprintf(w, "           %s\n", step.Comment)
+		}
+	}
+	if p.Convert {
+		fmt.Fprintln(w, "JSON payloads are regenerated for downloaded and updated tables and deleted for removed ones.")
+	}
+	return nil
+}
+
+// WriteJSON renders the plan as indented JSON.
+func (p *Plan) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
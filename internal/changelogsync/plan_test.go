@@ -0,0 +1,107 @@
+package changelogsync
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mort/internal/changelogsync/soatest"
+)
+
+func TestPlanDoesNotTouchDisk(t *testing.T) {
+	srv := soatest.NewServer()
+	defer srv.Close()
+	srv.AddEntries(
+		soatest.Entry{TableIdentity: 21, TableID: 1, Action: "Update", Comment: "Corrected age 90", UserID: "jdoe", SDate: "05/01/2024", LogMillis: 1000},
+		soatest.Entry{TableIdentity: 22, TableID: 2, Action: "Add", UserID: "asmith", LogMillis: 2000},
+		soatest.Entry{TableIdentity: 23, TableID: 2, Action: "Delete", LogMillis: 3000},
+	)
+
+	syncer, _ := newTestSyncer(t, srv, 0)
+	if err := os.MkdirAll(syncer.XMLDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(TablePath(syncer.XMLDir, 1), []byte("old"), 0o644); err != nil {
+		t.Fatalf("seed t1: %v", err)
+	}
+
+	plan, err := syncer.Plan()
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	want := []struct {
+		action     string
+		table      int
+		superseded bool
+	}{
+		{ActionUpdate, 1, false},
+		{ActionDownload, 2, true},
+		{ActionRemove, 2, false},
+	}
+	if len(plan.Steps) != len(want) {
+		t.Fatalf("len(Steps) = %d, want %d", len(plan.Steps), len(want))
+	}
+	for i, w := range want {
+		got := plan.Steps[i]
+		if got.Action != w.action || got.TableID != w.table || got.Superseded != w.superseded {
+			t.Fatalf("step %d = %+v, want %+v", i, got, w)
+		}
+	}
+	if plan.Steps[0].Comment != "Corrected age 90" || plan.Steps[0].UserID != "jdoe" || plan.Steps[0].Date != "05/01/2024" {
+		t.Fatalf("step 0 lost change log details: %+v", plan.Steps[0])
+	}
+
+	for _, req := range srv.Requests() {
+		if strings.HasPrefix(req, "GET /data/") {
+			t.Fatalf("plan downloaded a table: %s", req)
+		}
+	}
+	if data, _ := os.ReadFile(TablePath(syncer.XMLDir, 1)); string(data) != "old" {
+		t.Fatalf("t1.xml changed during planning: %q", data)
+	}
+	if fileExists(syncer.StatePath) || fileExists(JournalPath(syncer.StatePath)) {
+		t.Fatal("plan wrote state or journal files")
+	}
+}
+
+func TestRunDryRunJSON(t *testing.T) {
+	srv := soatest.NewServer()
+	defer srv.Close()
+	srv.AddEntries(soatest.Entry{TableIdentity: 7, TableID: 4, Action: "Add", Comment: "New table", UserID: "jdoe", LogMillis: 1000})
+
+	dir := t.TempDir()
+	args := []string{"-base-url", srv.URL, "-xml-dir", filepath.Join(dir, "xml"), "-state", filepath.Join(dir, "state.json"), "-dry-run", "-plan-format", "json"}
+	var stdout, stderr bytes.Buffer
+	if code := Run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("Run() exit code = %d, stderr = %s", code, stderr.String())
+	}
+
+	var plan Plan
+	if err := json.Unmarshal(stdout.Bytes(), &plan); err != nil {
+		t.Fatalf("decode plan: %v\n%s", err, stdout.String())
+	}
+	if len(plan.Steps) != 1 || plan.Steps[0].Action != ActionDownload || plan.Steps[0].Comment != "New table" {
+		t.Fatalf("unexpected plan: %+v", plan)
+	}
+	if fileExists(filepath.Join(dir, "xml")) {
+		t.Fatal("dry run created the xml dir")
+	}
+}
+
+func TestPlanWriteText(t *testing.T) {
+	plan := &Plan{Steps: []PlannedEntry{
+		{Action: ActionUpdate, TableID: 3, LogID: 9, Upstream: "Update", Comment: "Fixed typo", UserID: "jdoe", Date: "05/01/2024"},
+	}}
+	var buf bytes.Buffer
+	if err := plan.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+	for _, want := range []string{"update   t3.xml (log #9 on 05/01/2024 by jdoe: Update)", "Fixed typo"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("text plan missing %q:\n%s", want, buf.String())
+		}
+	}
+}
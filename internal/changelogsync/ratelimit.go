@@ -0,0 +1,48 @@
+package changelogsync
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter spaces calls to Wait at least interval apart across goroutines.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+	now      func() time.Time
+	sleep    func(time.Duration)
+}
+
+func newRateLimiter(perSecond float64, sleep func(time.Duration)) *rateLimiter {
+	l := &rateLimiter{now: time.Now, sleep: sleep}
+	if perSecond > 0 {
+		l.interval = time.Duration(float64(time.Second) / perSecond)
+	}
+	return l
+}
+
+// Wait blocks until the caller may issue its next request.
+func (l *rateLimiter) Wait() {
+	if l.interval <= 0 {
+		return
+	}
+	l.mu.Lock()
+	now := l.now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+	if wait > 0 {
+		l.sleep(wait)
+	}
+}
+
+func (c *Client) limiter() *rateLimiter {
+	c.limiterOnce.Do(func() {
+		c.rate = newRateLimiter(c.RequestsPerSecond, c.sleepFor)
+	})
+	return c.rate
+}
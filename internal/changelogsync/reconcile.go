@@ -0,0 +1,328 @@
+package changelogsync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Reconcile outcomes.
+const (
+	OutcomeMissing   = "missing"   // upstream table absent locally
+	OutcomeChanged   = "changed"   // local content hash differs from upstream
+	OutcomeUnchanged = "unchanged" // local copy matches upstream
+	OutcomeStale     = "stale"     // local table no longer exists upstream
+	OutcomeFailed    = "failed"
+)
+
+// ReconcileResult is the outcome for one table.
+type ReconcileResult struct {
+	TableID int
+	Outcome string
+	// Applied is true when the local mirror was changed to match upstream.
+	Applied bool
+	Err     error
+
+	// record is the log entry of an applied change.
+	record *LogRecord
+}
+
+// ReconcileReport summarises a reconciliation run, ordered by table id.
+type ReconcileReport struct {
+	Results []ReconcileResult
+}
+
+// Count returns how many results have outcome.
+func (r *ReconcileReport) Count(outcome string) int {
+	n := 0
+	for _, res := range r.Results {
+		if res.Outcome == outcome {
+			n++
+		}
+	}
+	return n
+}
+
+// ReconcileOptions controls what Reconcile changes on disk.
+type ReconcileOptions struct {
+	// Prune deletes stale local tables instead of only reporting them.
+	Prune bool
+	// DryRun compares without writing anything.
+	DryRun bool
+}
+
+var tableFileName = regexp.MustCompile(`^t(\d+)\.xml$`)
+
+// localTables lists the table ids of every tN.xml in dir.
+func localTables(dir string) ([]int, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ids []int
+	for _, entry := range entries {
+		m := tableFileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || m == nil {
+			continue
+		}
+		id, err := strconv.Atoi(m[1])
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Reconcile mirrors the upstream catalogue into XMLDir regardless of the
+// saved state. The catalogue is every table in the full change log whose
+// newest entry is not a delete, plus any local table the change log never
+// mentions that still downloads. Each upstream table is fetched, conditionally
+// when HTTPCache holds validators for the local copy, and compared with the
+// local copy by SHA-256; missing or differing tables are written and,
+// with Convert, converted. Local tables that no longer exist upstream are
+// reported as stale and removed with opts.Prune. When nothing failed and
+// DryRun is off, the state is checkpointed at the newest change log entry and
+// any journal is discarded.
+func (s *Syncer) Reconcile(opts ReconcileOptions) (*ReconcileReport, error) {
+	out := s.Out
+	if out == nil {
+		out = io.Discard
+	}
+
+	entries, err := s.Client.FetchAll()
+	if err != nil {
+		return nil, fmt.Errorf("fetch change log: %w", err)
+	}
+	s.changeLog.reset(entries)
+	latest := make(map[int]Entry, len(entries))
+	for _, entry := range entries {
+		if cur, ok := latest[entry.TableID]; !ok || entry.LogMillis() >= cur.LogMillis() {
+			latest[entry.TableID] = entry
+		}
+	}
+
+	local, err := localTables(s.XMLDir)
+	if err != nil {
+		return nil, fmt.Errorf("read xml dir: %w", err)
+	}
+	isLocal := make(map[int]bool, len(local))
+	for _, id := range local {
+		isLocal[id] = true
+	}
+
+	ids := make([]int, 0, len(latest)+len(local))
+	for id := range latest {
+		ids = append(ids, id)
+	}
+	for _, id := range local {
+		if _, ok := latest[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+
+	results := make([]ReconcileResult, len(ids))
+	sem := make(chan struct{}, max(s.Concurrency, 1))
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			var logged *Entry
+			if entry, ok := latest[id]; ok {
+				logged = &entry
+			}
+			results[i] = s.reconcileTable(id, logged, isLocal[id], opts)
+		}()
+	}
+	wg.Wait()
+	if s.Convert && !opts.DryRun {
+		if err := s.convertReconciled(results); err != nil {
+			return nil, err
+		}
+	}
+
+	report := &ReconcileReport{}
+	for _, res := range results {
+		if res.Outcome == "" {
+			continue
+		}
+		report.Results = append(report.Results, res)
+		writeReconcileLine(out, res, opts)
+		if res.record != nil {
+			s.Log.record(*res.record)
+		}
+	}
+	if report.Count(OutcomeFailed) > 0 || opts.DryRun {
+		return report, nil
+	}
+
+	state := &State{}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].LogMillis() < entries[j].LogMillis()
+	})
+	for _, entry := range entries {
+		state.Checkpoint(entry)
+	}
+	if err := SaveState(s.StatePath, state); err != nil {
+		return report, fmt.Errorf("save state: %w", err)
+	}
+	return report, removeJournal(s.StatePath)
+}
+
+func (s *Syncer) reconcileTable(id int, logged *Entry, local bool, opts ReconcileOptions) ReconcileResult {
+	res := ReconcileResult{TableID: id}
+	deletedUpstream := logged != nil && logged.IsDelete()
+	var fetched tableFetch
+	if !deletedUpstream {
+		var err error
+		fetched, err = s.fetchTable(id)
+		switch {
+		case IsNotFound(err):
+			deletedUpstream = true
+		case err != nil:
+			res.Outcome, res.Err = OutcomeFailed, err
+			return res
+		case fetched.NotModified:
+			res.Outcome = OutcomeUnchanged
+			return res
+		}
+	}
+	body := fetched.Body
+
+	if deletedUpstream {
+		if !local {
+			return ReconcileResult{}
+		}
+		res.Outcome = OutcomeStale
+		if opts.Prune && !opts.DryRun {
+			before := readFileState(TablePath(s.XMLDir, id))
+			if err := s.removeStale(id); err != nil {
+				res.Outcome, res.Err = OutcomeFailed, err
+				return res
+			}
+			res.Applied = true
+			rec := newLogRecord(ModeReconcile, ActionRemove, id, logged)
+			rec.Before = before
+			res.record = &rec
+		}
+		return res
+	}
+
+	res.Outcome = OutcomeMissing
+	var before *FileState
+	if local {
+		current, err := os.ReadFile(TablePath(s.XMLDir, id))
+		if err != nil {
+			res.Outcome, res.Err = OutcomeFailed, err
+			return res
+		}
+		sum := sha256.Sum256(current)
+		before = &FileState{Bytes: int64(len(current)), SHA256: hex.EncodeToString(sum[:])}
+		if sum == sha256.Sum256(body) {
+			if !opts.DryRun {
+				s.remember(id, fetched)
+			}
+			res.Outcome = OutcomeUnchanged
+			return res
+		}
+		res.Outcome = OutcomeChanged
+	}
+	if opts.DryRun {
+		return res
+	}
+	if err := s.storeTable(id, body, logged, archiveReconcile); err != nil {
+		res.Outcome, res.Err = OutcomeFailed, err
+		return res
+	}
+	s.remember(id, fetched)
+	res.Applied = true
+	action := ActionUpdate
+	if before == nil {
+		action = ActionDownload
+	}
+	rec := newLogRecord(ModeReconcile, action, id, logged)
+	rec.Before = before
+	rec.After = readFileState(TablePath(s.XMLDir, id))
+	rec.Converted = s.Convert
+	res.record = &rec
+	return res
+}
+
+// convertReconciled converts the tables reconciliation wrote, with
+// identifiers planned over the reconciled mirror, and fails the result of any
+// table that does not convert. Other tables whose identifier moved are
+// reconverted too.
+func (s *Syncer) convertReconciled(results []ReconcileResult) error {
+	plan, err := s.planIdentifiers(nil)
+	if err != nil {
+		return err
+	}
+	converted := make(map[int]bool)
+	for i := range results {
+		res := &results[i]
+		if !res.Applied || res.Outcome == OutcomeStale {
+			continue
+		}
+		converted[res.TableID] = true
+		if err := plan.convert(s.XMLDir, res.TableID); err != nil {
+			res.Outcome, res.Err = OutcomeFailed, fmt.Errorf("convert: %w", err)
+			res.Applied, res.record = false, nil
+		}
+	}
+	for _, id := range plan.stale(converted) {
+		if err := plan.convert(s.XMLDir, id); err != nil {
+			return fmt.Errorf("convert t%d.xml: %w", id, err)
+		}
+	}
+	return nil
+}
+
+func (s *Syncer) removeStale(id int) error {
+	if err := RemoveTable(id, s.XMLDir); err != nil {
+		return err
+	}
+	if s.HTTPCache != nil {
+		s.HTTPCache.Forget(id)
+	}
+	if s.Convert {
+		return removeJSON(s.JSONDir, id)
+	}
+	return nil
+}
+
+func writeReconcileLine(w io.Writer, res ReconcileResult, opts ReconcileOptions) {
+	name := fmt.Sprintf("t%d.xml", res.TableID)
+	switch {
+	case res.Outcome == OutcomeUnchanged:
+	case res.Outcome == OutcomeFailed:
+		fmt.Fprintf(w, "failed %s: %v\n", name, res.Err)
+	case res.Outcome == OutcomeStale && res.Applied:
+		fmt.Fprintf(w, "removed %s (not upstream)\n", name)
+	case res.Outcome == OutcomeStale && opts.Prune:
+		fmt.Fprintf(w, "would remove %s (not upstream)\n", name)
+	case res.Outcome == OutcomeStale:
+		fmt.Fprintf(w, "stale %s (not upstream; -prune deletes it)\n", name)
+	case res.Outcome == OutcomeMissing && res.Applied:
+		fmt.Fprintf(w, "downloaded %s (missing locally)\n", name)
+	case res.Outcome == OutcomeMissing:
+		fmt.Fprintf(w, "would download %s (missing locally)\n", name)
+	case res.Applied:
+		fmt.Fprintf(w, "updated %s (content differs)\n", name)
+	default:
+		fmt.Fprintf(w, "would update %s (content differs)\n", name)
+	}
+}
@@ -0,0 +1,161 @@
+package changelogsync
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mort/internal/changelogsync/soatest"
+)
+
+// seedMirror sets up an upstream with tables 1-3 (3 deleted upstream) plus an
+// unlogged table 5, and a local mirror that has drifted from it.
+func seedMirror(t *testing.T) (*soatest.Server, *Syncer, *strings.Builder) {
+	t.Helper()
+	srv := soatest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.AddEntries(
+		soatest.Entry{TableIdentity: 1, TableID: 1, Action: "Add", LogMillis: 1000},
+		soatest.Entry{TableIdentity: 2, TableID: 2, Action: "Add", LogMillis: 2000},
+		soatest.Entry{TableIdentity: 3, TableID: 3, Action: "Add", LogMillis: 3000},
+		soatest.Entry{TableIdentity: 4, TableID: 3, Action: "Delete", LogMillis: 4000},
+	)
+	srv.SetTable(1, tableXML(1, "one"))
+	srv.SetTable(2, tableXML(2, "two v2"))
+	srv.SetTable(5, tableXML(5, "five"))
+
+	syncer, out := newTestSyncer(t, srv, 2)
+	if err := os.MkdirAll(syncer.XMLDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	local := map[int][]byte{
+		2: tableXML(2, "two v1"), // changed upstream
+		3: tableXML(3, "three"),  // deleted upstream
+		4: tableXML(4, "four"),   // never logged, gone upstream
+		5: tableXML(5, "five"),   // never logged, identical upstream
+	}
+	for id, body := range local {
+		if err := os.WriteFile(TablePath(syncer.XMLDir, id), body, 0o644); err != nil {
+			t.Fatalf("seed t%d: %v", id, err)
+		}
+	}
+	return srv, syncer, out
+}
+
+func TestReconcileRepairsDrift(t *testing.T) {
+	_, syncer, out := seedMirror(t)
+	if err := os.MkdirAll(filepath.Dir(syncer.StatePath), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(JournalPath(syncer.StatePath), []byte(`{"op":"download","table":2}`), 0o644); err != nil {
+		t.Fatalf("seed journal: %v", err)
+	}
+
+	report, err := syncer.Reconcile(ReconcileOptions{})
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	got := map[int]string{}
+	for _, res := range report.Results {
+		got[res.TableID] = res.Outcome
+	}
+	want := map[int]string{1: OutcomeMissing, 2: OutcomeChanged, 3: OutcomeStale, 4: OutcomeStale, 5: OutcomeUnchanged}
+	if len(got) != len(want) {
+		t.Fatalf("outcomes = %v, want %v", got, want)
+	}
+	for id, outcome := range want {
+		if got[id] != outcome {
+			t.Fatalf("t%d outcome = %q, want %q (all: %v)", id, got[id], outcome, got)
+		}
+	}
+
+	for id, body := range map[int][]byte{1: tableXML(1, "one"), 2: tableXML(2, "two v2")} {
+		data, err := os.ReadFile(TablePath(syncer.XMLDir, id))
+		if err != nil || !bytes.Equal(data, body) {
+			t.Fatalf("t%d.xml = %q, %v; want %q", id, data, err, body)
+		}
+	}
+	if !fileExists(TablePath(syncer.XMLDir, 3)) {
+		t.Fatal("stale t3.xml removed without -prune")
+	}
+	if !strings.Contains(out.String(), "stale t3.xml") {
+		t.Fatalf("missing stale report:\n%s", out.String())
+	}
+
+	state, err := LoadState(syncer.StatePath)
+	if err != nil || state.LastLogMillis != 4000 {
+		t.Fatalf("state = %+v, %v; want checkpoint at 4000", state, err)
+	}
+	if fileExists(JournalPath(syncer.StatePath)) {
+		t.Fatal("reconcile should discard a stale journal")
+	}
+}
+
+func TestReconcilePrune(t *testing.T) {
+	_, syncer, _ := seedMirror(t)
+	if _, err := syncer.Reconcile(ReconcileOptions{Prune: true}); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	for _, id := range []int{3, 4} {
+		if fileExists(TablePath(syncer.XMLDir, id)) {
+			t.Fatalf("t%d.xml should be pruned", id)
+		}
+	}
+	if !fileExists(TablePath(syncer.XMLDir, 5)) {
+		t.Fatal("t5.xml still exists upstream and must be kept")
+	}
+}
+
+func TestReconcileDryRun(t *testing.T) {
+	_, syncer, out := seedMirror(t)
+	before, err := os.ReadDir(syncer.XMLDir)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+
+	report, err := syncer.Reconcile(ReconcileOptions{Prune: true, DryRun: true})
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if report.Count(OutcomeMissing) != 1 || report.Count(OutcomeChanged) != 1 || report.Count(OutcomeStale) != 2 {
+		t.Fatalf("unexpected report: %+v", report.Results)
+	}
+	after, err := os.ReadDir(syncer.XMLDir)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	if len(after) != len(before) {
+		t.Fatalf("dry run changed xml dir: %d -> %d files", len(before), len(after))
+	}
+	if data, _ := os.ReadFile(TablePath(syncer.XMLDir, 2)); !bytes.Equal(data, tableXML(2, "two v1")) {
+		t.Fatalf("dry run rewrote t2.xml: %q", data)
+	}
+	if fileExists(syncer.StatePath) {
+		t.Fatal("dry run wrote the state file")
+	}
+	if !strings.Contains(out.String(), "would remove t4.xml") {
+		t.Fatalf("missing dry-run line:\n%s", out.String())
+	}
+}
+
+func TestRunReconcileReportsFailures(t *testing.T) {
+	srv := soatest.NewServer()
+	defer srv.Close()
+	srv.AddEntries(soatest.Entry{TableIdentity: 1, TableID: 1, Action: "Add", LogMillis: 1000})
+	srv.FailNext("/data/t1.xml", 400)
+
+	dir := t.TempDir()
+	args := []string{"-base-url", srv.URL, "-xml-dir", filepath.Join(dir, "xml"), "-state", filepath.Join(dir, "state.json"), "-archive-dir", filepath.Join(dir, "archive"), "-reconcile"}
+	var stdout, stderr bytes.Buffer
+	if code := Run(args, &stdout, &stderr); code != 1 {
+		t.Fatalf("Run() exit code = %d, want 1", code)
+	}
+	if !strings.Contains(stdout.String(), "1 failed") {
+		t.Fatalf("stdout = %s", stdout.String())
+	}
+	if fileExists(filepath.Join(dir, "state.json")) {
+		t.Fatal("state must not advance when reconciliation fails")
+	}
+}
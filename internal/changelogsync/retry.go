@@ -0,0 +1,149 @@
+package changelogsync
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried. Delays grow
+// exponentially from BaseDelay up to MaxDelay; each delay is jittered to
+// between half and all of its nominal value.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of tries per request; 1 disables retries.
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// DefaultRetryPolicy is used when Client.Retry is the zero value.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+}
+
+// backoff returns the jittered delay before retry number attempt (1-based).
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int64N(int64(delay-half)+1))
+}
+
+// StatusError reports a non-200 response from the SOA service.
+type StatusError struct {
+	StatusCode int
+	Status     string
+	Body       string
+	// RetryAfter is the delay requested by a Retry-After header, if any.
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %s: %s", e.Status, e.Body)
+}
+
+// Temporary reports whether the status is worth retrying: 429 or any 5xx.
+func (e *StatusError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+func newStatusError(resp *http.Response, now time.Time) *StatusError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return &StatusError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       strings.TrimSpace(string(body)),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), now),
+	}
+}
+
+// parseRetryAfter accepts both delay-seconds and HTTP-date forms.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// isRetryable classifies transient failures: 429/5xx responses, network and
+// timeout errors, and connections cut off mid-response.
+func isRetryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Temporary()
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET)
+}
+
+// withRetry runs attempt under the client's retry policy and rate limiter.
+func (c *Client) withRetry(attempt func() error) error {
+	policy := c.Retry
+	if policy == (RetryPolicy{}) {
+		policy = DefaultRetryPolicy
+	}
+	maxAttempts := max(policy.MaxAttempts, 1)
+
+	var err error
+	for try := 1; ; try++ {
+		c.limiter().Wait()
+		err = attempt()
+		if err == nil {
+			return nil
+		}
+		if try >= maxAttempts || !isRetryable(err) {
+			break
+		}
+		delay := policy.backoff(try)
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.RetryAfter > delay {
+			delay = statusErr.RetryAfter
+		}
+		c.sleepFor(delay)
+	}
+	if maxAttempts > 1 && isRetryable(err) {
+		return fmt.Errorf("giving up after %d attempts: %w", maxAttempts, err)
+	}
+	return err
+}
+
+func (c *Client) sleepFor(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	if c.sleep != nil {
+		c.sleep(d)
+		return
+	}
+	time.Sleep(d)
+}
@@ -0,0 +1,136 @@
+package changelogsync
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mort/internal/changelogsync/soatest"
+)
+
+// newTestClient returns a client for srv whose retries never sleep.
+func newTestClient(srv *soatest.Server, pageSize int) *Client {
+	return &Client{
+		BaseURL:  srv.URL,
+		HTTP:     srv.Client(),
+		PageSize: pageSize,
+		sleep:    func(time.Duration) {},
+	}
+}
+
+type sleepRecorder struct {
+	mu     sync.Mutex
+	delays []time.Duration
+}
+
+func (r *sleepRecorder) sleep(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.delays = append(r.delays, d)
+}
+
+func TestDownloadRetriesTransientStatuses(t *testing.T) {
+	srv := soatest.NewServer()
+	defer srv.Close()
+	srv.AddEntries(soatest.Entry{TableID: 5, Action: "Update", LogMillis: 1000})
+	srv.SetTable(5, tableXML(5, ""))
+	srv.FailNext("/data/t5.xml", http.StatusServiceUnavailable, http.StatusBadGateway)
+	srv.ThrottleNext("/data/t5.xml", "7")
+
+	var rec sleepRecorder
+	syncer, _ := newTestSyncer(t, srv, 0)
+	syncer.Client.sleep = rec.sleep
+	syncer.Client.Retry = RetryPolicy{MaxAttempts: 4, BaseDelay: 10 * time.Millisecond, MaxDelay: time.Second}
+
+	if _, err := syncer.Sync(); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if _, err := os.Stat(TablePath(syncer.XMLDir, 5)); err != nil {
+		t.Fatalf("expected t5.xml: %v", err)
+	}
+	if got := srv.CountRequests("/data/t5.xml"); got != 4 {
+		t.Fatalf("requests = %d, want 4", got)
+	}
+	if len(rec.delays) != 3 {
+		t.Fatalf("sleeps = %v, want 3", rec.delays)
+	}
+	if rec.delays[2] != 7*time.Second {
+		t.Fatalf("Retry-After delay = %v, want 7s", rec.delays[2])
+	}
+}
+
+func TestDownloadDoesNotRetryClientErrors(t *testing.T) {
+	srv := soatest.NewServer()
+	defer srv.Close()
+
+	srv.AddEntries(soatest.Entry{TableID: 9, Action: "Update", LogMillis: 1000})
+
+	syncer, _ := newTestSyncer(t, srv, 0)
+	_, err := syncer.Sync()
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("Sync() error = %v, want 404", err)
+	}
+	if got := srv.CountRequests("/data/t9.xml"); got != 1 {
+		t.Fatalf("requests = %d, want 1", got)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	tests := []struct {
+		attempt int
+		nominal time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{9, time.Second},
+	}
+	for _, tt := range tests {
+		for range 20 {
+			got := policy.backoff(tt.attempt)
+			if got < tt.nominal/2 || got > tt.nominal {
+				t.Fatalf("backoff(%d) = %v, want within [%v, %v]", tt.attempt, got, tt.nominal/2, tt.nominal)
+			}
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{"-1", 0},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Fatalf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimiterSpacesRequests(t *testing.T) {
+	var rec sleepRecorder
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	limiter := newRateLimiter(2, rec.sleep)
+	limiter.now = func() time.Time { return now }
+
+	for range 3 {
+		limiter.Wait()
+	}
+	want := []time.Duration{500 * time.Millisecond, time.Second}
+	if len(rec.delays) != len(want) || rec.delays[0] != want[0] || rec.delays[1] != want[1] {
+		t.Fatalf("delays = %v, want %v", rec.delays, want)
+	}
+}
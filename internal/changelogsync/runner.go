@@ -0,0 +1,222 @@
+package changelogsync
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"mort/internal/tablearchive"
+)
+
+// Run executes the changelogsync CLI with the provided arguments.
+func Run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("changelogsync", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	statePath := fs.String("state", filepath.Join("json", "changelog_state.json"), "path to changelog state file")
+	xmlDir := fs.String("xml-dir", "xml", "directory where XML files are stored")
+	singleID := fs.Int("table", 0, "fetch a single table id and skip changelog scan")
+	jsonDir := fs.String("json-dir", "json", "directory for converted JSON tables")
+	convert := fs.Bool("convert", false, "convert changed tables into -json-dir and delete JSON for removed tables")
+	dryRun := fs.Bool("dry-run", false, "print the sync plan without touching disk or state")
+	planFormat := fs.String("plan-format", "text", "dry-run output format: text or json")
+	reconcile := fs.Bool("reconcile", false, "compare xml/ with the full upstream catalogue by content hash and repair differences")
+	prune := fs.Bool("prune", false, "with -reconcile, delete local tables that no longer exist upstream")
+	archiveDir := fs.String("archive-dir", "archive", "archive every downloaded revision here (empty disables)")
+	quarantineDir := fs.String("quarantine-dir", "quarantine", "keep downloads that fail integrity checks here (empty discards them)")
+	cachePath := fs.String("http-cache", "", "ETag/Last-Modified cache for -reconcile and -table (default "+httpCacheName+" beside -state)")
+	logPath := fs.String("log", "", "append a JSON line per applied change to this file")
+	summaryPath := fs.String("summary", "", "write a Markdown summary of the run to this file (- for stdout)")
+	resume := fs.Bool("resume", false, "reconcile the journal of an interrupted sync and continue from its last checkpoint")
+	baseURL := fs.String("base-url", DefaultBaseURL, "SOA mortality table service root")
+	attempts := fs.Int("retries", DefaultRetryPolicy.MaxAttempts, "attempts per request before giving up (1 disables retries)")
+	rate := fs.Float64("rate", 4, "maximum requests per second (0 for unlimited)")
+	concurrency := fs.Int("concurrency", 4, "maximum concurrent table downloads")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	client := NewClient(*baseURL)
+	client.Retry = DefaultRetryPolicy
+	client.Retry.MaxAttempts = *attempts
+	client.RequestsPerSecond = *rate
+	if *cachePath == "" {
+		*cachePath = HTTPCachePath(*statePath)
+	}
+
+	if *singleID > 0 {
+		if *dryRun {
+			fmt.Fprintf(stdout, "would download t%d.xml from %s\n", *singleID, client.TableURL(*singleID))
+			return 0
+		}
+		cache, err := LoadHTTPCache(*cachePath)
+		if err != nil {
+			fmt.Fprintf(stderr, "failed to load http cache: %v\n", err)
+			return 1
+		}
+		single := &Syncer{Client: client, XMLDir: *xmlDir, Archive: openArchive(*archiveDir), QuarantineDir: *quarantineDir, HTTPCache: cache}
+		fetched, err := single.fetchTable(*singleID)
+		if err == nil && !fetched.NotModified {
+			err = single.storeTable(*singleID, fetched.Body, nil, archiveManual)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "failed to download table %d: %v\n", *singleID, err)
+			return 1
+		}
+		if fetched.NotModified {
+			fmt.Fprintf(stdout, "t%d.xml not modified upstream\n", *singleID)
+		} else {
+			single.remember(*singleID, fetched)
+			if err := cache.Save(*cachePath); err != nil {
+				fmt.Fprintf(stderr, "failed to save http cache: %v\n", err)
+				return 1
+			}
+			fmt.Fprintf(stdout, "downloaded t%d.xml\n", *singleID)
+		}
+		if *convert {
+			single.JSONDir = *jsonDir
+			if err := single.convertTables([]int{*singleID}); err != nil {
+				fmt.Fprintf(stderr, "failed to convert table %d: %v\n", *singleID, err)
+				return 1
+			}
+			fmt.Fprintf(stdout, "converted t%d.xml -> %s\n", *singleID, JSONPath(*jsonDir, *singleID))
+		}
+		return 0
+	}
+
+	syncer := &Syncer{Client: client, XMLDir: *xmlDir, StatePath: *statePath, Convert: *convert, JSONDir: *jsonDir, Concurrency: *concurrency, Resume: *resume, Archive: openArchive(*archiveDir), QuarantineDir: *quarantineDir, Out: stdout}
+	if *dryRun && !*reconcile {
+		return runDryRun(syncer, *planFormat, stdout, stderr)
+	}
+
+	if *logPath != "" || *summaryPath != "" {
+		var logOut io.Writer
+		if *logPath != "" {
+			f, err := os.OpenFile(*logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+			if err != nil {
+				fmt.Fprintf(stderr, "failed to open log: %v\n", err)
+				return 1
+			}
+			defer f.Close()
+			logOut = f
+		}
+		syncer.Log = NewSyncLog(logOut)
+	}
+	summary := &Summary{Mode: ModeSync, Started: time.Now().UTC()}
+
+	var runErr error
+	if *reconcile {
+		summary.Mode = ModeReconcile
+		cache, err := LoadHTTPCache(*cachePath)
+		if err != nil {
+			fmt.Fprintf(stderr, "failed to load http cache: %v\n", err)
+			return 1
+		}
+		syncer.HTTPCache = cache
+		runErr = runReconcile(syncer, ReconcileOptions{Prune: *prune, DryRun: *dryRun}, stdout, stderr)
+		if !*dryRun {
+			if err := cache.Save(*cachePath); err != nil {
+				fmt.Fprintf(stderr, "failed to save http cache: %v\n", err)
+				return 1
+			}
+		}
+	} else {
+		runErr = runSync(syncer, stdout, stderr)
+	}
+
+	code := 0
+	if runErr != nil {
+		code = 1
+	}
+	if syncer.Log != nil {
+		if err := syncer.Log.Err(); err != nil {
+			fmt.Fprintf(stderr, "failed to write log: %v\n", err)
+			code = 1
+		}
+		summary.Finished = time.Now().UTC()
+		summary.Records = syncer.Log.Records()
+		summary.Err = runErr
+		if *summaryPath != "" {
+			if err := writeSummary(*summaryPath, summary, stdout); err != nil {
+				fmt.Fprintf(stderr, "failed to write summary: %v\n", err)
+				code = 1
+			}
+		}
+	}
+	return code
+}
+
+func runSync(syncer *Syncer, stdout, stderr io.Writer) error {
+	processed, err := syncer.Sync()
+	if err != nil {
+		fmt.Fprintf(stderr, "sync failed: %v\n", err)
+		return err
+	}
+	if processed == 0 {
+		fmt.Fprintln(stdout, "no new change log entries")
+		return nil
+	}
+	fmt.Fprintf(stdout, "processed %d entries\n", processed)
+	return nil
+}
+
+// writeSummary renders summary as Markdown to path, or to stdout for "-".
+func writeSummary(path string, summary *Summary, stdout io.Writer) error {
+	if path == "-" {
+		return summary.WriteMarkdown(stdout)
+	}
+	var buf bytes.Buffer
+	if err := summary.WriteMarkdown(&buf); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, buf.Bytes())
+}
+
+func runDryRun(syncer *Syncer, format string, stdout, stderr io.Writer) int {
+	if format != "text" && format != "json" {
+		fmt.Fprintf(stderr, "unknown plan format %q (want text or json)\n", format)
+		return 2
+	}
+	plan, err := syncer.Plan()
+	if err != nil {
+		fmt.Fprintf(stderr, "plan failed: %v\n", err)
+		return 1
+	}
+	if format == "json" {
+		err = plan.WriteJSON(stdout)
+	} else {
+		err = plan.WriteText(stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "plan failed: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func runReconcile(syncer *Syncer, opts ReconcileOptions, stdout, stderr io.Writer) error {
+	report, err := syncer.Reconcile(opts)
+	if err != nil {
+		fmt.Fprintf(stderr, "reconcile failed: %v\n", err)
+		return err
+	}
+	fmt.Fprintf(stdout, "reconciled %d tables: %d missing, %d changed, %d unchanged, %d stale, %d failed\n",
+		len(report.Results), report.Count(OutcomeMissing), report.Count(OutcomeChanged),
+		report.Count(OutcomeUnchanged), report.Count(OutcomeStale), report.Count(OutcomeFailed))
+	if n := report.Count(OutcomeFailed); n > 0 {
+		return fmt.Errorf("%d tables failed to reconcile", n)
+	}
+	return nil
+}
+
+func openArchive(dir string) *tablearchive.Archive {
+	if dir == "" {
+		return nil
+	}
+	return tablearchive.Open(dir)
+}
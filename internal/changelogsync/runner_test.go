@@ -0,0 +1,89 @@
+package changelogsync
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"mort/internal/changelogsync/soatest"
+)
+
+func TestRunSingleTable(t *testing.T) {
+	srv := soatest.NewServer()
+	defer srv.Close()
+	srv.SetTable(42, tableXML(42, ""))
+
+	xmlDir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"-base-url", srv.URL, "-xml-dir", xmlDir, "-state", filepath.Join(t.TempDir(), "state.json"), "-archive-dir", t.TempDir(), "-quarantine-dir", t.TempDir(), "-table", "42"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("Run() exit code = %d, stderr = %s", code, stderr.String())
+	}
+	if _, err := os.Stat(filepath.Join(xmlDir, "t42.xml")); err != nil {
+		t.Fatalf("expected t42.xml: %v", err)
+	}
+}
+
+func TestRunNoEntries(t *testing.T) {
+	srv := soatest.NewServer()
+	defer srv.Close()
+
+	dir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"-base-url", srv.URL, "-xml-dir", dir, "-archive-dir", t.TempDir(), "-quarantine-dir", t.TempDir(), "-state", filepath.Join(dir, "state.json")}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("Run() exit code = %d, stderr = %s", code, stderr.String())
+	}
+	if stdout.String() != "no new change log entries\n" {
+		t.Fatalf("stdout = %q", stdout.String())
+	}
+}
+
+func TestRunFailure(t *testing.T) {
+	srv := soatest.NewServer()
+	defer srv.Close()
+
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"-base-url", srv.URL, "-xml-dir", t.TempDir(), "-state", filepath.Join(t.TempDir(), "state.json"), "-archive-dir", t.TempDir(), "-quarantine-dir", t.TempDir(), "-table", "7"}, &stdout, &stderr)
+	if code != 1 {
+		t.Fatalf("Run() exit code = %d, want 1", code)
+	}
+	if stderr.Len() == 0 {
+		t.Fatal("expected stderr output")
+	}
+}
+
+func TestRunQuarantinesIntoDefaultDir(t *testing.T) {
+	srv := soatest.NewServer()
+	defer srv.Close()
+	srv.SetTable(42, []byte("<html><body>Maintenance</body></html>"))
+
+	t.Chdir(t.TempDir())
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"-base-url", srv.URL, "-archive-dir", t.TempDir(), "-table", "42"}, &stdout, &stderr)
+	if code != 1 {
+		t.Fatalf("Run() exit code = %d, want 1", code)
+	}
+	kept, _ := filepath.Glob(filepath.Join("quarantine", "t42-*.xml"))
+	if len(kept) != 1 {
+		t.Fatalf("quarantined files = %v, want one under quarantine/", kept)
+	}
+}
+
+func TestRunArchivesIntoDefaultDir(t *testing.T) {
+	srv := soatest.NewServer()
+	defer srv.Close()
+	srv.SetTable(42, tableXML(42, ""))
+
+	t.Chdir(t.TempDir())
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"-base-url", srv.URL, "-table", "42"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("Run() exit code = %d, stderr = %s", code, stderr.String())
+	}
+	entries, err := os.ReadDir("archive")
+	if err != nil || len(entries) == 0 {
+		t.Fatalf("archive/ entries = %v, %v; want the downloaded revision", entries, err)
+	}
+}
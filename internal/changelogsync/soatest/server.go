@@ -0,0 +1,269 @@
+// Package soatest provides an in-process fake of the SOA mortality table
+// service for offline changelogsync tests.
+package soatest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is a change log row served by the fake. LogMillis is rendered as the
+// service's "/Date(ms)/" LogDate and sDate is derived from it when empty.
+type Entry struct {
+	TableIdentity int
+	TableID       int
+	Action        string
+	Comment       string
+	UserID        string
+	SDate         string
+	LogMillis     int64
+}
+
+// Server serves GetListOfChangeLogs pages (newest first, honouring the page
+// and rows request fields) and /data/t<N>.xml downloads.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	entries  []Entry
+	tables   map[int]table
+	requests []string
+	// notModified counts conditional table requests answered with 304.
+	notModified int
+	failures    map[string][]failure
+}
+
+type table struct {
+	body     []byte
+	modified time.Time
+}
+
+type failure struct {
+	status     int
+	retryAfter string
+}
+
+// NewServer starts a fake service; callers must Close it.
+func NewServer() *Server {
+	s := &Server{
+		tables:   make(map[int]table),
+		failures: make(map[string][]failure),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/WebService.asmx/GetListOfChangeLogs", s.handleChangeLog)
+	mux.HandleFunc("/data/", s.handleTable)
+	s.Server = httptest.NewServer(s.record(mux))
+	return s
+}
+
+// AddEntries appends change log rows.
+func (s *Server) AddEntries(entries ...Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entries...)
+}
+
+// SetTable serves body at /data/t<tableID>.xml with an ETag derived from
+// body and the current time as Last-Modified. Conditional requests matching
+// either validator are answered with 304 Not Modified.
+func (s *Server) SetTable(tableID int, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tables[tableID] = table{body: body, modified: time.Now().UTC().Truncate(time.Second)}
+}
+
+// RemoveTable makes /data/t<tableID>.xml return 404.
+func (s *Server) RemoveTable(tableID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tables, tableID)
+}
+
+// FailNext makes the next len(statuses) requests to path answer with the
+// given status codes, in order, before serving normally again.
+func (s *Server) FailNext(path string, statuses ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, status := range statuses {
+		s.failures[path] = append(s.failures[path], failure{status: status})
+	}
+}
+
+// ThrottleNext makes the next request to path answer 429 Too Many Requests
+// with the given Retry-After header value.
+func (s *Server) ThrottleNext(path, retryAfter string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[path] = append(s.failures[path], failure{status: http.StatusTooManyRequests, retryAfter: retryAfter})
+}
+
+// Requests returns the "METHOD path" of every request served so far.
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// NotModified returns how many table requests were answered with 304.
+func (s *Server) NotModified() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.notModified
+}
+
+// CountRequests returns how many requests targeted path.
+func (s *Server) CountRequests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, req := range s.requests {
+		if strings.HasSuffix(req, " "+path) {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *Server) record(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+		var fail failure
+		if pending := s.failures[r.URL.Path]; len(pending) > 0 {
+			fail = pending[0]
+			s.failures[r.URL.Path] = pending[1:]
+		}
+		s.mu.Unlock()
+		if fail.status != 0 {
+			if fail.retryAfter != "" {
+				w.Header().Set("Retry-After", fail.retryAfter)
+			}
+			http.Error(w, http.StatusText(fail.status), fail.status)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+type changeLogRequest struct {
+	Page int `json:"page"`
+	Rows int `json:"rows"`
+}
+
+type wireEntry struct {
+	TableIdentity int    `json:"TableIdentity"`
+	TableID       int    `json:"FkXtbml"`
+	Comment       string `json:"Comment"`
+	SDate         string `json:"sDate"`
+	LogDate       string `json:"LogDate"`
+	UserID        string `json:"UserID"`
+	Action        string `json:"Action"`
+}
+
+func (s *Server) handleChangeLog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req changeLogRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("decode request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Rows < 1 {
+		req.Rows = 1000
+	}
+
+	s.mu.Lock()
+	entries := append([]Entry(nil), s.entries...)
+	s.mu.Unlock()
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].LogMillis > entries[j].LogMillis
+	})
+
+	pages := (len(entries) + req.Rows - 1) / req.Rows
+	start := min((req.Page-1)*req.Rows, len(entries))
+	end := min(start+req.Rows, len(entries))
+
+	rows := make([]wireEntry, 0, end-start)
+	for _, e := range entries[start:end] {
+		sdate := e.SDate
+		if sdate == "" {
+			sdate = time.UnixMilli(e.LogMillis).UTC().Format("01/02/2006")
+		}
+		rows = append(rows, wireEntry{
+			TableIdentity: e.TableIdentity,
+			TableID:       e.TableID,
+			Comment:       e.Comment,
+			SDate:         sdate,
+			LogDate:       "/Date(" + strconv.FormatInt(e.LogMillis, 10) + ")/",
+			UserID:        e.UserID,
+			Action:        e.Action,
+		})
+	}
+
+	var resp struct {
+		D struct {
+			Total   int         `json:"total"`
+			Page    int         `json:"page"`
+			Records int         `json:"records"`
+			Rows    []wireEntry `json:"rows"`
+		} `json:"d"`
+	}
+	resp.D.Total = pages
+	resp.D.Page = req.Page
+	resp.D.Records = len(entries)
+	resp.D.Rows = rows
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (s *Server) handleTable(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/data/")
+	idText, ok := strings.CutPrefix(strings.TrimSuffix(name, ".xml"), "t")
+	id, err := strconv.Atoi(idText)
+	if !ok || err != nil || !strings.HasSuffix(name, ".xml") {
+		http.NotFound(w, r)
+		return
+	}
+	s.mu.Lock()
+	tbl, found := s.tables[id]
+	s.mu.Unlock()
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+	sum := sha256.Sum256(tbl.body)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:8])+`"`)
+	w.Header().Set("Content-Type", "application/xml")
+	rec := &statusRecorder{ResponseWriter: w}
+	http.ServeContent(rec, r, name, tbl.modified, bytes.NewReader(tbl.body))
+	if rec.status == http.StatusNotModified {
+		s.mu.Lock()
+		s.notModified++
+		s.mu.Unlock()
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
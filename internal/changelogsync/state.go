@@ -0,0 +1,111 @@
+package changelogsync
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// State records how far the change log has been applied.
+type State struct {
+	LastLogMillis int64 `json:"last_log_ms"`
+	// AppliedLogIDs lists the TableIdentity of every entry logged exactly at
+	// LastLogMillis that has been applied, so a sync interrupted between two
+	// entries sharing a timestamp resumes without skipping or repeating one.
+	// Nil means all of them were applied (state written before checkpoints).
+	AppliedLogIDs []int `json:"applied_log_ids,omitempty"`
+}
+
+// Applied reports whether entry is covered by the state.
+func (s *State) Applied(entry Entry) bool {
+	ts := entry.LogMillis()
+	switch {
+	case ts < s.LastLogMillis:
+		return true
+	case ts > s.LastLogMillis:
+		return false
+	case s.AppliedLogIDs == nil:
+		return true
+	default:
+		return slices.Contains(s.AppliedLogIDs, entry.TableIdentity)
+	}
+}
+
+// behind reports whether entry and everything logged before it are applied,
+// which lets paging stop early.
+func (s *State) behind(entry Entry) bool {
+	ts := entry.LogMillis()
+	return ts < s.LastLogMillis || (ts == s.LastLogMillis && s.AppliedLogIDs == nil)
+}
+
+// Checkpoint marks entry as applied. Entries must be checkpointed in log order.
+func (s *State) Checkpoint(entry Entry) {
+	ts := entry.LogMillis()
+	if ts < s.LastLogMillis {
+		return
+	}
+	if ts > s.LastLogMillis || s.AppliedLogIDs == nil {
+		s.LastLogMillis = ts
+		s.AppliedLogIDs = []int{}
+	}
+	if !slices.Contains(s.AppliedLogIDs, entry.TableIdentity) {
+		s.AppliedLogIDs = append(s.AppliedLogIDs, entry.TableIdentity)
+	}
+}
+
+// LoadState reads the state file at path. A missing file yields a zero State.
+func LoadState(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &State{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// SaveState atomically replaces the state file at path, creating parent
+// directories as needed.
+func SaveState(path string, state *State) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// writeFileAtomic writes data to a temporary file beside path, syncs it and
+// renames it into place so readers never observe a partial file.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, tempPrefix+"*"+filepath.Ext(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
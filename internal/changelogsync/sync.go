@@ -0,0 +1,363 @@
+package changelogsync
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"mort/internal/tablearchive"
+	"mort/internal/xtbml"
+)
+
+// ErrInterrupted is returned when a journal from a crashed sync exists and
+// Resume is not set.
+var ErrInterrupted = errors.New("previous sync was interrupted; rerun with -resume")
+
+// Syncer applies new change log entries to a local XML mirror.
+type Syncer struct {
+	Client    *Client
+	XMLDir    string
+	StatePath string
+	// Convert regenerates JSONDir/tN.json after each download, with the
+	// identifier xtbmlconvert assigns over the whole mirror, and deletes it
+	// when the table is removed upstream.
+	Convert bool
+	JSONDir string
+	// Concurrency bounds parallel table downloads; 1 when zero or negative.
+	Concurrency int
+	// Archive, when set, keeps every downloaded revision with the change log
+	// entry that produced it.
+	Archive *tablearchive.Archive
+	// QuarantineDir receives downloads that fail VerifyTable; empty discards
+	// them.
+	QuarantineDir string
+	// HTTPCache, when set, makes reconciliation send conditional requests
+	// and records the validators of what it downloads. Callers save it.
+	HTTPCache *HTTPCache
+	// Log, when set, receives a record for every entry applied by Sync and
+	// every table Reconcile changes.
+	Log *SyncLog
+	// Resume reconciles the journal left by an interrupted sync before
+	// continuing from the last checkpoint.
+	Resume bool
+	// Out receives one progress line per applied entry; nil discards them.
+	Out io.Writer
+
+	changeLog changeLogCache
+}
+
+// Sync fetches entries the saved state has not applied, applies them oldest
+// first, and checkpoints the state after each entry, including its JSON
+// conversion when Convert is set, succeeds. Every action is journaled beside
+// the state file until the run ends, so only a crash leaves the journal
+// behind. It returns the number of entries processed.
+func (s *Syncer) Sync() (processed int, err error) {
+	out := s.Out
+	if out == nil {
+		out = io.Discard
+	}
+
+	state, err := LoadState(s.StatePath)
+	if err != nil {
+		return 0, fmt.Errorf("load state: %w", err)
+	}
+	s.changeLog.reset(nil)
+	recovery, err := s.recover(out)
+	if err != nil {
+		return 0, err
+	}
+
+	entries, err := s.Client.FetchPending(state)
+	if err != nil {
+		return 0, fmt.Errorf("fetch change log: %w", err)
+	}
+	if len(entries) == 0 {
+		return 0, removeJournal(s.StatePath)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].LogMillis() < entries[j].LogMillis()
+	})
+
+	jrnl, err := createJournal(JournalPath(s.StatePath))
+	if err != nil {
+		return 0, fmt.Errorf("create journal: %w", err)
+	}
+	defer func() {
+		jrnl.close()
+		if err != nil {
+			// A run that fails cleanly leaves nothing half-done: files are
+			// replaced atomically and the state holds every applied entry,
+			// so the next run can start over without -resume.
+			removeJournal(s.StatePath)
+		}
+	}()
+
+	// Only the newest entry per table decides the local outcome, so each
+	// table is downloaded at most once and superseded entries are logged only.
+	final := make(map[int]int, len(entries))
+	for i, entry := range entries {
+		final[entry.TableID] = i
+	}
+	// before snapshots the affected files ahead of any download so the log
+	// can report what each change replaced.
+	before := make(map[int]*FileState, len(final))
+	exists := make(map[int]bool, len(final))
+	if s.Log != nil {
+		for id := range final {
+			before[id] = readFileState(TablePath(s.XMLDir, id))
+		}
+	}
+	failed := s.prefetch(entries, final, recovery, jrnl)
+
+	var plan *identifierPlan
+	if s.Convert {
+		if plan, err = s.planConversions(entries, final, jrnl); err != nil {
+			return 0, err
+		}
+	}
+
+	for i, entry := range entries {
+		latest := final[entry.TableID] == i
+		if entry.IsDelete() {
+			if latest {
+				if err := s.removeTable(entry, jrnl); err != nil {
+					return processed, err
+				}
+			}
+			fmt.Fprintf(out, "removed t%d.xml (log #%d on %s)\n", entry.TableID, entry.TableIdentity, entry.SDate)
+		} else {
+			if latest {
+				if err := failed[entry.TableID]; err != nil {
+					return processed, fmt.Errorf("download t%d.xml: %w", entry.TableID, err)
+				}
+				if s.Convert && !recovery.converted(entry) {
+					err := jrnl.track(OpConvert, entry, func() error {
+						return plan.convert(s.XMLDir, entry.TableID)
+					})
+					if err != nil {
+						return processed, fmt.Errorf("convert t%d.xml: %w", entry.TableID, err)
+					}
+				}
+			}
+			fmt.Fprintf(out, "updated t%d.xml (log #%d on %s: %s)\n", entry.TableID, entry.TableIdentity, entry.SDate, entry.Action)
+		}
+
+		state.Checkpoint(entry)
+		if err := SaveState(s.StatePath, state); err != nil {
+			return processed, fmt.Errorf("save state: %w", err)
+		}
+		processed++
+		if s.Log != nil {
+			s.Log.record(s.syncRecord(entry, latest, before, exists))
+		}
+	}
+
+	if err := jrnl.close(); err != nil {
+		return processed, fmt.Errorf("close journal: %w", err)
+	}
+	return processed, removeJournal(s.StatePath)
+}
+
+// syncRecord describes an applied entry. before holds the files on disk
+// before the run; exists tracks whether each table exists as entries apply,
+// which decides the action of superseded entries.
+func (s *Syncer) syncRecord(entry Entry, latest bool, before map[int]*FileState, exists map[int]bool) LogRecord {
+	existed, seen := exists[entry.TableID]
+	if !seen {
+		existed = before[entry.TableID] != nil
+	}
+	exists[entry.TableID] = !entry.IsDelete()
+	if latest {
+		// The mirror goes straight from the snapshot to the final state.
+		existed = before[entry.TableID] != nil
+	}
+
+	action := ActionRemove
+	if !entry.IsDelete() {
+		action = ActionUpdate
+		if !existed {
+			action = ActionDownload
+		}
+	}
+	rec := newLogRecord(ModeSync, action, entry.TableID, &entry)
+	if !latest {
+		rec.Superseded = true
+		return rec
+	}
+	rec.Before = before[entry.TableID]
+	rec.After = readFileState(TablePath(s.XMLDir, entry.TableID))
+	rec.Converted = s.Convert && !entry.IsDelete()
+	return rec
+}
+
+// recover inspects the journal of an unfinished sync. Without Resume it
+// refuses to continue; with Resume it reconciles the journal against disk.
+func (s *Syncer) recover(out io.Writer) (*Recovery, error) {
+	path := JournalPath(s.StatePath)
+	if !fileExists(path) {
+		return &Recovery{}, nil
+	}
+	if !s.Resume {
+		return nil, fmt.Errorf("%w (journal %s)", ErrInterrupted, path)
+	}
+	records, err := ReadJournal(path)
+	if err != nil {
+		return nil, fmt.Errorf("read journal: %w", err)
+	}
+	jsonDir := ""
+	if s.Convert {
+		jsonDir = s.JSONDir
+	}
+	recovery, err := Reconcile(records, s.XMLDir, jsonDir)
+	if err != nil {
+		return nil, fmt.Errorf("reconcile journal: %w", err)
+	}
+	for _, rec := range recovery.InFlight {
+		fmt.Fprintf(out, "interrupted %s of t%d will be redone\n", rec.Op, rec.TableID)
+	}
+	for _, path := range recovery.TempFiles {
+		fmt.Fprintf(out, "removed partial file %s\n", path)
+	}
+	return recovery, nil
+}
+
+// prefetch downloads every table whose newest entry is not a delete using at
+// most s.Concurrency workers, skipping tables a resumed journal shows as
+// already downloaded for that same entry. It returns the download error for each failed table.
+func (s *Syncer) prefetch(entries []Entry, final map[int]int, recovery *Recovery, jrnl *journal) map[int]error {
+	var ids []int
+	latest := make(map[int]Entry)
+	for i, entry := range entries {
+		if final[entry.TableID] == i && !entry.IsDelete() && !recovery.downloaded(entry) {
+			ids = append(ids, entry.TableID)
+			latest[entry.TableID] = entry
+		}
+	}
+
+	errs := make([]error, len(ids))
+	sem := make(chan struct{}, max(s.Concurrency, 1))
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			entry := latest[id]
+			errs[i] = jrnl.track(OpDownload, entry, func() error {
+				body, err := s.Client.FetchTable(id)
+				if err != nil {
+					return err
+				}
+				return s.storeTable(id, body, &entry, "")
+			})
+		}()
+	}
+	wg.Wait()
+
+	failed := make(map[int]error)
+	for i, err := range errs {
+		if err != nil {
+			failed[ids[i]] = err
+		}
+	}
+	return failed
+}
+
+// planConversions assigns identifiers over the mirror as the run leaves it
+// and first reconverts the tables outside the run whose identifier moved
+// because a table sharing their name was added, renamed or removed.
+func (s *Syncer) planConversions(entries []Entry, final map[int]int, jrnl *journal) (*identifierPlan, error) {
+	removed := make(map[int]bool)
+	for id, i := range final {
+		if entries[i].IsDelete() {
+			removed[id] = true
+		}
+	}
+	plan, err := s.planIdentifiers(removed)
+	if err != nil {
+		return nil, err
+	}
+	inRun := make(map[int]bool, len(final))
+	for id := range final {
+		inRun[id] = true
+	}
+	for _, id := range plan.stale(inRun) {
+		err := jrnl.track(OpConvert, Entry{TableID: id}, func() error {
+			return plan.convert(s.XMLDir, id)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("convert t%d.xml: %w", id, err)
+		}
+	}
+	return plan, nil
+}
+
+func (s *Syncer) removeTable(entry Entry, jrnl *journal) error {
+	tableID := entry.TableID
+	err := jrnl.track(OpRemove, entry, func() error {
+		return RemoveTable(tableID, s.XMLDir)
+	})
+	if err != nil {
+		return fmt.Errorf("remove t%d.xml: %w", tableID, err)
+	}
+	if !s.Convert {
+		return nil
+	}
+	err = jrnl.track(OpRemoveJSON, entry, func() error {
+		return removeJSON(s.JSONDir, tableID)
+	})
+	if err != nil {
+		return fmt.Errorf("remove t%d.json: %w", tableID, err)
+	}
+	return nil
+}
+
+func removeJournal(statePath string) error {
+	err := os.Remove(JournalPath(statePath))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove journal: %w", err)
+	}
+	return nil
+}
+
+// JSONPath returns the converted payload path of t<tableID> inside jsonDir.
+func JSONPath(jsonDir string, tableID int) string {
+	return filepath.Join(jsonDir, fmt.Sprintf("t%d.json", tableID))
+}
+
+// ConvertTable converts xmlDir/t<tableID>.xml into jsonDir/t<tableID>.json,
+// replacing the payload atomically once it passes schema validation. An empty
+// identifier is derived from the table name.
+func ConvertTable(xmlDir, jsonDir string, tableID int, identifier string) error {
+	if err := os.MkdirAll(jsonDir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(jsonDir, tempPrefix+"*.json")
+	if err != nil {
+		return err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+	opts := xtbml.ConvertOptions{Identifier: identifier, Validate: true}
+	if err := xtbml.ConvertFileWithOptions(TablePath(xmlDir, tableID), tmp.Name(), opts); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), JSONPath(jsonDir, tableID))
+}
+
+func removeJSON(jsonDir string, tableID int) error {
+	err := os.Remove(JSONPath(jsonDir, tableID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,373 @@
+package changelogsync
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mort/internal/changelogsync/soatest"
+	"mort/internal/xtbml"
+)
+
+// tableXML returns a minimal XTbML document that passes VerifyTable for id;
+// note lands in the Comments so tests can tell revisions apart.
+func tableXML(id int, note string) []byte {
+	return tableXMLNamed(id, fmt.Sprintf("Table %d", id), note)
+}
+
+func tableXMLNamed(id int, name, note string) []byte {
+	return []byte(fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+<XTbML version="1.3">
+	<ContentClassification>
+		<TableIdentity>%d</TableIdentity>
+		<ProviderDomain>example.org</ProviderDomain>
+		<ProviderName>Example Provider</ProviderName>
+		<TableReference>Example Reference</TableReference>
+		<ContentType tc="1">Demo</ContentType>
+		<TableName>%s</TableName>
+		<TableDescription>Sync test table.</TableDescription>
+		<Comments>%s</Comments>
+	</ContentClassification>
+	<Table>
+		<MetaData>
+			<ScalingFactor>0</ScalingFactor>
+			<DataType tc="2">Floating Point</DataType>
+			<Nation tc="1">Nowhere</Nation>
+			<TableDescription>Primary table</TableDescription>
+			<AxisDef id="Age">
+				<ScaleType tc="3">Age</ScaleType>
+				<AxisName>Age</AxisName>
+				<MinScaleValue>40</MinScaleValue>
+				<MaxScaleValue>40</MaxScaleValue>
+				<Increment>1</Increment>
+			</AxisDef>
+		</MetaData>
+		<Values>
+			<Axis>
+				<Y t="40">0.01</Y>
+			</Axis>
+		</Values>
+	</Table>
+</XTbML>
+`, id, name, note))
+}
+
+func newTestSyncer(t *testing.T, srv *soatest.Server, pageSize int) (*Syncer, *strings.Builder) {
+	t.Helper()
+	dir := t.TempDir()
+	out := &strings.Builder{}
+	return &Syncer{
+		Client:    newTestClient(srv, pageSize),
+		XMLDir:    filepath.Join(dir, "xml"),
+		StatePath: filepath.Join(dir, "json", "changelog_state.json"),
+		Out:       out,
+	}, out
+}
+
+func TestSyncAppliesEntriesOldestFirst(t *testing.T) {
+	srv := soatest.NewServer()
+	defer srv.Close()
+	srv.AddEntries(
+		soatest.Entry{TableIdentity: 11, TableID: 1, Action: "Update", LogMillis: 1000},
+		soatest.Entry{TableIdentity: 12, TableID: 2, Action: "Add", LogMillis: 2000},
+		soatest.Entry{TableIdentity: 13, TableID: 3, Action: "Delete", LogMillis: 3000},
+	)
+	srv.SetTable(1, tableXML(1, "one"))
+	srv.SetTable(2, tableXML(2, "two"))
+
+	syncer, out := newTestSyncer(t, srv, 2)
+	if err := os.MkdirAll(syncer.XMLDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(TablePath(syncer.XMLDir, 3), []byte("stale"), 0o644); err != nil {
+		t.Fatalf("seed t3: %v", err)
+	}
+
+	processed, err := syncer.Sync()
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if processed != 3 {
+		t.Fatalf("Sync() processed = %d, want 3", processed)
+	}
+	if got := srv.CountRequests(changeLogPath); got != 2 {
+		t.Fatalf("change log requests = %d, want 2 pages", got)
+	}
+
+	data, err := os.ReadFile(TablePath(syncer.XMLDir, 2))
+	if err != nil || !bytes.Equal(data, tableXML(2, "two")) {
+		t.Fatalf("t2.xml = %q, %v", data, err)
+	}
+	if _, err := os.Stat(TablePath(syncer.XMLDir, 3)); !os.IsNotExist(err) {
+		t.Fatalf("t3.xml should be removed, stat err = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "updated t1.xml") || !strings.HasPrefix(lines[2], "removed t3.xml") {
+		t.Fatalf("unexpected progress output:\n%s", out.String())
+	}
+
+	state, err := LoadState(syncer.StatePath)
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	if state.LastLogMillis != 3000 {
+		t.Fatalf("LastLogMillis = %d, want 3000", state.LastLogMillis)
+	}
+}
+
+func TestSyncStopsAtSavedState(t *testing.T) {
+	srv := soatest.NewServer()
+	defer srv.Close()
+	srv.AddEntries(
+		soatest.Entry{TableID: 1, Action: "Update", LogMillis: 1000},
+		soatest.Entry{TableID: 2, Action: "Update", LogMillis: 2000},
+		soatest.Entry{TableID: 3, Action: "Update", LogMillis: 3000},
+		soatest.Entry{TableID: 4, Action: "Update", LogMillis: 4000},
+	)
+	srv.SetTable(4, tableXML(4, ""))
+
+	syncer, _ := newTestSyncer(t, srv, 2)
+	if err := SaveState(syncer.StatePath, &State{LastLogMillis: 3000}); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+
+	processed, err := syncer.Sync()
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if processed != 1 {
+		t.Fatalf("Sync() processed = %d, want 1", processed)
+	}
+	if got := srv.CountRequests(changeLogPath); got != 1 {
+		t.Fatalf("change log requests = %d, want 1 (stop before page 2)", got)
+	}
+	if got := srv.CountRequests("/data/t3.xml"); got != 0 {
+		t.Fatalf("already applied t3 was downloaded %d times", got)
+	}
+
+	processed, err = syncer.Sync()
+	if err != nil || processed != 0 {
+		t.Fatalf("second Sync() = %d, %v; want 0, nil", processed, err)
+	}
+}
+
+func TestSyncCheckpointsBeforeFailure(t *testing.T) {
+	srv := soatest.NewServer()
+	defer srv.Close()
+	srv.AddEntries(
+		soatest.Entry{TableID: 1, Action: "Update", LogMillis: 1000},
+		soatest.Entry{TableID: 2, Action: "Update", LogMillis: 2000},
+	)
+	srv.SetTable(1, tableXML(1, ""))
+
+	syncer, _ := newTestSyncer(t, srv, 0)
+	processed, err := syncer.Sync()
+	if err == nil || !strings.Contains(err.Error(), "download t2.xml") {
+		t.Fatalf("Sync() error = %v, want download t2.xml failure", err)
+	}
+	if processed != 1 {
+		t.Fatalf("Sync() processed = %d, want 1", processed)
+	}
+	state, loadErr := LoadState(syncer.StatePath)
+	if loadErr != nil {
+		t.Fatalf("LoadState() error = %v", loadErr)
+	}
+	if state.LastLogMillis != 1000 {
+		t.Fatalf("LastLogMillis = %d, want checkpoint at 1000", state.LastLogMillis)
+	}
+}
+
+func TestSyncReportsChangeLogErrors(t *testing.T) {
+	srv := soatest.NewServer()
+	defer srv.Close()
+	srv.FailNext(changeLogPath, 500, 500, 500, 500, 500)
+
+	syncer, _ := newTestSyncer(t, srv, 0)
+	_, err := syncer.Sync()
+	if err == nil || !strings.Contains(err.Error(), "fetch change log") || !strings.Contains(err.Error(), "giving up after 5 attempts") {
+		t.Fatalf("Sync() error = %v, want fetch change log failure after retries", err)
+	}
+}
+
+func TestSyncConvertsChangedTables(t *testing.T) {
+	srv := soatest.NewServer()
+	defer srv.Close()
+	srv.AddEntries(
+		soatest.Entry{TableID: 1, Action: "Update", LogMillis: 1000},
+		soatest.Entry{TableID: 2, Action: "Delete", LogMillis: 2000},
+	)
+	srv.SetTable(1, tableXML(1, ""))
+
+	syncer, _ := newTestSyncer(t, srv, 0)
+	syncer.Convert = true
+	syncer.JSONDir = filepath.Join(filepath.Dir(syncer.StatePath), "tables")
+	if err := os.MkdirAll(syncer.JSONDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(JSONPath(syncer.JSONDir, 2), []byte("{}"), 0o644); err != nil {
+		t.Fatalf("seed t2.json: %v", err)
+	}
+
+	if _, err := syncer.Sync(); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	data, err := os.ReadFile(JSONPath(syncer.JSONDir, 1))
+	if err != nil {
+		t.Fatalf("expected t1.json: %v", err)
+	}
+	if !strings.Contains(string(data), `"identifier": "table_1"`) {
+		t.Fatalf("t1.json is not a converted payload:\n%s", data)
+	}
+	if _, err := os.Stat(JSONPath(syncer.JSONDir, 2)); !os.IsNotExist(err) {
+		t.Fatalf("t2.json should be removed, stat err = %v", err)
+	}
+}
+
+func TestSyncConvertsWithAssignedIdentifiers(t *testing.T) {
+	srv := soatest.NewServer()
+	defer srv.Close()
+	syncer, _ := newTestSyncer(t, srv, 0)
+	syncer.Convert = true
+	syncer.JSONDir = filepath.Join(filepath.Dir(syncer.StatePath), "tables")
+
+	// t5 is already mirrored and converted as the only table named "Shared".
+	if err := os.MkdirAll(syncer.XMLDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(TablePath(syncer.XMLDir, 5), tableXMLNamed(5, "Shared", ""), 0o644); err != nil {
+		t.Fatalf("seed t5.xml: %v", err)
+	}
+	if err := ConvertTable(syncer.XMLDir, syncer.JSONDir, 5, ""); err != nil {
+		t.Fatalf("seed t5.json: %v", err)
+	}
+
+	srv.AddEntries(
+		soatest.Entry{TableID: 3, Action: "Update", LogMillis: 1000},
+		soatest.Entry{TableID: 7, Action: "Update", LogMillis: 2000},
+	)
+	srv.SetTable(3, tableXMLNamed(3, "Shared", ""))
+	srv.SetTable(7, tableXMLNamed(7, "Shared", ""))
+	if _, err := syncer.Sync(); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	want := map[int]string{3: "shared", 5: "shared_5", 7: "shared_7"}
+	for id, identifier := range want {
+		if got := xtbml.PayloadIdentifier(JSONPath(syncer.JSONDir, id)); got != identifier {
+			t.Errorf("t%d.json identifier = %q, want %q", id, got, identifier)
+		}
+	}
+
+	// Removing the lowest identity hands the base identifier back to t5, and
+	// its old identifier becomes an alias.
+	srv.AddEntries(soatest.Entry{TableID: 3, Action: "Delete", LogMillis: 3000})
+	if _, err := syncer.Sync(); err != nil {
+		t.Fatalf("second Sync() error = %v", err)
+	}
+	if got := xtbml.PayloadIdentifier(JSONPath(syncer.JSONDir, 5)); got != "shared" {
+		t.Errorf("t5.json identifier = %q after removal, want shared", got)
+	}
+	if got := xtbml.PayloadIdentifier(JSONPath(syncer.JSONDir, 7)); got != "shared_7" {
+		t.Errorf("t7.json identifier = %q after removal, want shared_7", got)
+	}
+	aliases, err := xtbml.LoadAliases(syncer.JSONDir)
+	if err != nil {
+		t.Fatalf("LoadAliases() error = %v", err)
+	}
+	if got := aliases["shared_5"]; got != "shared" {
+		t.Errorf("alias shared_5 = %q, want shared", got)
+	}
+}
+
+func TestSyncConvertsBesideUnreadableTables(t *testing.T) {
+	srv := soatest.NewServer()
+	defer srv.Close()
+	syncer, _ := newTestSyncer(t, srv, 0)
+	syncer.Convert = true
+	syncer.JSONDir = filepath.Join(filepath.Dir(syncer.StatePath), "tables")
+
+	// t9 holds a control character the XML decoder rejects, as some mirrored
+	// tables do; it must not stop other tables from converting.
+	if err := os.MkdirAll(syncer.XMLDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(TablePath(syncer.XMLDir, 9), tableXMLNamed(9, "Bad\x03Name", ""), 0o644); err != nil {
+		t.Fatalf("seed t9.xml: %v", err)
+	}
+
+	srv.AddEntries(soatest.Entry{TableID: 3, Action: "Update", LogMillis: 1000})
+	srv.SetTable(3, tableXMLNamed(3, "Shared", ""))
+	if _, err := syncer.Sync(); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if got := xtbml.PayloadIdentifier(JSONPath(syncer.JSONDir, 3)); got != "shared" {
+		t.Fatalf("t3.json identifier = %q, want shared", got)
+	}
+}
+
+func TestSyncKeepsStateWhenConversionFails(t *testing.T) {
+	srv := soatest.NewServer()
+	defer srv.Close()
+	srv.AddEntries(soatest.Entry{TableID: 1, Action: "Update", LogMillis: 1000})
+	// Passes the download checks but converts to an empty identifier, which
+	// the schema rejects.
+	srv.SetTable(1, tableXMLNamed(1, "***", ""))
+
+	syncer, _ := newTestSyncer(t, srv, 0)
+	syncer.Convert = true
+	syncer.JSONDir = t.TempDir()
+
+	_, err := syncer.Sync()
+	if err == nil || !strings.Contains(err.Error(), "convert t1.xml") {
+		t.Fatalf("Sync() error = %v, want convert failure", err)
+	}
+	if _, statErr := os.Stat(syncer.StatePath); !os.IsNotExist(statErr) {
+		t.Fatalf("state file should not be written after a failed conversion, stat err = %v", statErr)
+	}
+}
+
+func TestSyncDownloadsEachTableOnce(t *testing.T) {
+	srv := soatest.NewServer()
+	defer srv.Close()
+	srv.AddEntries(
+		soatest.Entry{TableID: 1, Action: "Update", LogMillis: 1000},
+		soatest.Entry{TableID: 2, Action: "Update", LogMillis: 2000},
+		soatest.Entry{TableID: 1, Action: "Update", LogMillis: 3000},
+		soatest.Entry{TableID: 2, Action: "Delete", LogMillis: 4000},
+		soatest.Entry{TableID: 3, Action: "Add", LogMillis: 5000},
+		soatest.Entry{TableID: 4, Action: "Add", LogMillis: 6000},
+	)
+	for _, id := range []int{1, 2, 3, 4} {
+		srv.SetTable(id, tableXML(id, ""))
+	}
+
+	syncer, out := newTestSyncer(t, srv, 0)
+	syncer.Concurrency = 3
+	processed, err := syncer.Sync()
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if processed != 6 {
+		t.Fatalf("Sync() processed = %d, want 6", processed)
+	}
+	if got := srv.CountRequests("/data/t1.xml"); got != 1 {
+		t.Fatalf("t1.xml downloaded %d times, want 1", got)
+	}
+	if got := srv.CountRequests("/data/t2.xml"); got != 0 {
+		t.Fatalf("deleted t2.xml downloaded %d times, want 0", got)
+	}
+	for _, id := range []int{1, 3, 4} {
+		if _, err := os.Stat(TablePath(syncer.XMLDir, id)); err != nil {
+			t.Fatalf("expected t%d.xml: %v", id, err)
+		}
+	}
+	if _, err := os.Stat(TablePath(syncer.XMLDir, 2)); !os.IsNotExist(err) {
+		t.Fatalf("t2.xml should be absent, stat err = %v", err)
+	}
+	if got := strings.Count(out.String(), "\n"); got != 6 {
+		t.Fatalf("progress lines = %d, want 6:\n%s", got, out.String())
+	}
+}
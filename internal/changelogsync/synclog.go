@@ -0,0 +1,205 @@
+package changelogsync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Log record modes.
+const (
+	ModeSync      = "sync"
+	ModeReconcile = "reconcile"
+)
+
+// LogRecord is one line of the structured sync log: a change applied to the
+// mirror, or a superseded change log entry that was only checkpointed.
+type LogRecord struct {
+	Time     time.Time `json:"time"`
+	Mode     string    `json:"mode"`
+	Action   string    `json:"action"`
+	TableID  int       `json:"table"`
+	LogID    int       `json:"logId,omitempty"`
+	Upstream string    `json:"upstreamAction,omitempty"`
+	Comment  string    `json:"comment,omitempty"`
+	UserID   string    `json:"user,omitempty"`
+	Date     string    `json:"date,omitempty"`
+	Logged   time.Time `json:"logged,omitzero"`
+	// Superseded marks entries a later entry for the same table overrides.
+	Superseded bool `json:"superseded,omitempty"`
+	// Before and After describe t<TableID>.xml around the change; nil when
+	// the file did not exist.
+	Before    *FileState `json:"before,omitempty"`
+	After     *FileState `json:"after,omitempty"`
+	Converted bool       `json:"converted,omitempty"`
+}
+
+// FileState is the size and SHA-256 of a mirrored file.
+type FileState struct {
+	Bytes  int64  `json:"bytes"`
+	SHA256 string `json:"sha256"`
+}
+
+// readFileState describes the file at path, or returns nil when it does not
+// exist or cannot be read.
+func readFileState(path string) *FileState {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	sum := sha256.Sum256(data)
+	return &FileState{Bytes: int64(len(data)), SHA256: hex.EncodeToString(sum[:])}
+}
+
+// newLogRecord fills the change log fields of a record; entry may be nil for
+// tables the change log never mentions.
+func newLogRecord(mode, action string, tableID int, entry *Entry) LogRecord {
+	rec := LogRecord{Time: time.Now().UTC(), Mode: mode, Action: action, TableID: tableID}
+	if entry != nil {
+		rec.LogID = entry.TableIdentity
+		rec.Upstream = entry.Action
+		rec.Comment = entry.Comment
+		rec.UserID = entry.UserID
+		rec.Date = entry.SDate
+		if ms := entry.LogMillis(); ms != 0 {
+			rec.Logged = time.UnixMilli(ms).UTC()
+		}
+	}
+	return rec
+}
+
+// SyncLog writes LogRecords as JSON lines and keeps them for the end-of-run
+// Summary. It is safe for concurrent use; a nil *SyncLog discards records.
+type SyncLog struct {
+	mu      sync.Mutex
+	w       io.Writer
+	records []LogRecord
+	err     error
+}
+
+// NewSyncLog returns a log writing JSON lines to w; w may be nil to only
+// collect records for the summary.
+func NewSyncLog(w io.Writer) *SyncLog {
+	return &SyncLog{w: w}
+}
+
+func (l *SyncLog) record(rec LogRecord) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, rec)
+	if l.w == nil || l.err != nil {
+		return
+	}
+	line, err := json.Marshal(rec)
+	if err == nil {
+		_, err = l.w.Write(append(line, '\n'))
+	}
+	l.err = err
+}
+
+// Records returns the records logged so far, in order.
+func (l *SyncLog) Records() []LogRecord {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]LogRecord(nil), l.records...)
+}
+
+// Err returns the first error writing the JSON lines, if any.
+func (l *SyncLog) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+// Summary is the end-of-run account of a sync or reconciliation.
+type Summary struct {
+	Mode     string
+	Started  time.Time
+	Finished time.Time
+	Records  []LogRecord
+	// Err is the error that stopped the run early, if any.
+	Err error
+}
+
+// Count returns how many records, superseded entries excluded, have action.
+func (s *Summary) Count(action string) int {
+	n := 0
+	for _, rec := range s.Records {
+		if rec.Action == action && !rec.Superseded {
+			n++
+		}
+	}
+	return n
+}
+
+// WriteMarkdown renders the summary as a Markdown section suitable for a
+// pull request body.
+func (s *Summary) WriteMarkdown(w io.Writer) error {
+	title := "Change log sync"
+	if s.Mode == ModeReconcile {
+		title = "Mirror reconciliation"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s\n\n", title)
+	fmt.Fprintf(&b, "%d downloaded, %d updated, %d removed", s.Count(ActionDownload), s.Count(ActionUpdate), s.Count(ActionRemove))
+	if n := len(s.Records) - s.Count(ActionDownload) - s.Count(ActionUpdate) - s.Count(ActionRemove); n > 0 {
+		fmt.Fprintf(&b, ", %d superseded", n)
+	}
+	fmt.Fprintf(&b, " (%s to %s).\n", s.Started.Format(time.RFC3339), s.Finished.Format(time.RFC3339))
+	if s.Err != nil {
+		fmt.Fprintf(&b, "\n**The run stopped early:** %s\n", markdownCell(s.Err.Error()))
+	}
+	if len(s.Records) == 0 {
+		b.WriteString("\nNo tables changed.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	b.WriteString("\n| Table | Action | Log # | Date | User | Size | Comment |\n")
+	b.WriteString("| --- | --- | --- | --- | --- | --- | --- |\n")
+	for _, rec := range s.Records {
+		action := rec.Action
+		if rec.Superseded {
+			action += " (superseded)"
+		}
+		logID := ""
+		if rec.LogID != 0 {
+			logID = fmt.Sprintf("%d", rec.LogID)
+		}
+		fmt.Fprintf(&b, "| t%d | %s | %s | %s | %s | %s | %s |\n",
+			rec.TableID, action, logID, markdownCell(rec.Date), markdownCell(rec.UserID),
+			sizeChange(rec), markdownCell(rec.Comment))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func sizeChange(rec LogRecord) string {
+	switch {
+	case rec.Superseded:
+		return ""
+	case rec.Before == nil && rec.After == nil:
+		return ""
+	case rec.Before == nil:
+		return fmt.Sprintf("%d bytes", rec.After.Bytes)
+	case rec.After == nil:
+		return fmt.Sprintf("%d bytes removed", rec.Before.Bytes)
+	default:
+		return fmt.Sprintf("%d → %d bytes", rec.Before.Bytes, rec.After.Bytes)
+	}
+}
+
+// markdownCell keeps s on one table row.
+func markdownCell(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	return strings.ReplaceAll(s, "|", `\|`)
+}
@@ -0,0 +1,168 @@
+package changelogsync
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"mort/internal/changelogsync/soatest"
+)
+
+func TestSyncLogRecordsAppliedEntries(t *testing.T) {
+	srv := soatest.NewServer()
+	defer srv.Close()
+	srv.AddEntries(
+		soatest.Entry{TableIdentity: 11, TableID: 1, Action: "Update", Comment: "fixed ages", UserID: "jdoe", LogMillis: 1000},
+		soatest.Entry{TableIdentity: 12, TableID: 2, Action: "Add", LogMillis: 2000},
+		soatest.Entry{TableIdentity: 13, TableID: 2, Action: "Update", LogMillis: 3000},
+		soatest.Entry{TableIdentity: 14, TableID: 3, Action: "Delete", LogMillis: 4000},
+	)
+	srv.SetTable(1, tableXML(1, "new"))
+	srv.SetTable(2, tableXML(2, "two"))
+
+	syncer, _ := newTestSyncer(t, srv, 10)
+	if err := os.MkdirAll(syncer.XMLDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	for id, body := range map[int][]byte{1: tableXML(1, "old"), 3: tableXML(3, "three")} {
+		if err := os.WriteFile(TablePath(syncer.XMLDir, id), body, 0o644); err != nil {
+			t.Fatalf("seed t%d: %v", id, err)
+		}
+	}
+	var lines bytes.Buffer
+	syncer.Log = NewSyncLog(&lines)
+
+	if _, err := syncer.Sync(); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	var records []LogRecord
+	scanner := bufio.NewScanner(&lines)
+	for scanner.Scan() {
+		var rec LogRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("decode %s: %v", scanner.Text(), err)
+		}
+		records = append(records, rec)
+	}
+	if len(records) != 4 {
+		t.Fatalf("records = %d, want 4", len(records))
+	}
+
+	update := records[0]
+	if update.Action != ActionUpdate || update.TableID != 1 || update.LogID != 11 || update.Comment != "fixed ages" || update.UserID != "jdoe" {
+		t.Fatalf("records[0] = %+v", update)
+	}
+	if update.Before == nil || update.After == nil || update.Before.SHA256 == update.After.SHA256 {
+		t.Fatalf("records[0] before/after = %+v/%+v", update.Before, update.After)
+	}
+	if update.After.Bytes != int64(len(tableXML(1, "new"))) || update.After.SHA256 != contentHash(tableXML(1, "new")) {
+		t.Fatalf("records[0].After = %+v", update.After)
+	}
+	if !update.Logged.Equal(time.UnixMilli(1000)) {
+		t.Fatalf("records[0].Logged = %v", update.Logged)
+	}
+
+	if added := records[1]; added.Action != ActionDownload || !added.Superseded || added.After != nil {
+		t.Fatalf("records[1] = %+v, want a superseded download", added)
+	}
+	if latest := records[2]; latest.Action != ActionDownload || latest.Superseded || latest.Before != nil || latest.After == nil {
+		t.Fatalf("records[2] = %+v, want the download of t2", latest)
+	}
+	if removed := records[3]; removed.Action != ActionRemove || removed.Before == nil || removed.After != nil {
+		t.Fatalf("records[3] = %+v, want the removal of t3", removed)
+	}
+}
+
+func TestSummaryWriteMarkdown(t *testing.T) {
+	started := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	summary := &Summary{
+		Mode:     ModeSync,
+		Started:  started,
+		Finished: started.Add(time.Minute),
+		Records: []LogRecord{
+			{Action: ActionUpdate, TableID: 1, LogID: 11, Date: "03/01/2024", UserID: "jdoe", Comment: "fixed | split\nrows",
+				Before: &FileState{Bytes: 100}, After: &FileState{Bytes: 120}},
+			{Action: ActionDownload, TableID: 2, LogID: 12, Superseded: true},
+			{Action: ActionRemove, TableID: 3, LogID: 14, Before: &FileState{Bytes: 50}},
+		},
+		Err: errors.New("download t4.xml: boom"),
+	}
+	var buf bytes.Buffer
+	if err := summary.WriteMarkdown(&buf); err != nil {
+		t.Fatalf("WriteMarkdown() error = %v", err)
+	}
+	want := `## Change log sync
+
+0 downloaded, 1 updated, 1 removed, 1 superseded (2024-03-01T12:00:00Z to 2024-03-01T12:01:00Z).
+
+**The run stopped early:** download t4.xml: boom
+
+| Table | Action | Log # | Date | User | Size | Comment |
+| --- | --- | --- | --- | --- | --- | --- |
+| t1 | update | 11 | 03/01/2024 | jdoe | 100 → 120 bytes | fixed \| split rows |
+| t2 | download (superseded) | 12 |  |  |  |  |
+| t3 | remove | 14 |  |  | 50 bytes removed |  |
+`
+	if buf.String() != want {
+		t.Fatalf("WriteMarkdown() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestRunWritesLogAndSummary(t *testing.T) {
+	srv := soatest.NewServer()
+	defer srv.Close()
+	srv.AddEntries(soatest.Entry{TableIdentity: 11, TableID: 1, Action: "Add", LogMillis: 1000})
+	srv.SetTable(1, tableXML(1, ""))
+
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "sync.jsonl")
+	summaryPath := filepath.Join(dir, "summary.md")
+	args := []string{"-base-url", srv.URL, "-xml-dir", filepath.Join(dir, "xml"), "-state", filepath.Join(dir, "state.json"),
+		"-archive-dir", "", "-log", logPath, "-summary", summaryPath}
+	var stdout, stderr bytes.Buffer
+	if code := Run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("Run() exit code = %d, stderr = %s", code, stderr.String())
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("read log: %v", err)
+	}
+	if n := strings.Count(string(data), "\n"); n != 1 || !strings.Contains(string(data), `"action":"download"`) {
+		t.Fatalf("log = %s", data)
+	}
+	summary, err := os.ReadFile(summaryPath)
+	if err != nil {
+		t.Fatalf("read summary: %v", err)
+	}
+	if !strings.Contains(string(summary), "1 downloaded, 0 updated, 0 removed") || !strings.Contains(string(summary), "| t1 | download | 11 |") {
+		t.Fatalf("summary = %s", summary)
+	}
+}
+
+func TestReconcileLogsAppliedChanges(t *testing.T) {
+	_, syncer, _ := seedMirror(t)
+	syncer.Log = NewSyncLog(nil)
+	if _, err := syncer.Reconcile(ReconcileOptions{Prune: true}); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	var got []string
+	for _, rec := range syncer.Log.Records() {
+		if rec.Mode != ModeReconcile {
+			t.Fatalf("record mode = %q", rec.Mode)
+		}
+		got = append(got, fmt.Sprintf("%s t%d", rec.Action, rec.TableID))
+	}
+	want := "download t1,update t2,remove t3,remove t4"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("records = %v, want %s", got, want)
+	}
+}
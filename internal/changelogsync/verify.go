@@ -0,0 +1,61 @@
+package changelogsync
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"mort/internal/xtbml"
+)
+
+// ErrIntegrity marks downloaded content that failed VerifyTable.
+var ErrIntegrity = errors.New("integrity check failed")
+
+// VerifyTable checks that body is a usable XTbML document for tableID: it
+// parses without anomalies other than a missing version attribute, which
+// published tables routinely omit, carries a content classification with a
+// table name, declares TableIdentity tableID, and holds at least one rate.
+// Errors wrap ErrIntegrity.
+func VerifyTable(tableID int, body []byte) error {
+	table, anomalies, err := xtbml.ParseTableMode(body, xtbml.ParseLenient)
+	if err != nil {
+		return fmt.Errorf("%w: t%d.xml: %v", ErrIntegrity, tableID, err)
+	}
+	for _, a := range anomalies {
+		if !errors.Is(a, xtbml.ErrMissingVersion) {
+			return fmt.Errorf("%w: t%d.xml: %v", ErrIntegrity, tableID, a)
+		}
+	}
+	class := table.Classification
+	if class == nil || strings.TrimSpace(class.TableName) == "" {
+		return fmt.Errorf("%w: t%d.xml has no content classification table name", ErrIntegrity, tableID)
+	}
+	if got := strings.TrimSpace(class.TableIdentity); got != strconv.Itoa(tableID) {
+		return fmt.Errorf("%w: t%d.xml declares TableIdentity %q", ErrIntegrity, tableID, got)
+	}
+	for _, t := range table.Tables {
+		for _, rate := range t.Rates {
+			if rate.Rate != nil {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w: t%d.xml has no rate data", ErrIntegrity, tableID)
+}
+
+// quarantine keeps rejected content for inspection, next to a note with the
+// reason, without touching the mirror.
+func (s *Syncer) quarantine(tableID int, body []byte, reason error) error {
+	if s.QuarantineDir == "" {
+		return nil
+	}
+	stamp := time.Now().UTC().Format("20060102T150405.000Z")
+	base := filepath.Join(s.QuarantineDir, fmt.Sprintf("t%d-%s", tableID, stamp))
+	if err := writeFileAtomic(base+".xml", body); err != nil {
+		return err
+	}
+	return writeFileAtomic(base+".reason.txt", []byte(reason.Error()+"\n"))
+}
@@ -0,0 +1,93 @@
+package changelogsync
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mort/internal/changelogsync/soatest"
+)
+
+func TestVerifyTable(t *testing.T) {
+	tests := []struct {
+		name string
+		body []byte
+		want string
+	}{
+		{name: "valid", body: tableXML(7, "")},
+		{name: "no version attribute", body: bytes.Replace(tableXML(7, ""), []byte(`<XTbML version="1.3">`), []byte(`<XTbML>`), 1)},
+		{name: "html error page", body: []byte("<html><body>Service Unavailable</body></html>"), want: "t7.xml"},
+		{name: "truncated", body: tableXML(7, "")[:200], want: "t7.xml"},
+		{name: "identity mismatch", body: tableXML(8, ""), want: `declares TableIdentity "8"`},
+		{name: "no table name", body: tableXMLNamed(7, "", ""), want: "missing table name"},
+		{name: "corrupt rate", body: bytes.Replace(tableXML(7, ""), []byte(`<Y t="40">0.01</Y>`), []byte(`<Y t="40">0,01</Y>`), 1), want: "not a number"},
+		{name: "no rates", body: bytes.Replace(tableXML(7, ""), []byte(`<Y t="40">0.01</Y>`), nil, 1), want: "no rate data"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyTable(7, tt.body)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("VerifyTable() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrIntegrity) {
+				t.Fatalf("VerifyTable() error = %v, want ErrIntegrity", err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("VerifyTable() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyncQuarantinesRejectedDownloads(t *testing.T) {
+	srv := soatest.NewServer()
+	defer srv.Close()
+	srv.AddEntries(soatest.Entry{TableIdentity: 11, TableID: 1, Action: "Update", LogMillis: 1000})
+	page := []byte("<html><body>Maintenance</body></html>")
+	srv.SetTable(1, page)
+
+	syncer, _ := newTestSyncer(t, srv, 10)
+	syncer.QuarantineDir = filepath.Join(t.TempDir(), "quarantine")
+	if err := os.MkdirAll(syncer.XMLDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	good := tableXML(1, "good")
+	if err := os.WriteFile(TablePath(syncer.XMLDir, 1), good, 0o644); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	_, err := syncer.Sync()
+	if !errors.Is(err, ErrIntegrity) {
+		t.Fatalf("Sync() error = %v, want ErrIntegrity", err)
+	}
+	data, err := os.ReadFile(TablePath(syncer.XMLDir, 1))
+	if err != nil || !bytes.Equal(data, good) {
+		t.Fatalf("t1.xml = %q, %v; want the good copy kept", data, err)
+	}
+	state, err := LoadState(syncer.StatePath)
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	if state.LastLogMillis != 0 {
+		t.Fatalf("LastLogMillis = %d, want 0", state.LastLogMillis)
+	}
+
+	kept, _ := filepath.Glob(filepath.Join(syncer.QuarantineDir, "t1-*.xml"))
+	if len(kept) != 1 {
+		t.Fatalf("quarantined files = %v, want one", kept)
+	}
+	body, err := os.ReadFile(kept[0])
+	if err != nil || !bytes.Equal(body, page) {
+		t.Fatalf("quarantined body = %q, %v", body, err)
+	}
+	reason, err := os.ReadFile(strings.TrimSuffix(kept[0], ".xml") + ".reason.txt")
+	if err != nil || !strings.Contains(string(reason), ErrIntegrity.Error()) {
+		t.Fatalf("reason = %q, %v", reason, err)
+	}
+}
@@ -0,0 +1,242 @@
+// Package duplicates finds rate tables that repeat across the library. Each
+// rate grid is fingerprinted by its cells, and grids with identical or nearly
+// identical rates are clustered so a canonical table can be picked.
+package duplicates
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"mort/internal/xtbml"
+)
+
+// Grid is one rate table of a converted file, reduced to its cells.
+type Grid struct {
+	File          string `json:"file"`
+	Identifier    string `json:"identifier"`
+	TableIdentity string `json:"tableIdentity"`
+	Name          string `json:"name"`
+	// Table is the index of the rate table inside the file.
+	Table int `json:"table"`
+	Cells int `json:"cells"`
+	// Fingerprint is a SHA-256 over the cell positions and rates; grids with
+	// the same fingerprint have identical rates.
+	Fingerprint string `json:"fingerprint"`
+
+	// shape hashes the cell positions and which cells are blank; only grids
+	// of the same shape are compared under a tolerance.
+	shape string
+	rates []float64
+}
+
+// Grids fingerprints every rate table of payload, read from file. Tables
+// without any populated cell are skipped.
+func Grids(file string, payload *xtbml.ConvertedTable) []Grid {
+	var grids []Grid
+	for i, table := range payload.Tables {
+		entries := append([]xtbml.RateEntryPayload(nil), table.Rates...)
+		sort.SliceStable(entries, func(a, b int) bool { return entryLess(entries[a], entries[b]) })
+
+		shape := sha256.New()
+		full := sha256.New()
+		var rates []float64
+		for _, entry := range entries {
+			pos := strconv.Itoa(entry.Age)
+			if entry.Duration != nil {
+				pos += "/" + strconv.Itoa(*entry.Duration)
+			}
+			if entry.Rate == nil {
+				shape.Write([]byte(pos + "=-;"))
+				full.Write([]byte(pos + "=-;"))
+				continue
+			}
+			shape.Write([]byte(pos + ";"))
+			full.Write([]byte(pos + "=" + strconv.FormatFloat(*entry.Rate, 'g', -1, 64) + ";"))
+			rates = append(rates, *entry.Rate)
+		}
+		if len(rates) == 0 {
+			continue
+		}
+		grid := Grid{
+			File:        file,
+			Identifier:  payload.Identifier,
+			Table:       i,
+			Cells:       len(rates),
+			Fingerprint: hex.EncodeToString(full.Sum(nil)),
+			shape:       hex.EncodeToString(shape.Sum(nil)),
+			rates:       rates,
+		}
+		if payload.Classification != nil {
+			grid.TableIdentity = payload.Classification.TableIdentity
+			grid.Name = payload.Classification.TableName
+		}
+		grids = append(grids, grid)
+	}
+	return grids
+}
+
+func entryLess(a, b xtbml.RateEntryPayload) bool {
+	if a.Age != b.Age {
+		return a.Age < b.Age
+	}
+	if (a.Duration == nil) != (b.Duration == nil) {
+		return a.Duration == nil
+	}
+	return a.Duration != nil && *a.Duration < *b.Duration
+}
+
+// Cluster is a set of grids with identical or nearly identical rates. Members
+// are ordered by table identity, so the first is the oldest published table
+// and a natural canonical choice. MaxDelta is the largest absolute rate
+// difference between any member and the first; zero means every member is
+// identical.
+type Cluster struct {
+	Cells    int     `json:"cells"`
+	MaxDelta float64 `json:"maxDelta"`
+	Members  []Grid  `json:"members"`
+}
+
+// Identical reports whether every member has exactly the same rates.
+func (c Cluster) Identical() bool {
+	for _, m := range c.Members[1:] {
+		if m.Fingerprint != c.Members[0].Fingerprint {
+			return false
+		}
+	}
+	return true
+}
+
+// Options tunes clustering.
+type Options struct {
+	// Tolerance is the largest absolute difference at which two rates still
+	// count as equal. Zero clusters only identical grids.
+	Tolerance float64
+	// MinCells skips grids with fewer populated cells, which match each other
+	// by coincidence.
+	MinCells int
+	// SameFile also reports grids that only repeat inside one file.
+	SameFile bool
+}
+
+// Find clusters grids with identical rates, or rates within opts.Tolerance of
+// each other cell by cell, and returns clusters of two or more grids, largest
+// first. Grids are only compared when their cell positions and blanks agree.
+func Find(grids []Grid, opts Options) []Cluster {
+	byShape := make(map[string][]int)
+	for i, g := range grids {
+		if g.Cells < opts.MinCells {
+			continue
+		}
+		byShape[g.shape] = append(byShape[g.shape], i)
+	}
+
+	parent := make([]int, len(grids))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	union := func(a, b int) {
+		if ra, rb := find(a), find(b); ra != rb {
+			parent[rb] = ra
+		}
+	}
+
+	for _, members := range byShape {
+		// Identical grids collapse onto one representative before the
+		// pairwise tolerance comparison.
+		reps := make(map[string]int)
+		var distinct []int
+		for _, i := range members {
+			if rep, ok := reps[grids[i].Fingerprint]; ok {
+				union(rep, i)
+				continue
+			}
+			reps[grids[i].Fingerprint] = i
+			distinct = append(distinct, i)
+		}
+		if opts.Tolerance <= 0 {
+			continue
+		}
+		for x := 0; x < len(distinct); x++ {
+			for y := x + 1; y < len(distinct); y++ {
+				if maxDelta(grids[distinct[x]].rates, grids[distinct[y]].rates) <= opts.Tolerance {
+					union(distinct[x], distinct[y])
+				}
+			}
+		}
+	}
+
+	groups := make(map[int][]Grid)
+	for _, members := range byShape {
+		for _, i := range members {
+			root := find(i)
+			groups[root] = append(groups[root], grids[i])
+		}
+	}
+	var clusters []Cluster
+	for _, members := range groups {
+		if len(members) < 2 || (!opts.SameFile && singleFile(members)) {
+			continue
+		}
+		sort.SliceStable(members, func(a, b int) bool { return gridLess(members[a], members[b]) })
+		cluster := Cluster{Cells: members[0].Cells, Members: members}
+		for _, m := range members[1:] {
+			cluster.MaxDelta = math.Max(cluster.MaxDelta, maxDelta(members[0].rates, m.rates))
+		}
+		clusters = append(clusters, cluster)
+	}
+	sort.Slice(clusters, func(a, b int) bool {
+		if len(clusters[a].Members) != len(clusters[b].Members) {
+			return len(clusters[a].Members) > len(clusters[b].Members)
+		}
+		return gridLess(clusters[a].Members[0], clusters[b].Members[0])
+	})
+	return clusters
+}
+
+func maxDelta(a, b []float64) float64 {
+	var worst float64
+	for i := range a {
+		worst = math.Max(worst, math.Abs(a[i]-b[i]))
+	}
+	return worst
+}
+
+func singleFile(members []Grid) bool {
+	for _, m := range members[1:] {
+		if m.File != members[0].File {
+			return false
+		}
+	}
+	return true
+}
+
+// gridLess orders grids by numeric table identity, then file and table index.
+func gridLess(a, b Grid) bool {
+	ai, aErr := strconv.Atoi(strings.TrimSpace(a.TableIdentity))
+	bi, bErr := strconv.Atoi(strings.TrimSpace(b.TableIdentity))
+	switch {
+	case aErr == nil && bErr == nil && ai != bi:
+		return ai < bi
+	case aErr == nil && bErr != nil:
+		return true
+	case aErr != nil && bErr == nil:
+		return false
+	case a.TableIdentity != b.TableIdentity:
+		return a.TableIdentity < b.TableIdentity
+	case a.File != b.File:
+		return a.File < b.File
+	default:
+		return a.Table < b.Table
+	}
+}
@@ -0,0 +1,95 @@
+package duplicates
+
+import (
+	"testing"
+
+	"mort/internal/xtbml"
+)
+
+func table(identity string, rates ...float64) *xtbml.ConvertedTable {
+	tp := xtbml.TablePayload{}
+	for i, r := range rates {
+		tp.Rates = append(tp.Rates, xtbml.RateEntryPayload{Age: 40 + i, Rate: &r})
+	}
+	return &xtbml.ConvertedTable{
+		Identifier:     "table_" + identity,
+		Classification: &xtbml.ClassificationPayload{TableIdentity: identity, TableName: "Table " + identity},
+		Tables:         []xtbml.TablePayload{tp},
+	}
+}
+
+func grids(tables ...*xtbml.ConvertedTable) []Grid {
+	var all []Grid
+	for _, tbl := range tables {
+		all = append(all, Grids("t"+tbl.Classification.TableIdentity+".json", tbl)...)
+	}
+	return all
+}
+
+func identities(c Cluster) []string {
+	var ids []string
+	for _, m := range c.Members {
+		ids = append(ids, m.TableIdentity)
+	}
+	return ids
+}
+
+func TestFindIdentical(t *testing.T) {
+	all := grids(
+		table("20", 0.1, 0.2, 0.3),
+		table("3", 0.1, 0.2, 0.3),
+		table("7", 0.1, 0.2, 0.30001),
+		table("9", 0.1, 0.2),
+	)
+	clusters := Find(all, Options{})
+	if len(clusters) != 1 {
+		t.Fatalf("Find() = %+v, want one cluster", clusters)
+	}
+	c := clusters[0]
+	if got := identities(c); len(got) != 2 || got[0] != "3" || got[1] != "20" {
+		t.Fatalf("members = %v, want [3 20]", got)
+	}
+	if !c.Identical() || c.MaxDelta != 0 || c.Cells != 3 {
+		t.Fatalf("cluster = %+v", c)
+	}
+}
+
+func TestFindWithinTolerance(t *testing.T) {
+	all := grids(
+		table("1", 0.1, 0.2, 0.3),
+		table("2", 0.1, 0.2, 0.30001),
+		table("3", 0.1, 0.2, 0.31),
+	)
+	clusters := Find(all, Options{Tolerance: 0.0001})
+	if len(clusters) != 1 || len(clusters[0].Members) != 2 || clusters[0].Identical() {
+		t.Fatalf("Find() = %+v", clusters)
+	}
+	if d := clusters[0].MaxDelta; d <= 0 || d > 0.0001 {
+		t.Fatalf("MaxDelta = %v", d)
+	}
+}
+
+func TestFindRespectsShapeAndMinCells(t *testing.T) {
+	blank := table("2", 0.1, 0.2, 0.3)
+	blank.Tables[0].Rates[1].Rate = nil
+	all := grids(table("1", 0.1, 0.2, 0.3), blank, table("3", 0.5), table("4", 0.5))
+
+	if clusters := Find(all, Options{Tolerance: 1, MinCells: 2}); len(clusters) != 0 {
+		t.Fatalf("Find() = %+v, want no clusters", clusters)
+	}
+	if clusters := Find(all, Options{}); len(clusters) != 1 || identities(clusters[0])[0] != "3" {
+		t.Fatalf("Find() without MinCells = %+v", clusters)
+	}
+}
+
+func TestFindSameFile(t *testing.T) {
+	tbl := table("1", 0.1, 0.2)
+	tbl.Tables = append(tbl.Tables, tbl.Tables[0])
+	all := grids(tbl)
+	if clusters := Find(all, Options{}); len(clusters) != 0 {
+		t.Fatalf("Find() = %+v, want repeats within one file skipped", clusters)
+	}
+	if clusters := Find(all, Options{SameFile: true}); len(clusters) != 1 {
+		t.Fatalf("Find(SameFile) = %+v", clusters)
+	}
+}
@@ -0,0 +1,162 @@
+// Package lint runs data-quality rules against converted rate tables.
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"mort/internal/xtbml"
+)
+
+// Severity ranks findings; higher is more serious.
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+// MarshalText renders the severity by name in JSON output.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText parses a severity name written by MarshalText.
+func (s *Severity) UnmarshalText(text []byte) error {
+	parsed, err := ParseSeverity(string(text))
+	if err != nil {
+		return err
+	}
+	*s = parsed
+	return nil
+}
+
+// ParseSeverity maps a user-supplied name to a Severity.
+func ParseSeverity(name string) (Severity, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "info":
+		return SeverityInfo, nil
+	case "warning", "warn":
+		return SeverityWarning, nil
+	case "error":
+		return SeverityError, nil
+	default:
+		return 0, fmt.Errorf("unknown severity %q (want info, warning or error)", name)
+	}
+}
+
+// Finding is one problem a rule found. Table is the index of the rate table
+// inside the payload; Age and Duration locate the cell when the finding is
+// about a single cell.
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Table    int      `json:"table"`
+	Age      *int     `json:"age,omitempty"`
+	Duration *int     `json:"duration,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// Location describes where the finding applies, e.g. "table 0 age 40
+// duration 2".
+func (f Finding) Location() string {
+	loc := fmt.Sprintf("table %d", f.Table)
+	if f.Age != nil {
+		loc += fmt.Sprintf(" age %d", *f.Age)
+	}
+	if f.Duration != nil {
+		loc += fmt.Sprintf(" duration %d", *f.Duration)
+	}
+	return loc
+}
+
+// Rule is a named data-quality check.
+type Rule struct {
+	Name        string
+	Description string
+	// Check reports the findings for one rate table of payload.
+	Check func(payload *xtbml.ConvertedTable, index int, table xtbml.TablePayload) []Finding
+}
+
+// Rules returns the built-in rules in reporting order.
+func Rules() []Rule {
+	return []Rule{
+		{Name: RuleRateRange, Description: "rates outside [0,1] for probability content types", Check: checkRateRange},
+		{Name: RuleMissingCell, Description: "missing cells inside the axis range", Check: checkMissingCells},
+		{Name: RuleAxisMetadata, Description: "axis minimum, maximum or increment disagreeing with the rates", Check: checkAxisMetadata},
+		{Name: RuleMonotoneMortality, Description: "mortality decreasing with age at older ages", Check: checkMonotoneMortality},
+		{Name: RuleDuplicateCell, Description: "the same cell appearing more than once", Check: checkDuplicateCells},
+		{Name: RuleUnknownCode, Description: "content type, nation, data type or scale type codes missing from the code lists", Check: checkUnknownCodes},
+	}
+}
+
+// Options filters what Lint reports.
+type Options struct {
+	// Rules limits linting to the named rules; all rules when empty.
+	Rules []string
+	// MinSeverity drops findings below this severity.
+	MinSeverity Severity
+}
+
+// Lint runs the selected rules over every rate table in payload and returns
+// findings ordered by table, rule order and position. Unknown rule names are
+// an error.
+func Lint(payload *xtbml.ConvertedTable, opts Options) ([]Finding, error) {
+	rules, err := selectRules(opts.Rules)
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	for i, table := range payload.Tables {
+		for _, rule := range rules {
+			for _, f := range rule.Check(payload, i, table) {
+				if f.Severity >= opts.MinSeverity {
+					findings = append(findings, f)
+				}
+			}
+		}
+	}
+	return findings, nil
+}
+
+func selectRules(names []string) ([]Rule, error) {
+	all := Rules()
+	if len(names) == 0 {
+		return all, nil
+	}
+	want := make(map[string]bool, len(names))
+	for _, name := range names {
+		want[strings.TrimSpace(name)] = true
+	}
+	var rules []Rule
+	for _, rule := range all {
+		if want[rule.Name] {
+			rules = append(rules, rule)
+			delete(want, rule.Name)
+		}
+	}
+	if len(want) > 0 {
+		unknown := make([]string, 0, len(want))
+		for name := range want {
+			unknown = append(unknown, name)
+		}
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown lint rule %s", strings.Join(unknown, ", "))
+	}
+	return rules, nil
+}
@@ -0,0 +1,212 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"mort/internal/xtbml"
+)
+
+func rate(v float64) *float64 { return &v }
+
+func dur(v int) *int { return &v }
+
+func axis(name, min, max, inc string) xtbml.AxisDefinitionPayload {
+	return xtbml.AxisDefinitionPayload{ID: name, AxisName: name, MinValue: min, MaxValue: max, Increment: inc}
+}
+
+func payload(contentCode, contentLabel string, axes []xtbml.AxisDefinitionPayload, rates ...xtbml.RateEntryPayload) *xtbml.ConvertedTable {
+	return &xtbml.ConvertedTable{
+		Classification: &xtbml.ClassificationPayload{
+			ContentType: xtbml.ClassifiedValuePayload{Code: contentCode, Label: contentLabel},
+		},
+		Tables: []xtbml.TablePayload{{
+			Metadata: &xtbml.TableMetaPayload{Axes: axes},
+			Rates:    rates,
+		}},
+	}
+}
+
+// describe renders findings compactly for comparison.
+func describe(findings []Finding) string {
+	var lines []string
+	for _, f := range findings {
+		lines = append(lines, fmt.Sprintf("%s %s %s: %s", f.Severity, f.Rule, f.Location(), f.Message))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestLintRules(t *testing.T) {
+	ageAxis := []xtbml.AxisDefinitionPayload{axis("Age", "60", "62", "1")}
+	tests := []struct {
+		name  string
+		table *xtbml.ConvertedTable
+		rule  string
+		want  string
+	}{
+		{
+			name: "rate above one for mortality",
+			table: payload("4", "Insured Lives Mortality", ageAxis,
+				xtbml.RateEntryPayload{Age: 60, Rate: rate(0.1)},
+				xtbml.RateEntryPayload{Age: 61, Rate: rate(1.5)},
+				xtbml.RateEntryPayload{Age: 62, Rate: rate(-0.1)}),
+			rule: RuleRateRange,
+			want: "error rate-range table 0 age 61: rate 1.5 is outside [0,1] for content type \"Insured Lives Mortality\" (4)\n" +
+				"error rate-range table 0 age 62: rate -0.1 is outside [0,1] for content type \"Insured Lives Mortality\" (4)",
+		},
+		{
+			name: "rate above one for claim incidence",
+			table: payload("80", "Claim Incidence", ageAxis,
+				xtbml.RateEntryPayload{Age: 60, Rate: rate(2.0643)}),
+			rule: RuleRateRange,
+			want: "warning rate-range table 0 age 60: rate 2.0643 is outside [0,1] for content type \"Claim Incidence\" (80)",
+		},
+		{
+			name: "rate above one for a projection scale",
+			table: payload("22", "Projection Scale", ageAxis,
+				xtbml.RateEntryPayload{Age: 60, Rate: rate(1.5)}),
+			rule: RuleRateRange,
+		},
+		{
+			name: "blank cells",
+			table: payload("4", "Insured Lives Mortality", []xtbml.AxisDefinitionPayload{axis("Age", "60", "64", "1")},
+				xtbml.RateEntryPayload{Age: 60, Rate: nil},
+				xtbml.RateEntryPayload{Age: 61, Rate: rate(0.1)},
+				xtbml.RateEntryPayload{Age: 62, Rate: nil},
+				xtbml.RateEntryPayload{Age: 63, Rate: rate(0.2)},
+				xtbml.RateEntryPayload{Age: 64, Rate: nil}),
+			rule: RuleMissingCell,
+			want: "info missing-cell table 0 age 60: no rates for age 60 at the edge of the table\n" +
+				"warning missing-cell table 0 age 62: cell has no rate\n" +
+				"info missing-cell table 0 age 64: no rates for age 64 at the edge of the table",
+		},
+		{
+			name: "blank cells without axis metadata",
+			table: payload("4", "Insured Lives Mortality", nil,
+				xtbml.RateEntryPayload{Age: 33, Duration: dur(1), Rate: nil},
+				xtbml.RateEntryPayload{Age: 33, Duration: dur(2), Rate: rate(0.4)},
+				xtbml.RateEntryPayload{Age: 31, Duration: dur(1), Rate: nil},
+				xtbml.RateEntryPayload{Age: 31, Duration: dur(2), Rate: rate(0.2)},
+				xtbml.RateEntryPayload{Age: 32, Duration: dur(1), Rate: nil},
+				xtbml.RateEntryPayload{Age: 32, Duration: dur(2), Rate: rate(0.3)},
+				xtbml.RateEntryPayload{Age: 30, Duration: dur(1), Rate: nil},
+				xtbml.RateEntryPayload{Age: 30, Duration: dur(2), Rate: rate(0.1)}),
+			rule: RuleMissingCell,
+			want: "info missing-cell table 0 age 30 duration 1: no rates for duration 1 at the edge of the table\n" +
+				"info missing-cell table 0 age 31 duration 1: no rates for duration 1 at the edge of the table\n" +
+				"info missing-cell table 0 age 32 duration 1: no rates for duration 1 at the edge of the table\n" +
+				"info missing-cell table 0 age 33 duration 1: no rates for duration 1 at the edge of the table",
+		},
+		{
+			name: "absent cells inside the axis range",
+			table: payload("4", "Insured Lives Mortality",
+				[]xtbml.AxisDefinitionPayload{axis("Age", "20", "23", "1"), axis("Duration", "1", "3", "1")},
+				xtbml.RateEntryPayload{Age: 20, Duration: dur(1), Rate: rate(0.1)},
+				xtbml.RateEntryPayload{Age: 20, Duration: dur(2), Rate: rate(0.1)},
+				xtbml.RateEntryPayload{Age: 20, Duration: dur(3), Rate: rate(0.1)},
+				xtbml.RateEntryPayload{Age: 23, Duration: dur(1), Rate: rate(0.1)}),
+			rule: RuleMissingCell,
+			want: "warning missing-cell table 0 age 21: no cells for age 21-22 inside the declared range\n" +
+				"warning missing-cell table 0 age 23 duration 2: no cell for duration 2-3 inside the declared range",
+		},
+		{
+			name: "axis metadata disagrees with data",
+			table: payload("4", "Insured Lives Mortality",
+				[]xtbml.AxisDefinitionPayload{axis("Age", "18", "70", "1"), axis("Duration", "1", "x", "1")},
+				xtbml.RateEntryPayload{Age: 20, Duration: dur(1), Rate: rate(0.1)},
+				xtbml.RateEntryPayload{Age: 25, Duration: dur(2), Rate: rate(0.1)},
+				xtbml.RateEntryPayload{Age: 70, Duration: dur(3), Rate: rate(0.1)}),
+			rule: RuleAxisMetadata,
+			want: "warning axis-metadata table 0: axis Age declares minimum 18 but the data starts at 20\n" +
+				"warning axis-metadata table 0: axis Age declares increment 1 but the data steps by 5\n" +
+				"warning axis-metadata table 0: axis Duration maximum \"x\" is not a whole number",
+		},
+		{
+			name: "mortality falls at older ages",
+			table: payload("84", "Population Mortality", []xtbml.AxisDefinitionPayload{axis("Age", "58", "62", "1")},
+				xtbml.RateEntryPayload{Age: 58, Rate: rate(0.02)},
+				xtbml.RateEntryPayload{Age: 59, Rate: rate(0.01)},
+				xtbml.RateEntryPayload{Age: 60, Rate: rate(0.03)},
+				xtbml.RateEntryPayload{Age: 61, Rate: rate(0.025)},
+				xtbml.RateEntryPayload{Age: 62, Rate: rate(0.04)}),
+			rule: RuleMonotoneMortality,
+			want: "warning monotone-mortality table 0 age 61: rate 0.025 is below 0.03 at age 60",
+		},
+		{
+			name: "falling termination rates are fine",
+			table: payload("5", "Termination Voluntary", ageAxis,
+				xtbml.RateEntryPayload{Age: 60, Rate: rate(0.3)},
+				xtbml.RateEntryPayload{Age: 61, Rate: rate(0.2)}),
+			rule: RuleMonotoneMortality,
+		},
+		{
+			name: "duplicate cells",
+			table: payload("4", "Insured Lives Mortality",
+				[]xtbml.AxisDefinitionPayload{axis("Age", "20", "20", "1"), axis("Duration", "1", "1", "1")},
+				xtbml.RateEntryPayload{Age: 20, Duration: dur(1), Rate: rate(0.1)},
+				xtbml.RateEntryPayload{Age: 20, Duration: dur(1), Rate: rate(0.2)}),
+			rule: RuleDuplicateCell,
+			want: "error duplicate-cell table 0 age 20 duration 1: cell appears 2 times",
+		},
+		{
+			name: "unregistered codes",
+			table: payload("999", "Bespoke", []xtbml.AxisDefinitionPayload{
+				{ID: "Age", AxisName: "Age", ScaleType: xtbml.ClassifiedValuePayload{Code: "3", Label: "Age"}},
+				{ID: "Band", AxisName: "Band", ScaleType: xtbml.ClassifiedValuePayload{Code: "7", Label: "Band"}},
+			}),
+			rule: RuleUnknownCode,
+			want: "warning unknown-code table 0: classification.contentType code \"999\" (\"Bespoke\") is not a registered contentType code\n" +
+				"warning unknown-code table 0: tables[0].metadata.axes[1].scaleType code \"7\" (\"Band\") is not a registered scaleType code",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings, err := Lint(tt.table, Options{Rules: []string{tt.rule}})
+			if err != nil {
+				t.Fatalf("Lint() error = %v", err)
+			}
+			if got := describe(findings); got != tt.want {
+				t.Fatalf("Lint() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintFiltersBySeverity(t *testing.T) {
+	table := payload("4", "Insured Lives Mortality", []xtbml.AxisDefinitionPayload{axis("Age", "60", "62", "1")},
+		xtbml.RateEntryPayload{Age: 60, Rate: nil},
+		xtbml.RateEntryPayload{Age: 61, Rate: rate(1.5)},
+		xtbml.RateEntryPayload{Age: 62, Rate: rate(0.5)})
+
+	all, err := Lint(table, Options{})
+	if err != nil {
+		t.Fatalf("Lint() error = %v", err)
+	}
+	errs, err := Lint(table, Options{MinSeverity: SeverityError})
+	if err != nil {
+		t.Fatalf("Lint() error = %v", err)
+	}
+	if len(all) <= len(errs) || len(errs) != 1 || errs[0].Rule != RuleRateRange {
+		t.Fatalf("all = %s\nerrors = %s", describe(all), describe(errs))
+	}
+}
+
+func TestLintRejectsUnknownRule(t *testing.T) {
+	_, err := Lint(payload("4", "", nil), Options{Rules: []string{"bogus", RuleRateRange}})
+	if err == nil || !strings.Contains(err.Error(), "bogus") {
+		t.Fatalf("Lint() error = %v, want unknown rule", err)
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	for name, want := range map[string]Severity{"info": SeverityInfo, "WARN": SeverityWarning, " error ": SeverityError} {
+		got, err := ParseSeverity(name)
+		if err != nil || got != want {
+			t.Fatalf("ParseSeverity(%q) = %v, %v; want %v", name, got, err, want)
+		}
+	}
+	if _, err := ParseSeverity("fatal"); err == nil {
+		t.Fatal("ParseSeverity(fatal) should fail")
+	}
+}
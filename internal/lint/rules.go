@@ -0,0 +1,510 @@
+package lint
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"mort/internal/xtbml"
+)
+
+// Rule names.
+const (
+	RuleRateRange         = "rate-range"
+	RuleMissingCell       = "missing-cell"
+	RuleAxisMetadata      = "axis-metadata"
+	RuleMonotoneMortality = "monotone-mortality"
+	RuleDuplicateCell     = "duplicate-cell"
+	RuleUnknownCode       = "unknown-code"
+)
+
+// olderAge is the first age from which mortality is expected not to fall.
+const olderAge = 60
+
+// maxAxisSteps bounds how many axis positions the missing-cell rule walks,
+// guarding against nonsensical declared ranges.
+const maxAxisSteps = 10000
+
+// Content type codes (the XTbML tc attribute) whose rates are usually
+// probabilities but are also published per unit of exposure or as multiples,
+// so rates outside [0,1] are only warnings. Mortality content types are
+// always probabilities.
+var probabilityContentTypes = map[string]bool{
+	"5":  true, // Termination Voluntary
+	"8":  true, // Disability Recovery
+	"14": true, // Remarriage
+	"77": true, // ADB, AD&D
+	"80": true, // Claim Incidence
+	"82": true, // Claim Termination
+}
+
+// Content type codes whose rates are mortality rates.
+var mortalityContentTypes = map[string]bool{
+	"1": true, "2": true, "3": true, "4": true, "57": true,
+	"78": true, "83": true, "84": true, "85": true,
+}
+
+func contentType(payload *xtbml.ConvertedTable) xtbml.ClassifiedValuePayload {
+	if payload.Classification == nil {
+		return xtbml.ClassifiedValuePayload{}
+	}
+	return payload.Classification.ContentType
+}
+
+// rateRangeSeverity returns the severity of a rate outside [0,1] in payload,
+// and false when its content type is not a probability.
+func rateRangeSeverity(payload *xtbml.ConvertedTable) (Severity, bool) {
+	switch {
+	case isMortality(payload):
+		return SeverityError, true
+	case probabilityContentTypes[strings.TrimSpace(contentType(payload).Code)]:
+		return SeverityWarning, true
+	default:
+		return 0, false
+	}
+}
+
+func isMortality(payload *xtbml.ConvertedTable) bool {
+	ct := contentType(payload)
+	return mortalityContentTypes[strings.TrimSpace(ct.Code)] ||
+		strings.Contains(strings.ToLower(ct.Label), "mortality")
+}
+
+// noDuration is the duration key of cells on single-axis tables.
+const noDuration = math.MinInt
+
+type cellKey struct {
+	age      int
+	duration int
+}
+
+func keyOf(entry xtbml.RateEntryPayload) cellKey {
+	if entry.Duration == nil {
+		return cellKey{age: entry.Age, duration: noDuration}
+	}
+	return cellKey{age: entry.Age, duration: *entry.Duration}
+}
+
+func cellFinding(rule string, severity Severity, index int, key cellKey, format string, args ...any) Finding {
+	age := key.age
+	f := Finding{Rule: rule, Severity: severity, Table: index, Age: &age, Message: fmt.Sprintf(format, args...)}
+	if key.duration != noDuration {
+		dur := key.duration
+		f.Duration = &dur
+	}
+	return f
+}
+
+func checkRateRange(payload *xtbml.ConvertedTable, index int, table xtbml.TablePayload) []Finding {
+	severity, ok := rateRangeSeverity(payload)
+	if !ok {
+		return nil
+	}
+	var findings []Finding
+	for _, entry := range table.Rates {
+		if entry.Rate != nil && (*entry.Rate < 0 || *entry.Rate > 1) {
+			findings = append(findings, cellFinding(RuleRateRange, severity, index, keyOf(entry),
+				"rate %s is outside [0,1] for content type %s", formatRate(*entry.Rate), describeContentType(payload)))
+		}
+	}
+	return findings
+}
+
+func describeContentType(payload *xtbml.ConvertedTable) string {
+	ct := contentType(payload)
+	switch {
+	case ct.Label != "" && ct.Code != "":
+		return fmt.Sprintf("%q (%s)", ct.Label, ct.Code)
+	case ct.Label != "":
+		return strconv.Quote(ct.Label)
+	default:
+		return ct.Code
+	}
+}
+
+func formatRate(rate float64) string {
+	return strconv.FormatFloat(rate, 'g', -1, 64)
+}
+
+// axisSpec is the numeric form of an axis definition; ok fields report which
+// values parsed.
+type axisSpec struct {
+	name                   string
+	min, max, increment    int
+	minOK, maxOK, incOK    bool
+	rawMin, rawMax, rawInc string
+}
+
+func parseAxis(axis xtbml.AxisDefinitionPayload) axisSpec {
+	spec := axisSpec{name: axisName(axis), rawMin: axis.MinValue, rawMax: axis.MaxValue, rawInc: axis.Increment}
+	spec.min, spec.minOK = parseAxisValue(axis.MinValue)
+	spec.max, spec.maxOK = parseAxisValue(axis.MaxValue)
+	spec.increment, spec.incOK = parseAxisValue(axis.Increment)
+	return spec
+}
+
+func axisName(axis xtbml.AxisDefinitionPayload) string {
+	if name := strings.TrimSpace(axis.AxisName); name != "" {
+		return name
+	}
+	if id := strings.TrimSpace(axis.ID); id != "" {
+		return id
+	}
+	return "axis"
+}
+
+func parseAxisValue(raw string) (int, bool) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	if err != nil || f != math.Trunc(f) {
+		return 0, false
+	}
+	return int(f), true
+}
+
+// walkable reports whether the axis declares a usable range to step through.
+func (a axisSpec) walkable() bool {
+	return a.minOK && a.maxOK && a.incOK && a.increment > 0 && a.min <= a.max &&
+		(a.max-a.min)/a.increment <= maxAxisSteps
+}
+
+// axes returns the parsed first (age) and second (duration) axis of table.
+func axes(table xtbml.TablePayload) (ageAxis, durAxis *axisSpec) {
+	if table.Metadata == nil {
+		return nil, nil
+	}
+	if len(table.Metadata.Axes) > 0 {
+		spec := parseAxis(table.Metadata.Axes[0])
+		ageAxis = &spec
+	}
+	if len(table.Metadata.Axes) > 1 {
+		spec := parseAxis(table.Metadata.Axes[1])
+		durAxis = &spec
+	}
+	return ageAxis, durAxis
+}
+
+func checkMissingCells(_ *xtbml.ConvertedTable, index int, table xtbml.TablePayload) []Finding {
+	findings := blankCells(index, table)
+	cells := make(map[cellKey]bool, len(table.Rates))
+	byAge := make(map[int][]int)
+	hasDuration := false
+	for _, entry := range table.Rates {
+		key := keyOf(entry)
+		if !cells[key] {
+			byAge[key.age] = append(byAge[key.age], key.duration)
+		}
+		cells[key] = true
+		hasDuration = hasDuration || entry.Duration != nil
+	}
+
+	ageAxis, durAxis := axes(table)
+	if ageAxis == nil || !ageAxis.walkable() || len(table.Rates) == 0 {
+		sortFindings(findings)
+		return findings
+	}
+	var missingAges []int
+	for age := ageAxis.min; age <= ageAxis.max; age += ageAxis.increment {
+		if len(byAge[age]) == 0 {
+			missingAges = append(missingAges, age)
+			continue
+		}
+		if !hasDuration || durAxis == nil || !durAxis.walkable() {
+			continue
+		}
+		var missingDurations []int
+		for dur := durAxis.min; dur <= durAxis.max; dur += durAxis.increment {
+			if !cells[cellKey{age: age, duration: dur}] {
+				missingDurations = append(missingDurations, dur)
+			}
+		}
+		for _, run := range runs(missingDurations, durAxis.increment) {
+			key := cellKey{age: age, duration: run[0]}
+			findings = append(findings, cellFinding(RuleMissingCell, SeverityWarning, index, key,
+				"no cell for %s %s inside the declared range", strings.ToLower(durAxis.name), describeRun(run)))
+		}
+	}
+	for _, run := range runs(missingAges, ageAxis.increment) {
+		findings = append(findings, cellFinding(RuleMissingCell, SeverityWarning, index, cellKey{age: run[0], duration: noDuration},
+			"no cells for %s %s inside the declared range", strings.ToLower(ageAxis.name), describeRun(run)))
+	}
+	sortFindings(findings)
+	return findings
+}
+
+// blankCells reports cells without a rate. Each line of cells (the whole
+// table for one axis, each age row for two) is walked in order: blanks between
+// populated cells are warnings, while blanks at either end of a line, such as
+// the unused corner of a select table, are reported once per run as info.
+func blankCells(index int, table xtbml.TablePayload) []Finding {
+	lines := make(map[int][]xtbml.RateEntryPayload)
+	for _, entry := range table.Rates {
+		line := noDuration
+		if entry.Duration != nil {
+			line = entry.Age
+		}
+		lines[line] = append(lines[line], entry)
+	}
+	order := make([]int, 0, len(lines))
+	for line := range lines {
+		order = append(order, line)
+	}
+	sort.Ints(order)
+	var findings []Finding
+	for _, key := range order {
+		line := lines[key]
+		sort.SliceStable(line, func(i, j int) bool {
+			a, b := keyOf(line[i]), keyOf(line[j])
+			if a.age != b.age {
+				return a.age < b.age
+			}
+			return a.duration < b.duration
+		})
+		first, last := -1, -1
+		for i, entry := range line {
+			if entry.Rate != nil {
+				if first < 0 {
+					first = i
+				}
+				last = i
+			}
+		}
+		var edge []xtbml.RateEntryPayload
+		flush := func() {
+			if len(edge) == 0 {
+				return
+			}
+			lo, hi := keyOf(edge[0]), keyOf(edge[len(edge)-1])
+			what, run := "age", [2]int{lo.age, hi.age}
+			if lo.duration != noDuration {
+				what, run = "duration", [2]int{lo.duration, hi.duration}
+			}
+			if run[0] != run[1] {
+				what += "s"
+			}
+			findings = append(findings, cellFinding(RuleMissingCell, SeverityInfo, index, lo,
+				"no rates for %s %s at the edge of the table", what, describeRun(run)))
+			edge = nil
+		}
+		for i, entry := range line {
+			if entry.Rate != nil {
+				flush()
+				continue
+			}
+			if first < 0 || i < first || i > last {
+				edge = append(edge, entry)
+				continue
+			}
+			findings = append(findings, cellFinding(RuleMissingCell, SeverityWarning, index, keyOf(entry), "cell has no rate"))
+		}
+		flush()
+	}
+	return findings
+}
+
+// runs splits sorted values into [first, last] runs separated by step.
+func runs(values []int, step int) [][2]int {
+	var out [][2]int
+	for _, v := range values {
+		if n := len(out); n > 0 && out[n-1][1]+step == v {
+			out[n-1][1] = v
+			continue
+		}
+		out = append(out, [2]int{v, v})
+	}
+	return out
+}
+
+func describeRun(run [2]int) string {
+	if run[0] == run[1] {
+		return strconv.Itoa(run[0])
+	}
+	return fmt.Sprintf("%d-%d", run[0], run[1])
+}
+
+func sortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		ai, aj := findings[i].Age, findings[j].Age
+		if ai == nil || aj == nil {
+			return ai == nil && aj != nil
+		}
+		if *ai != *aj {
+			return *ai < *aj
+		}
+		di, dj := findings[i].Duration, findings[j].Duration
+		if di == nil || dj == nil {
+			return di == nil && dj != nil
+		}
+		return *di < *dj
+	})
+}
+
+func checkAxisMetadata(_ *xtbml.ConvertedTable, index int, table xtbml.TablePayload) []Finding {
+	if len(table.Rates) == 0 {
+		return nil
+	}
+	var ages, durations []int
+	for _, entry := range table.Rates {
+		ages = append(ages, entry.Age)
+		if entry.Duration != nil {
+			durations = append(durations, *entry.Duration)
+		}
+	}
+
+	ageAxis, durAxis := axes(table)
+	var findings []Finding
+	warn := func(format string, args ...any) {
+		findings = append(findings, Finding{Rule: RuleAxisMetadata, Severity: SeverityWarning, Table: index, Message: fmt.Sprintf(format, args...)})
+	}
+	if ageAxis == nil {
+		warn("rates present but no axis is defined")
+		return findings
+	}
+	compareAxis(*ageAxis, ages, warn)
+	switch {
+	case len(durations) > 0 && durAxis == nil:
+		warn("rates have durations but only one axis is defined")
+	case durAxis != nil && len(durations) == 0:
+		warn("axis %s is defined but no rate has a duration", durAxis.name)
+	case durAxis != nil:
+		compareAxis(*durAxis, durations, warn)
+	}
+	return findings
+}
+
+// compareAxis checks the declared bounds and increment of axis against the
+// values actually used.
+func compareAxis(axis axisSpec, values []int, warn func(string, ...any)) {
+	distinct := sortedDistinct(values)
+	lo, hi := distinct[0], distinct[len(distinct)-1]
+
+	switch {
+	case !axis.minOK:
+		warn("axis %s minimum %q is not a whole number", axis.name, axis.rawMin)
+	case axis.min != lo:
+		warn("axis %s declares minimum %d but the data starts at %d", axis.name, axis.min, lo)
+	}
+	switch {
+	case !axis.maxOK:
+		warn("axis %s maximum %q is not a whole number", axis.name, axis.rawMax)
+	case axis.max != hi:
+		warn("axis %s declares maximum %d but the data ends at %d", axis.name, axis.max, hi)
+	}
+	if len(distinct) < 2 {
+		return
+	}
+	step := 0
+	for i := 1; i < len(distinct); i++ {
+		step = gcd(step, distinct[i]-distinct[i-1])
+	}
+	switch {
+	case !axis.incOK:
+		warn("axis %s increment %q is not a whole number", axis.name, axis.rawInc)
+	case axis.increment != step:
+		warn("axis %s declares increment %d but the data steps by %d", axis.name, axis.increment, step)
+	}
+}
+
+func sortedDistinct(values []int) []int {
+	seen := make(map[int]bool, len(values))
+	var out []int
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	sort.Ints(out)
+	return out
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+func checkMonotoneMortality(payload *xtbml.ConvertedTable, index int, table xtbml.TablePayload) []Finding {
+	if !isMortality(payload) || !ageIndexed(table) {
+		return nil
+	}
+	// Follow each duration column (or the single column) up the ages.
+	columns := make(map[int][]xtbml.RateEntryPayload)
+	for _, entry := range table.Rates {
+		key := keyOf(entry)
+		if entry.Rate != nil && key.age >= olderAge {
+			columns[key.duration] = append(columns[key.duration], entry)
+		}
+	}
+	var findings []Finding
+	for _, column := range columns {
+		sort.SliceStable(column, func(i, j int) bool { return column[i].Age < column[j].Age })
+		for i := 1; i < len(column); i++ {
+			prev, cur := column[i-1], column[i]
+			if cur.Age != prev.Age && *cur.Rate < *prev.Rate {
+				findings = append(findings, cellFinding(RuleMonotoneMortality, SeverityWarning, index, keyOf(cur),
+					"rate %s is below %s at age %d", formatRate(*cur.Rate), formatRate(*prev.Rate), prev.Age))
+			}
+		}
+	}
+	sortFindings(findings)
+	return findings
+}
+
+// ageIndexed reports whether the first axis of table is age; tables without
+// axis metadata are assumed to be.
+func ageIndexed(table xtbml.TablePayload) bool {
+	if table.Metadata == nil || len(table.Metadata.Axes) == 0 {
+		return true
+	}
+	axis := table.Metadata.Axes[0]
+	if strings.TrimSpace(axis.ScaleType.Code) == "3" {
+		return true
+	}
+	for _, s := range []string{axis.ScaleType.Label, axis.ID, axis.AxisName} {
+		if strings.Contains(strings.ToLower(s), "age") {
+			return true
+		}
+	}
+	return false
+}
+
+func checkDuplicateCells(_ *xtbml.ConvertedTable, index int, table xtbml.TablePayload) []Finding {
+	counts := make(map[cellKey]int, len(table.Rates))
+	var order []cellKey
+	for _, entry := range table.Rates {
+		key := keyOf(entry)
+		if counts[key] == 0 {
+			order = append(order, key)
+		}
+		counts[key]++
+	}
+	var findings []Finding
+	for _, key := range order {
+		if n := counts[key]; n > 1 {
+			findings = append(findings, cellFinding(RuleDuplicateCell, SeverityError, index, key, "cell appears %d times", n))
+		}
+	}
+	return findings
+}
+
+// checkUnknownCodes reports classified values whose code is missing from the
+// xtbml code-list registry. Classification values are reported with table 0.
+func checkUnknownCodes(payload *xtbml.ConvertedTable, index int, _ xtbml.TablePayload) []Finding {
+	prefix := fmt.Sprintf("tables[%d].", index)
+	var findings []Finding
+	for _, u := range xtbml.UnknownCodes(payload) {
+		if !strings.HasPrefix(u.Path, prefix) && (index != 0 || strings.HasPrefix(u.Path, "tables[")) {
+			continue
+		}
+		findings = append(findings, Finding{
+			Rule:     RuleUnknownCode,
+			Severity: SeverityWarning,
+			Table:    index,
+			Message:  fmt.Sprintf("%s code %q (%q) is not a registered %s code", u.Path, u.Code, u.Label, u.Vocabulary),
+		})
+	}
+	return findings
+}
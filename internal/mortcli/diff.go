@@ -0,0 +1,160 @@
+package mortcli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"mort/internal/tablearchive"
+	"mort/internal/tablediff"
+	"mort/internal/tuiapp"
+	"mort/internal/xtbml"
+	"mort/tui"
+)
+
+// diffOutput is the JSON form of a comparison.
+type diffOutput struct {
+	A       string `json:"a"`
+	B       string `json:"b"`
+	Equal   bool   `json:"equal"`
+	Verdict string `json:"verdict"`
+	*tablediff.Result
+}
+
+// runTUI runs a Bubble Tea model; tests replace it.
+var runTUI = func(model tea.Model) error {
+	_, err := tea.NewProgram(model, tea.WithAltScreen()).Run()
+	return err
+}
+
+func runDiff(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("mort diff", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: mort diff [flags] <a> <b>")
+		fmt.Fprintln(stderr, "\nEach side is a JSON or XML file, a table in -json-dir (identifier, table identity")
+		fmt.Fprintln(stderr, "or file name), or an archived revision written as t1234@<revision>, where the")
+		fmt.Fprintln(stderr, "revision is a number, a sha256 prefix or latest. Exits 0 when the tables match,")
+		fmt.Fprintln(stderr, "1 when they differ and 2 on errors.")
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+
+	jsonDir := fs.String("json-dir", defaultJSONDir(), "directory containing converted JSON tables")
+	archiveDir := fs.String("archive-dir", defaultArchiveDir(), "revision archive written by changelogsync")
+	format := fs.String("format", "text", "output format: text, json or tui")
+	tolerance := fs.Float64("tolerance", 0, "treat rates differing by at most this much as equal")
+	maxCells := fs.Int("max-cells", 50, "changed cells listed per rate table in text output (0 for all)")
+
+	refs, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(refs) != 2 {
+		fs.Usage()
+		return 2
+	}
+	if *format != "text" && *format != "json" && *format != "tui" {
+		fmt.Fprintf(stderr, "unknown diff format %q (want text, json or tui)\n", *format)
+		return 2
+	}
+
+	archive := tablearchive.Open(*archiveDir)
+	a, labelA, err := loadDiffSide(*jsonDir, archive, refs[0])
+	if err != nil {
+		fmt.Fprintf(stderr, "diff failed: %v\n", err)
+		return 2
+	}
+	b, labelB, err := loadDiffSide(*jsonDir, archive, refs[1])
+	if err != nil {
+		fmt.Fprintf(stderr, "diff failed: %v\n", err)
+		return 2
+	}
+	result := tablediff.Compare(a, b, tablediff.Options{Tolerance: *tolerance})
+
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		out := diffOutput{A: labelA, B: labelB, Equal: result.Equal(), Verdict: result.Verdict(), Result: result}
+		if err := enc.Encode(out); err != nil {
+			fmt.Fprintf(stderr, "diff failed: %v\n", err)
+			return 2
+		}
+	case "tui":
+		if err := runTUI(tui.NewDiffModel(a, b, labelA, labelB, result)); err != nil {
+			fmt.Fprintf(stderr, "tui error: %v\n", err)
+			return 2
+		}
+	default:
+		opts := tablediff.TextOptions{A: labelA, B: labelB, MaxCells: *maxCells}
+		if err := tablediff.WriteText(stdout, result, opts); err != nil {
+			fmt.Fprintf(stderr, "diff failed: %v\n", err)
+			return 2
+		}
+	}
+	if result.Equal() {
+		return 0
+	}
+	return 1
+}
+
+// loadDiffSide loads one side of a comparison and returns it with a label
+// describing where it came from.
+func loadDiffSide(jsonDir string, archive *tablearchive.Archive, ref string) (*xtbml.ConvertedTable, string, error) {
+	if table, revision, ok := strings.Cut(ref, "@"); ok {
+		if _, err := os.Stat(ref); err != nil {
+			return loadArchivedSide(archive, table, revision)
+		}
+	}
+	path := ref
+	if info, err := os.Stat(ref); err != nil || info.IsDir() {
+		resolved, err := tuiapp.ResolveTablePath(jsonDir, ref)
+		if err != nil {
+			return nil, "", err
+		}
+		path = resolved
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	var payload *xtbml.ConvertedTable
+	if strings.EqualFold(filepath.Ext(path), ".xml") {
+		payload, err = xtbml.ParseTable(raw)
+	} else {
+		payload, err = xtbml.DecodeTable(raw)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", path, err)
+	}
+	return payload, path, nil
+}
+
+func loadArchivedSide(archive *tablearchive.Archive, table, revision string) (*xtbml.ConvertedTable, string, error) {
+	tableID, err := parseTableID(table)
+	if err != nil {
+		return nil, "", err
+	}
+	rev, err := archive.Lookup(tableID, revision)
+	if err != nil {
+		return nil, "", err
+	}
+	body, err := archive.Read(rev)
+	if err != nil {
+		return nil, "", err
+	}
+	payload, err := xtbml.ParseTable(body)
+	if err != nil {
+		return nil, "", fmt.Errorf("t%d revision %d: %w", tableID, rev.Number, err)
+	}
+	label := fmt.Sprintf("t%d revision %d (%s, %s)", tableID, rev.Number, rev.Hash[:12], rev.Effective().Format(time.DateOnly))
+	return payload, label, nil
+}
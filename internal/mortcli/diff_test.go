@@ -0,0 +1,98 @@
+package mortcli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"mort/internal/tablearchive"
+)
+
+func TestRunDiffFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeLintTable(t, dir, "before", 0.01, 0.02)
+	writeLintTable(t, dir, "after", 0.01, 0.025)
+
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"diff", "-json-dir", dir, "before", "before"}, &stdout, &stderr)
+	if code != 0 || !strings.Contains(stdout.String(), "No differences.") {
+		t.Fatalf("diff of identical tables: exit %d, stdout:\n%s\nstderr: %s", code, stdout.String(), stderr.String())
+	}
+
+	stdout.Reset()
+	code = Run([]string{"diff", "-json-dir", dir, "-format", "json", "before", filepath.Join(dir, "after.json")}, &stdout, &stderr)
+	if code != 1 {
+		t.Fatalf("diff exit code = %d, want 1; stderr = %s", code, stderr.String())
+	}
+	var out struct {
+		Equal  bool `json:"equal"`
+		Fields []struct {
+			Path string `json:"path"`
+		} `json:"fields"`
+		Cells []struct {
+			Kind string   `json:"kind"`
+			Age  int      `json:"age"`
+			Abs  *float64 `json:"abs"`
+		} `json:"cells"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		t.Fatalf("decode json output: %v\n%s", err, stdout.String())
+	}
+	if out.Equal || len(out.Cells) != 1 || out.Cells[0].Age != 61 || out.Cells[0].Abs == nil {
+		t.Fatalf("json output = %+v", out)
+	}
+	if len(out.Fields) != 2 {
+		t.Fatalf("fields = %+v, want identifier and table name", out.Fields)
+	}
+
+	if code := Run([]string{"diff", "-json-dir", dir, "before", "missing"}, &stdout, &stderr); code != 2 {
+		t.Fatalf("diff with unknown table exit code = %d, want 2", code)
+	}
+}
+
+func TestRunDiffArchivedRevisions(t *testing.T) {
+	archiveDir := t.TempDir()
+	archive := tablearchive.Open(archiveDir)
+	v1, err := os.ReadFile(filepath.Join("..", "xtbml", "testdata", "table_small.xml"))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	v2 := bytes.Replace(v1, []byte("Illustrative only."), []byte("Comments revised."), 1)
+	logDate := time.Date(2024, 1, 10, 9, 0, 0, 0, time.UTC)
+	for _, body := range [][]byte{v1, v2} {
+		if _, err := archive.Store(12, body, tablearchive.Revision{Action: "Update", LogDate: logDate}); err != nil {
+			t.Fatalf("Store() error = %v", err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"diff", "-archive-dir", archiveDir, "t12@1", "t12@latest"}, &stdout, &stderr)
+	if code != 1 {
+		t.Fatalf("diff exit code = %d, stderr = %s", code, stderr.String())
+	}
+	for _, want := range []string{"--- t12 revision 1", "+++ t12 revision 2", "classification.comments", "Rates are identical; 1 field changed."} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Fatalf("diff output missing %q:\n%s", want, stdout.String())
+		}
+	}
+
+	// The TUI receives the same comparison.
+	var ran tea.Model
+	defer func(orig func(tea.Model) error) { runTUI = orig }(runTUI)
+	runTUI = func(model tea.Model) error {
+		ran = model
+		return nil
+	}
+	if code := Run([]string{"diff", "-archive-dir", archiveDir, "-format", "tui", "t12@1", "t12@2"}, &stdout, &stderr); code != 1 {
+		t.Fatalf("diff -format tui exit code = %d, stderr = %s", code, stderr.String())
+	}
+	if ran == nil || !strings.Contains(ran.View(), "Comments revised.") {
+		t.Fatalf("tui view missing the changed comment")
+	}
+}
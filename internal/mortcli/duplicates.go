@@ -0,0 +1,111 @@
+package mortcli
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"mort/internal/duplicates"
+	"mort/internal/tablediff"
+	"mort/internal/xtbml"
+)
+
+func runDuplicates(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("mort duplicates", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: mort duplicates [flags]")
+		fmt.Fprintln(stderr, "\nClusters rate tables in -json-dir whose rates are identical, or within -tolerance.")
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+
+	jsonDir := fs.String("json-dir", defaultJSONDir(), "directory containing converted JSON tables")
+	tolerance := fs.Float64("tolerance", 0, "largest absolute rate difference still counted as a match")
+	minCells := fs.Int("min-cells", 5, "ignore rate tables with fewer populated cells")
+	sameFile := fs.Bool("same-file", false, "also report rate tables repeated only within one file")
+	format := fs.String("format", "text", "output format: text or json")
+
+	refs, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(refs) != 0 {
+		fs.Usage()
+		return 2
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(stderr, "unknown duplicates format %q (want text or json)\n", *format)
+		return 2
+	}
+	if *tolerance < 0 {
+		fmt.Fprintln(stderr, "-tolerance must not be negative")
+		return 2
+	}
+
+	paths, err := lintPaths(*jsonDir, nil)
+	if err != nil {
+		fmt.Fprintf(stderr, "duplicates failed: %v\n", err)
+		return 1
+	}
+	var grids []duplicates.Grid
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "duplicates failed: %v\n", err)
+			return 1
+		}
+		table, err := xtbml.DecodeTable(raw)
+		if errors.Is(err, xtbml.ErrNotTable) {
+			continue
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "duplicates failed: %s: %v\n", path, err)
+			return 1
+		}
+		grids = append(grids, duplicates.Grids(filepath.Base(path), table)...)
+	}
+
+	clusters := duplicates.Find(grids, duplicates.Options{Tolerance: *tolerance, MinCells: *minCells, SameFile: *sameFile})
+	if *format == "json" {
+		if clusters == nil {
+			clusters = []duplicates.Cluster{}
+		}
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(clusters); err != nil {
+			fmt.Fprintf(stderr, "duplicates failed: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	if err := writeDuplicatesText(stdout, clusters, len(grids)); err != nil {
+		fmt.Fprintf(stderr, "duplicates failed: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func writeDuplicatesText(w io.Writer, clusters []duplicates.Cluster, grids int) error {
+	bw := bufio.NewWriter(w)
+	members := 0
+	for i, c := range clusters {
+		members += len(c.Members)
+		match := "identical rates"
+		if !c.Identical() {
+			match = "max |Δ| " + tablediff.FormatRate(c.MaxDelta)
+		}
+		fmt.Fprintf(bw, "Cluster %d: %d rate tables, %d cells, %s\n", i+1, len(c.Members), c.Cells, match)
+		for _, m := range c.Members {
+			fmt.Fprintf(bw, "  %-12s table %d  %-8s %s\n", m.File, m.Table, m.TableIdentity, m.Name)
+		}
+		fmt.Fprintln(bw)
+	}
+	fmt.Fprintf(bw, "%d clusters covering %d of %d rate tables\n", len(clusters), members, grids)
+	return bw.Flush()
+}
@@ -0,0 +1,42 @@
+package mortcli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"mort/internal/duplicates"
+)
+
+func TestRunDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	writeLintTable(t, dir, "first", 0.01, 0.02)
+	writeLintTable(t, dir, "second", 0.01, 0.02)
+	writeLintTable(t, dir, "third", 0.01, 0.021)
+
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"duplicates", "-json-dir", dir, "-min-cells", "2"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("duplicates exit code = %d, stderr = %s", code, stderr.String())
+	}
+	out := stdout.String()
+	for _, want := range []string{"Cluster 1: 2 rate tables, 2 cells, identical rates", "first.json", "second.json", "1 clusters covering 2 of 3 rate tables"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	stdout.Reset()
+	code = Run([]string{"duplicates", "-json-dir", dir, "-min-cells", "2", "-tolerance", "0.002", "-format", "json"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("duplicates exit code = %d, stderr = %s", code, stderr.String())
+	}
+	var clusters []duplicates.Cluster
+	if err := json.Unmarshal(stdout.Bytes(), &clusters); err != nil {
+		t.Fatalf("decode json: %v\n%s", err, stdout.String())
+	}
+	if len(clusters) != 1 || len(clusters[0].Members) != 3 || clusters[0].MaxDelta == 0 {
+		t.Fatalf("clusters = %+v", clusters)
+	}
+}
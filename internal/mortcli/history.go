@@ -0,0 +1,185 @@
+package mortcli
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"mort/internal/tablearchive"
+	"mort/internal/xtbml"
+)
+
+func runHistory(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("mort history", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: mort history [flags] <table id, e.g. t1234>")
+		fs.PrintDefaults()
+	}
+	archiveDir := fs.String("archive-dir", defaultArchiveDir(), "revision archive written by changelogsync")
+	format := fs.String("format", "text", "output format: text or json")
+
+	refs, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(refs) != 1 {
+		fs.Usage()
+		return 2
+	}
+	tableID, err := parseTableID(refs[0])
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	history, err := tablearchive.Open(*archiveDir).History(tableID)
+	if err != nil {
+		fmt.Fprintf(stderr, "history failed: %v\n", err)
+		return 1
+	}
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if history == nil {
+			history = []tablearchive.Revision{}
+		}
+		if err := enc.Encode(history); err != nil {
+			fmt.Fprintf(stderr, "history failed: %v\n", err)
+			return 1
+		}
+	case "text":
+		if len(history) == 0 {
+			fmt.Fprintf(stdout, "no archived revisions of t%d\n", tableID)
+			return 0
+		}
+		tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "REV\tEFFECTIVE\tSHA256\tLOG\tACTION\tUSER\tCOMMENT")
+		for _, rev := range history {
+			logID := ""
+			if rev.LogID != 0 {
+				logID = "#" + strconv.Itoa(rev.LogID)
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", rev.Number, rev.Effective().Format(time.DateOnly),
+				rev.Hash[:12], logID, rev.Action, rev.UserID, rev.Comment)
+		}
+		if err := tw.Flush(); err != nil {
+			fmt.Fprintf(stderr, "history failed: %v\n", err)
+			return 1
+		}
+	default:
+		fmt.Fprintf(stderr, "unknown format %q (want text or json)\n", *format)
+		return 2
+	}
+	return 0
+}
+
+func runMaterialize(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("mort materialize", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: mort materialize [flags] <table id, e.g. t1234>")
+		fs.PrintDefaults()
+	}
+	archiveDir := fs.String("archive-dir", defaultArchiveDir(), "revision archive written by changelogsync")
+	revision := fs.String("revision", "latest", "revision number, sha256 prefix (4+ characters) or latest")
+	asOf := fs.String("as-of", "", "pick the revision current on this date (YYYY-MM-DD) instead of -revision")
+	out := fs.String("out", "", "write the XML here instead of stdout")
+	jsonOut := fs.String("json", "", "also convert the revision to JSON at this path")
+
+	refs, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(refs) != 1 {
+		fs.Usage()
+		return 2
+	}
+	tableID, err := parseTableID(refs[0])
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	archive := tablearchive.Open(*archiveDir)
+	var rev tablearchive.Revision
+	if *asOf != "" {
+		date, parseErr := time.Parse(time.DateOnly, *asOf)
+		if parseErr != nil {
+			fmt.Fprintf(stderr, "invalid -as-of date %q (want YYYY-MM-DD)\n", *asOf)
+			return 2
+		}
+		// A valuation date covers the whole day.
+		rev, err = archive.AsOf(tableID, date.Add(24*time.Hour-time.Nanosecond))
+	} else {
+		rev, err = archive.Lookup(tableID, *revision)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "materialize failed: %v\n", err)
+		return 1
+	}
+	body, err := archive.Read(rev)
+	if err != nil {
+		fmt.Fprintf(stderr, "materialize failed: %v\n", err)
+		return 1
+	}
+
+	if *jsonOut != "" {
+		converted, err := xtbml.ConvertXTbml(bytes.NewReader(body))
+		if err == nil {
+			err = xtbml.ValidateJSON(converted)
+		}
+		if err == nil {
+			err = os.MkdirAll(filepath.Dir(*jsonOut), 0o755)
+		}
+		if err == nil {
+			err = os.WriteFile(*jsonOut, converted, 0o644)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "materialize failed: convert t%d revision %d: %v\n", tableID, rev.Number, err)
+			return 1
+		}
+	}
+	switch {
+	case *out != "":
+		if err := archive.Materialize(rev, *out); err != nil {
+			fmt.Fprintf(stderr, "materialize failed: %v\n", err)
+			return 1
+		}
+	case *jsonOut == "":
+		if _, err := stdout.Write(body); err != nil {
+			fmt.Fprintf(stderr, "materialize failed: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	fmt.Fprintf(stderr, "materialized t%d revision %d (%s, %s)\n", tableID, rev.Number, rev.Hash[:12], rev.Effective().Format(time.DateOnly))
+	return 0
+}
+
+// parseTableID accepts "t1234", "1234" or "t1234.xml".
+func parseTableID(ref string) (int, error) {
+	s := strings.TrimSuffix(strings.ToLower(filepath.Base(ref)), ".xml")
+	s = strings.TrimPrefix(s, "t")
+	id, err := strconv.Atoi(s)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid table id %q (want t1234 or 1234)", ref)
+	}
+	return id, nil
+}
+
+func defaultArchiveDir() string {
+	if dir := os.Getenv("MORT_ARCHIVE_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(".", "archive")
+}
@@ -0,0 +1,69 @@
+package mortcli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"mort/internal/tablearchive"
+)
+
+func TestRunHistoryAndMaterialize(t *testing.T) {
+	archiveDir := t.TempDir()
+	archive := tablearchive.Open(archiveDir)
+	v1, err := os.ReadFile(filepath.Join("..", "xtbml", "testdata", "table_small.xml"))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	v2 := bytes.Replace(v1, []byte("0.011"), []byte("0.012"), 1)
+	jan := time.Date(2024, 1, 10, 9, 0, 0, 0, time.UTC)
+	jun := time.Date(2024, 6, 30, 9, 0, 0, 0, time.UTC)
+	if _, err := archive.Store(12, v1, tablearchive.Revision{LogID: 1, Action: "Add", UserID: "jdoe", LogDate: jan}); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	if _, err := archive.Store(12, v2, tablearchive.Revision{LogID: 2, Action: "Update", Comment: "Age 41 corrected", LogDate: jun}); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"history", "-archive-dir", archiveDir, "t12"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("history exit code = %d, stderr = %s", code, stderr.String())
+	}
+	for _, want := range []string{"2024-01-10", "Age 41 corrected", "#2"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Fatalf("history missing %q:\n%s", want, stdout.String())
+		}
+	}
+
+	// The June update lands on the valuation date itself.
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "t12.json")
+	stdout.Reset()
+	stderr.Reset()
+	code := Run([]string{"materialize", "-archive-dir", archiveDir, "-as-of", "2024-06-30", "-json", jsonPath, "12"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("materialize exit code = %d, stderr = %s", code, stderr.String())
+	}
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatalf("read json: %v", err)
+	}
+	if !strings.Contains(string(data), "0.012") {
+		t.Fatalf("as-of 2024-06-30 should pick revision 2:\n%s", data)
+	}
+
+	stdout.Reset()
+	if code := Run([]string{"materialize", "-archive-dir", archiveDir, "-as-of", "2024-03-31", "t12"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("materialize exit code = %d, stderr = %s", code, stderr.String())
+	}
+	if !bytes.Equal(stdout.Bytes(), v1) {
+		t.Fatal("as-of 2024-03-31 should write revision 1 to stdout")
+	}
+
+	if code := Run([]string{"materialize", "-archive-dir", archiveDir, "-as-of", "2023-12-31", "t12"}, &stdout, &stderr); code != 1 {
+		t.Fatalf("materialize before history exit code = %d, want 1", code)
+	}
+}
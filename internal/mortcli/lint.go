@@ -0,0 +1,147 @@
+package mortcli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"mort/internal/lint"
+	"mort/internal/tuiapp"
+	"mort/internal/xtbml"
+)
+
+// lintResult is the JSON form of the findings for one file.
+type lintResult struct {
+	File     string         `json:"file"`
+	Findings []lint.Finding `json:"findings"`
+}
+
+func runLint(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("mort lint", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: mort lint [flags] [identifier|table identity|path ...]")
+		fmt.Fprintln(stderr, "\nWith no tables, every table in -json-dir is linted. Rules:")
+		for _, rule := range lint.Rules() {
+			fmt.Fprintf(stderr, "  %-20s %s\n", rule.Name, rule.Description)
+		}
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+
+	jsonDir := fs.String("json-dir", defaultJSONDir(), "directory containing converted JSON tables")
+	severity := fs.String("severity", "info", "report findings at or above this severity: info, warning or error")
+	rules := fs.String("rules", "", "comma-separated rules to run (default all)")
+	format := fs.String("format", "text", "output format: text or json")
+
+	refs, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	minSeverity, err := lint.ParseSeverity(*severity)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(stderr, "unknown lint format %q (want text or json)\n", *format)
+		return 2
+	}
+	opts := lint.Options{MinSeverity: minSeverity}
+	if *rules != "" {
+		opts.Rules = strings.Split(*rules, ",")
+	}
+
+	paths, err := lintPaths(*jsonDir, refs)
+	if err != nil {
+		fmt.Fprintf(stderr, "lint failed: %v\n", err)
+		return 1
+	}
+
+	results := []lintResult{}
+	errorsFound := false
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "lint failed: %v\n", err)
+			return 1
+		}
+		table, err := xtbml.DecodeTable(raw)
+		if errors.Is(err, xtbml.ErrNotTable) && len(refs) == 0 {
+			continue
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "lint failed: %s: %v\n", path, err)
+			return 1
+		}
+		findings, err := lint.Lint(table, opts)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		for _, f := range findings {
+			errorsFound = errorsFound || f.Severity == lint.SeverityError
+		}
+		if len(findings) > 0 {
+			results = append(results, lintResult{File: filepath.Base(path), Findings: findings})
+		}
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			fmt.Fprintf(stderr, "lint failed: %v\n", err)
+			return 1
+		}
+	} else {
+		writeLintText(stdout, results, len(paths))
+	}
+	if errorsFound {
+		return 1
+	}
+	return 0
+}
+
+// lintPaths resolves refs to payload paths, or lists every JSON file in dir
+// when refs is empty.
+func lintPaths(dir string, refs []string) ([]string, error) {
+	if len(refs) > 0 {
+		paths := make([]string, 0, len(refs))
+		for _, ref := range refs {
+			path, err := tuiapp.ResolveTablePath(dir, ref)
+			if err != nil {
+				return nil, err
+			}
+			paths = append(paths, path)
+		}
+		return paths, nil
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no JSON tables in %s", dir)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func writeLintText(w io.Writer, results []lintResult, files int) {
+	counts := map[lint.Severity]int{}
+	for _, res := range results {
+		for _, f := range res.Findings {
+			counts[f.Severity]++
+			fmt.Fprintf(w, "%s: %s: %s: %s: %s\n", res.File, f.Severity, f.Rule, f.Location(), f.Message)
+		}
+	}
+	fmt.Fprintf(w, "%d files checked: %d errors, %d warnings, %d info\n",
+		files, counts[lint.SeverityError], counts[lint.SeverityWarning], counts[lint.SeverityInfo])
+}
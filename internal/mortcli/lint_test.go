@@ -0,0 +1,88 @@
+package mortcli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mort/internal/xtbml"
+)
+
+func writeLintTable(t *testing.T, dir, name string, rates ...float64) {
+	t.Helper()
+	table := &xtbml.ConvertedTable{
+		SchemaVersion: xtbml.CurrentSchemaVersion,
+		Identifier:    name,
+		Classification: &xtbml.ClassificationPayload{
+			TableName:   name,
+			ContentType: xtbml.ClassifiedValuePayload{Code: "4", Label: "Insured Lives Mortality"},
+		},
+	}
+	meta := &xtbml.TableMetaPayload{Axes: []xtbml.AxisDefinitionPayload{{ID: "Age", AxisName: "Age", MinValue: "60", MaxValue: "61", Increment: "1"}}}
+	tp := xtbml.TablePayload{Metadata: meta}
+	for i, r := range rates {
+		tp.Rates = append(tp.Rates, xtbml.RateEntryPayload{Age: 60 + i, Rate: &r})
+	}
+	table.Tables = []xtbml.TablePayload{tp}
+	data, err := xtbml.EncodeTable(table)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".json"), data, 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+func TestRunLint(t *testing.T) {
+	dir := t.TempDir()
+	writeLintTable(t, dir, "good", 0.1, 0.2)
+	writeLintTable(t, dir, "bad", 0.3, 1.2)
+	if err := os.WriteFile(filepath.Join(dir, "changelog_state.json"), []byte(`{"last_log_ms": 1}`), 0o644); err != nil {
+		t.Fatalf("write state: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"lint", "-json-dir", dir}, &stdout, &stderr)
+	if code != 1 {
+		t.Fatalf("Run() exit code = %d, want 1; stderr = %s", code, stderr.String())
+	}
+	want := "bad.json: error: rate-range: table 0 age 61: rate 1.2 is outside [0,1] for content type \"Insured Lives Mortality\" (4)\n" +
+		"3 files checked: 1 errors, 0 warnings, 0 info\n"
+	if stdout.String() != want {
+		t.Fatalf("stdout =\n%s\nwant\n%s", stdout.String(), want)
+	}
+}
+
+func TestRunLintFilters(t *testing.T) {
+	dir := t.TempDir()
+	writeLintTable(t, dir, "bad", 0.3, 0.2)
+
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"lint", "-json-dir", dir, "-severity", "error", "bad"}, &stdout, &stderr)
+	if code != 0 || !strings.HasPrefix(stdout.String(), "1 files checked: 0 errors, 0 warnings") {
+		t.Fatalf("Run() = %d, stdout = %s, stderr = %s", code, stdout.String(), stderr.String())
+	}
+
+	stdout.Reset()
+	code = Run([]string{"lint", "-json-dir", dir, "-rules", "monotone-mortality", "-format", "json", "bad"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("Run() json exit code = %d, stderr = %s", code, stderr.String())
+	}
+	var results []lintResult
+	if err := json.Unmarshal(stdout.Bytes(), &results); err != nil {
+		t.Fatalf("decode: %v\n%s", err, stdout.String())
+	}
+	if len(results) != 1 || len(results[0].Findings) != 1 || results[0].Findings[0].Rule != "monotone-mortality" {
+		t.Fatalf("results = %+v", results)
+	}
+	if !strings.Contains(stdout.String(), `"severity": "warning"`) {
+		t.Fatalf("severity should be rendered by name: %s", stdout.String())
+	}
+
+	if code := Run([]string{"lint", "-json-dir", dir, "-rules", "bogus"}, &stdout, &stderr); code != 2 {
+		t.Fatalf("unknown rule exit code = %d, want 2", code)
+	}
+}
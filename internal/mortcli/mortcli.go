@@ -0,0 +1,83 @@
+package mortcli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+type command struct {
+	summary string
+	run     func(args []string, stdout, stderr io.Writer) int
+}
+
+var commands = map[string]command{
+	"report":      {summary: "render a Markdown or HTML report for a table", run: runReport},
+	"history":     {summary: "list archived revisions of a table", run: runHistory},
+	"materialize": {summary: "extract an archived table revision for conversion", run: runMaterialize},
+	"lint":        {summary: "check tables for data-quality problems", run: runLint},
+	"diff":        {summary: "compare two versions of a table", run: runDiff},
+	"duplicates":  {summary: "cluster tables with identical or nearly identical rates", run: runDuplicates},
+	"stats":       {summary: "summarise the table library by content type, nation and shape", run: runStats},
+}
+
+// IsCommand reports whether name is a known mort subcommand.
+func IsCommand(name string) bool {
+	_, ok := commands[name]
+	return ok
+}
+
+// Run executes the mort subcommand named by args[0].
+func Run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		printUsage(stderr)
+		return 2
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n", args[0])
+		printUsage(stderr)
+		return 2
+	}
+	return cmd.run(args[1:], stdout, stderr)
+}
+
+func printUsage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(w, "usage: mort <command> [flags] [args]")
+	fmt.Fprintln(w, "\ncommands:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-12s %s\n", name, commands[name].summary)
+	}
+}
+
+// parseInterspersed parses flags that may appear before or after positional
+// arguments and returns the positional arguments.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func defaultJSONDir() string {
+	if dir := os.Getenv("MORT_JSON_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(".", "json")
+}
@@ -0,0 +1,82 @@
+package mortcli
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"mort/internal/report"
+	"mort/internal/tuiapp"
+)
+
+func runReport(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("mort report", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: mort report [flags] <identifier|table identity|path>")
+		fs.PrintDefaults()
+	}
+
+	jsonDir := fs.String("json-dir", defaultJSONDir(), "directory containing converted JSON tables")
+	format := fs.String("format", "markdown", "output format: markdown or html")
+	out := fs.String("out", "", "write the report to this file instead of stdout")
+	pageSize := fs.Int("page-size", 0, "ages per rate page (default 50)")
+	maxColumns := fs.Int("max-columns", 0, "duration columns per rate page (default 12)")
+	validate := fs.Bool("validate", false, "validate the table against the JSON schema before rendering")
+
+	refs, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(refs) != 1 {
+		fs.Usage()
+		return 2
+	}
+	reportFormat, err := report.ParseFormat(*format)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	path, err := tuiapp.ResolveTablePath(*jsonDir, refs[0])
+	if err != nil {
+		fmt.Fprintf(stderr, "report failed: %v\n", err)
+		return 1
+	}
+	detail, err := tuiapp.LoadTableDetailWithOptions(path, tuiapp.LoadOptions{Validate: *validate})
+	if err != nil {
+		fmt.Fprintf(stderr, "report failed: %v\n", err)
+		return 1
+	}
+
+	w := stdout
+	var file *os.File
+	if *out != "" {
+		file, err = os.Create(*out)
+		if err != nil {
+			fmt.Fprintf(stderr, "report failed: %v\n", err)
+			return 1
+		}
+		defer file.Close()
+		w = file
+	}
+	buf := bufio.NewWriter(w)
+	opts := report.Options{Format: reportFormat, PageSize: *pageSize, MaxColumns: *maxColumns}
+	if err := report.Render(buf, detail, opts); err != nil {
+		fmt.Fprintf(stderr, "report failed: %v\n", err)
+		return 1
+	}
+	if err := buf.Flush(); err != nil {
+		fmt.Fprintf(stderr, "report failed: %v\n", err)
+		return 1
+	}
+	if file != nil {
+		if err := file.Close(); err != nil {
+			fmt.Fprintf(stderr, "report failed: %v\n", err)
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,46 @@
+package mortcli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunReport(t *testing.T) {
+	dir := filepath.Join("..", "tuiapp", "testdata", "json")
+
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"report", "table_alpha", "-json-dir", dir}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("Run() exit code = %d, stderr = %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "# Alpha Table") {
+		t.Fatalf("stdout missing title: %s", stdout.String())
+	}
+
+	out := filepath.Join(t.TempDir(), "alpha.html")
+	stdout.Reset()
+	code = Run([]string{"report", "-json-dir", dir, "-format", "html", "-out", out, "alpha"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("Run() html exit code = %d, stderr = %s", code, stderr.String())
+	}
+	html, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	if !strings.HasPrefix(string(html), "<!DOCTYPE html>") {
+		t.Fatalf("unexpected html output: %s", html)
+	}
+}
+
+func TestRunUnknownCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"bogus"}, &stdout, &stderr); code != 2 {
+		t.Fatalf("Run() exit code = %d, want 2", code)
+	}
+	if !strings.Contains(stderr.String(), "report") {
+		t.Fatalf("usage should list commands: %s", stderr.String())
+	}
+}
@@ -0,0 +1,92 @@
+package mortcli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"mort/internal/catalog"
+	"mort/internal/xtbml"
+)
+
+func runStats(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("mort stats", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: mort stats [flags]")
+		fmt.Fprintln(stderr, "\nSummarises the tables in -json-dir by content type, nation, provider and shape.")
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+
+	jsonDir := fs.String("json-dir", defaultJSONDir(), "directory containing converted JSON tables")
+	format := fs.String("format", "text", "output format: text or json")
+	top := fs.Int("top", 10, "number of largest tables and tables with missing data to list")
+	var filter catalog.Filter
+	fs.StringVar(&filter.ContentType, "content-type", "", "only count tables whose content type contains this text")
+	fs.StringVar(&filter.Nation, "nation", "", "only count tables whose nation contains this text")
+	fs.StringVar(&filter.Provider, "provider", "", "only count tables whose provider contains this text")
+	fs.IntVar(&filter.Axes, "axes", 0, "only count documents with a rate table of this many axes (2 for select tables)")
+
+	refs, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(refs) != 0 {
+		fs.Usage()
+		return 2
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(stderr, "unknown stats format %q (want text or json)\n", *format)
+		return 2
+	}
+	if *top <= 0 {
+		fmt.Fprintln(stderr, "-top must be positive")
+		return 2
+	}
+
+	paths, err := lintPaths(*jsonDir, nil)
+	if err != nil {
+		fmt.Fprintf(stderr, "stats failed: %v\n", err)
+		return 1
+	}
+	builder := catalog.Builder{Top: *top}
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "stats failed: %v\n", err)
+			return 1
+		}
+		table, err := xtbml.DecodeTable(raw)
+		if errors.Is(err, xtbml.ErrNotTable) {
+			continue
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "stats failed: %s: %v\n", path, err)
+			return 1
+		}
+		if filter.Match(table) {
+			builder.Add(filepath.Base(path), table)
+		}
+	}
+
+	summary := builder.Catalog()
+	if *format == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(summary); err != nil {
+			fmt.Fprintf(stderr, "stats failed: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	if err := catalog.WriteText(stdout, summary); err != nil {
+		fmt.Fprintf(stderr, "stats failed: %v\n", err)
+		return 1
+	}
+	return 0
+}
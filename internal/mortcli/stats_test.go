@@ -0,0 +1,47 @@
+package mortcli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"mort/internal/catalog"
+)
+
+func TestRunStats(t *testing.T) {
+	dir := t.TempDir()
+	writeLintTable(t, dir, "small", 0.01)
+	writeLintTable(t, dir, "large", 0.01, 0.02, 0.03)
+
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"stats", "-json-dir", dir}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("stats exit code = %d, stderr = %s", code, stderr.String())
+	}
+	out := stdout.String()
+	for _, want := range []string{"Documents:     2", "Rate tables:   2", "2  Insured Lives Mortality", "3 cells  large.json"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	stdout.Reset()
+	code = Run([]string{"stats", "-json-dir", dir, "-format", "json", "-top", "1", "-axes", "1"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("stats exit code = %d, stderr = %s", code, stderr.String())
+	}
+	var summary catalog.Catalog
+	if err := json.Unmarshal(stdout.Bytes(), &summary); err != nil {
+		t.Fatalf("decode json: %v\n%s", err, stdout.String())
+	}
+	if summary.Documents != 2 || summary.Cells != 4 || len(summary.Largest) != 1 || summary.Largest[0].File != "large.json" {
+		t.Fatalf("summary = %+v", summary)
+	}
+
+	stdout.Reset()
+	code = Run([]string{"stats", "-json-dir", dir, "-nation", "canada"}, &stdout, &stderr)
+	if code != 0 || !strings.Contains(stdout.String(), "Documents:     0") {
+		t.Fatalf("filtered stats exit code = %d, output:\n%s", code, stdout.String())
+	}
+}
@@ -0,0 +1,75 @@
+package report
+
+import (
+	"html/template"
+	"io"
+)
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"classified": classified,
+	"orDash": func(s string) string {
+		if s == "" {
+			return "—"
+		}
+		return s
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem auto; max-width: 64rem; color: #1f2328; line-height: 1.45; }
+h1 { margin-bottom: 0.25rem; }
+h2 { border-bottom: 1px solid #d0d7de; padding-bottom: 0.25rem; margin-top: 2rem; }
+table { border-collapse: collapse; margin: 0.5rem 0 1rem; font-size: 0.9rem; }
+th, td { border: 1px solid #d0d7de; padding: 0.2rem 0.6rem; text-align: left; }
+th { background: #f6f8fa; }
+table.rates td { text-align: right; font-variant-numeric: tabular-nums; }
+.muted { color: #656d76; }
+section.page { break-inside: avoid; page-break-inside: avoid; }
+footer { margin-top: 2rem; font-size: 0.85rem; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{with .Description}}<p>{{.}}</p>{{end}}
+{{if .Classification}}<h2>Classification</h2>
+<table>{{range .Classification}}
+<tr><th>{{.Label}}</th><td>{{orDash .Value}}</td></tr>{{end}}
+</table>{{end}}
+{{with .Keywords}}<p><strong>Keywords:</strong> {{range $i, $k := .}}{{if $i}}, {{end}}{{$k}}{{end}}</p>{{end}}
+{{with .Comments}}<h2>Comments</h2>
+<p>{{.}}</p>{{end}}
+{{with .Reference}}<h2>References</h2>
+<p>{{.}}</p>{{end}}
+{{range .Tables}}<h2>{{.Heading}}</h2>
+{{if .Metadata}}<h3>Metadata</h3>
+<table>{{range .Metadata}}
+<tr><th>{{.Label}}</th><td>{{orDash .Value}}</td></tr>{{end}}
+</table>{{end}}
+{{if .Axes}}<h3>Axes</h3>
+<table>
+<tr><th>Axis</th><th>ID</th><th>Scale Type</th><th>Min</th><th>Max</th><th>Increment</th></tr>{{range .Axes}}
+<tr><td>{{orDash .AxisName}}</td><td>{{orDash .ID}}</td><td>{{orDash (classified .ScaleType)}}</td><td>{{orDash .MinValue}}</td><td>{{orDash .MaxValue}}</td><td>{{orDash .Increment}}</td></tr>{{end}}
+</table>{{end}}
+<h3>Summary</h3>
+<table>{{range .Summary}}
+<tr><th>{{.Label}}</th><td>{{.Value}}</td></tr>{{end}}
+</table>
+{{if .Pages}}<h3>Rates</h3>{{range .Pages}}
+<section class="page">
+<h4>{{.Caption}}</h4>
+<table class="rates">
+<tr>{{range .Header}}<th>{{.}}</th>{{end}}</tr>{{range .Rows}}
+<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>{{end}}
+</table>
+</section>{{end}}{{else}}<p class="muted">No rate data.</p>{{end}}
+{{end}}<footer class="muted">Generated by <code>mort report</code> from <code>{{.Identifier}}</code>{{with .Version}} (XTbML version {{.}}){{end}}.</footer>
+</body>
+</html>
+`))
+
+func renderHTML(w io.Writer, view reportView) error {
+	return htmlTemplate.Execute(w, view)
+}
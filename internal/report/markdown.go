@@ -0,0 +1,107 @@
+package report
+
+import (
+	"io"
+	"strings"
+)
+
+func renderMarkdown(w io.Writer, view reportView) error {
+	var b strings.Builder
+	b.WriteString("# " + mdInline(view.Title) + "\n\n")
+	if view.Description != "" {
+		b.WriteString(mdInline(view.Description) + "\n\n")
+	}
+
+	if len(view.Classification) > 0 {
+		b.WriteString("## Classification\n\n")
+		writeMarkdownFields(&b, view.Classification)
+	}
+	if len(view.Keywords) > 0 {
+		b.WriteString("**Keywords:** " + mdInline(strings.Join(view.Keywords, ", ")) + "\n\n")
+	}
+	if view.Comments != "" {
+		b.WriteString("## Comments\n\n" + mdInline(view.Comments) + "\n\n")
+	}
+	if view.Reference != "" {
+		b.WriteString("## References\n\n" + mdInline(view.Reference) + "\n\n")
+	}
+
+	for _, table := range view.Tables {
+		b.WriteString("## " + table.Heading + "\n\n")
+		if len(table.Metadata) > 0 {
+			b.WriteString("### Metadata\n\n")
+			writeMarkdownFields(&b, table.Metadata)
+		}
+		if len(table.Axes) > 0 {
+			b.WriteString("### Axes\n\n")
+			rows := make([][]string, len(table.Axes))
+			for i, axis := range table.Axes {
+				rows[i] = []string{axis.AxisName, axis.ID, classified(axis.ScaleType), axis.MinValue, axis.MaxValue, axis.Increment}
+			}
+			writeMarkdownTable(&b, []string{"Axis", "ID", "Scale Type", "Min", "Max", "Increment"}, rows)
+		}
+		b.WriteString("### Summary\n\n")
+		writeMarkdownFields(&b, table.Summary)
+		if len(table.Pages) == 0 {
+			b.WriteString("_No rate data._\n\n")
+			continue
+		}
+		b.WriteString("### Rates\n\n")
+		for _, page := range table.Pages {
+			b.WriteString("#### " + page.Caption + "\n\n")
+			writeMarkdownTable(&b, page.Header, page.Rows)
+		}
+	}
+
+	b.WriteString("---\n\n_Generated by `mort report` from `" + view.Identifier + "`")
+	if view.Version != "" {
+		b.WriteString(" (XTbML version " + mdInline(view.Version) + ")")
+	}
+	b.WriteString("._\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeMarkdownFields(b *strings.Builder, fields []field) {
+	rows := make([][]string, len(fields))
+	for i, f := range fields {
+		rows[i] = []string{f.Label, f.Value}
+	}
+	writeMarkdownTable(b, []string{"Field", "Value"}, rows)
+}
+
+func writeMarkdownTable(b *strings.Builder, header []string, rows [][]string) {
+	writeMarkdownRow(b, header)
+	sep := make([]string, len(header))
+	for i := range sep {
+		sep[i] = "---"
+	}
+	writeMarkdownRow(b, sep)
+	for _, row := range rows {
+		writeMarkdownRow(b, row)
+	}
+	b.WriteString("\n")
+}
+
+func writeMarkdownRow(b *strings.Builder, cells []string) {
+	b.WriteString("|")
+	for _, cell := range cells {
+		if cell == "" {
+			cell = "—"
+		}
+		b.WriteString(" " + mdCell(cell) + " |")
+	}
+	b.WriteString("\n")
+}
+
+var mdInlineReplacer = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ")
+
+// mdInline flattens text onto a single line so it cannot break block structure.
+func mdInline(s string) string {
+	return mdInlineReplacer.Replace(strings.TrimSpace(s))
+}
+
+func mdCell(s string) string {
+	return strings.ReplaceAll(mdInline(s), "|", `\|`)
+}
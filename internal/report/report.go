@@ -0,0 +1,247 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"mort/internal/xtbml"
+)
+
+// Format selects the report output flavour.
+type Format string
+
+const (
+	FormatMarkdown Format = "markdown"
+	FormatHTML     Format = "html"
+)
+
+const (
+	defaultPageSize   = 50
+	defaultMaxColumns = 12
+)
+
+// Options controls report rendering.
+type Options struct {
+	Format Format
+	// PageSize is the number of ages shown per rate page.
+	PageSize int
+	// MaxColumns caps the duration columns shown per rate page.
+	MaxColumns int
+}
+
+// ParseFormat maps a user-supplied format name to a Format.
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "md", "markdown":
+		return FormatMarkdown, nil
+	case "html", "htm":
+		return FormatHTML, nil
+	default:
+		return "", fmt.Errorf("unknown report format %q", name)
+	}
+}
+
+// Render writes a self-contained report for detail to w.
+func Render(w io.Writer, detail *xtbml.ConvertedTable, opts Options) error {
+	if detail == nil {
+		return fmt.Errorf("render report: no table")
+	}
+	view := buildView(detail, opts)
+	switch opts.Format {
+	case FormatHTML:
+		return renderHTML(w, view)
+	case FormatMarkdown, "":
+		return renderMarkdown(w, view)
+	default:
+		return fmt.Errorf("unknown report format %q", opts.Format)
+	}
+}
+
+type field struct {
+	Label string
+	Value string
+}
+
+type reportView struct {
+	Title          string
+	Description    string
+	Identifier     string
+	Version        string
+	Classification []field
+	Comments       string
+	Reference      string
+	Keywords       []string
+	Tables         []tableView
+}
+
+type tableView struct {
+	Heading  string
+	Metadata []field
+	Axes     []xtbml.AxisDefinitionPayload
+	Summary  []field
+	Pages    []ratePage
+}
+
+type ratePage struct {
+	Caption string
+	Header  []string
+	Rows    [][]string
+}
+
+func buildView(detail *xtbml.ConvertedTable, opts Options) reportView {
+	view := reportView{
+		Title:      detail.Identifier,
+		Identifier: detail.Identifier,
+		Version:    detail.Version,
+	}
+	if c := detail.Classification; c != nil {
+		if c.TableName != "" {
+			view.Title = c.TableName
+		}
+		view.Description = c.TableDescription
+		view.Comments = c.Comments
+		view.Reference = c.TableReference
+		view.Keywords = c.Keywords
+		view.Classification = []field{
+			{"Table Identity", c.TableIdentity},
+			{"Identifier", detail.Identifier},
+			{"Provider Domain", c.ProviderDomain},
+			{"Provider Name", c.ProviderName},
+			{"Content Type", classified(c.ContentType)},
+			{"XTbML Version", detail.Version},
+		}
+	}
+
+	for i, table := range detail.Tables {
+		tv := tableView{
+			Heading: fmt.Sprintf("Table %d of %d (index %d)", i+1, len(detail.Tables), table.Index),
+		}
+		if meta := table.Metadata; meta != nil {
+			tv.Metadata = []field{
+				{"Scaling Factor", meta.ScalingFactor},
+				{"Data Type", classified(meta.DataType)},
+				{"Nation", classified(meta.Nation)},
+				{"Description", meta.TableDescription},
+			}
+			tv.Axes = meta.Axes
+		}
+		tv.Summary = summaryFields(Summarize(table.Rates))
+		tv.Pages = paginate(table.Rates, opts)
+		view.Tables = append(view.Tables, tv)
+	}
+	return view
+}
+
+func summaryFields(stats Stats) []field {
+	fields := []field{
+		{"Cells", strconv.Itoa(stats.Cells)},
+		{"Missing", strconv.Itoa(stats.Missing)},
+	}
+	if stats.Cells == 0 {
+		return fields
+	}
+	fields = append(fields, field{"Ages", fmt.Sprintf("%d–%d", stats.MinAge, stats.MaxAge)})
+	if stats.HasDuration {
+		fields = append(fields, field{"Durations", fmt.Sprintf("%d–%d", stats.MinDuration, stats.MaxDuration)})
+	}
+	if stats.Cells > stats.Missing {
+		fields = append(fields,
+			field{"Minimum", formatFloat(stats.Min)},
+			field{"Maximum", formatFloat(stats.Max)},
+			field{"Mean", formatFloat(stats.Mean)},
+		)
+	}
+	return fields
+}
+
+// paginate splits a rate grid into pages of at most PageSize ages and
+// MaxColumns duration columns.
+func paginate(rates []xtbml.RateEntryPayload, opts Options) []ratePage {
+	if len(rates) == 0 {
+		return nil
+	}
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	maxColumns := opts.MaxColumns
+	if maxColumns <= 0 {
+		maxColumns = defaultMaxColumns
+	}
+
+	grid := newRateGrid(rates)
+	columns := grid.durations
+	if grid.hasPlainCells() || len(columns) == 0 {
+		columns = append([]int{-1}, columns...)
+	}
+
+	var pages []ratePage
+	for rowStart := 0; rowStart < len(grid.ages); rowStart += pageSize {
+		ages := grid.ages[rowStart:min(rowStart+pageSize, len(grid.ages))]
+		for colStart := 0; colStart < len(columns); colStart += maxColumns {
+			cols := columns[colStart:min(colStart+maxColumns, len(columns))]
+			page := ratePage{
+				Caption: pageCaption(ages, cols, len(columns) > maxColumns),
+				Header:  append([]string{"Age"}, columnTitles(cols)...),
+			}
+			for _, age := range ages {
+				row := []string{strconv.Itoa(age)}
+				for _, dur := range cols {
+					rate, ok := grid.lookup(age, dur)
+					if !ok || rate == nil {
+						row = append(row, "—")
+						continue
+					}
+					row = append(row, formatFloat(*rate))
+				}
+				page.Rows = append(page.Rows, row)
+			}
+			pages = append(pages, page)
+		}
+	}
+	return pages
+}
+
+func pageCaption(ages, cols []int, splitColumns bool) string {
+	caption := fmt.Sprintf("Ages %d–%d", ages[0], ages[len(ages)-1])
+	if !splitColumns {
+		return caption
+	}
+	first, last := cols[0], cols[len(cols)-1]
+	if first < 0 && len(cols) > 1 {
+		first = cols[1]
+	}
+	if first < 0 {
+		return caption + ", rates without duration"
+	}
+	return fmt.Sprintf("%s, durations %d–%d", caption, first, last)
+}
+
+func columnTitles(cols []int) []string {
+	out := make([]string, len(cols))
+	for i, dur := range cols {
+		if dur < 0 {
+			out[i] = "Rate"
+			continue
+		}
+		out[i] = fmt.Sprintf("Dur %d", dur)
+	}
+	return out
+}
+
+func classified(v xtbml.ClassifiedValuePayload) string {
+	switch {
+	case v.Label == "" && v.Code == "":
+		return ""
+	case v.Code == "":
+		return v.Label
+	default:
+		return fmt.Sprintf("%s (%s)", v.Label, v.Code)
+	}
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,99 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"mort/internal/xtbml"
+)
+
+func sampleTable() *xtbml.ConvertedTable {
+	rate := func(v float64) *float64 { return &v }
+	dur := func(v int) *int { return &v }
+	return &xtbml.ConvertedTable{
+		Identifier: "sample_table",
+		Version:    "1.3",
+		Classification: &xtbml.ClassificationPayload{
+			TableIdentity:  "42",
+			ProviderName:   "Example | Provider",
+			TableReference: "Example Reference",
+			ContentType:    xtbml.ClassifiedValuePayload{Code: "1", Label: "Demo"},
+			TableName:      "Sample <Table>",
+			Comments:       "Line one.\nLine two.",
+			Keywords:       []string{"demo"},
+		},
+		Tables: []xtbml.TablePayload{{
+			Index: 0,
+			Metadata: &xtbml.TableMetaPayload{
+				Nation: xtbml.ClassifiedValuePayload{Code: "1", Label: "Nowhere"},
+				Axes:   []xtbml.AxisDefinitionPayload{{ID: "Age", AxisName: "Age", MinValue: "40", MaxValue: "42"}},
+			},
+			Rates: []xtbml.RateEntryPayload{
+				{Age: 40, Duration: dur(1), Rate: rate(0.01)},
+				{Age: 40, Duration: dur(2), Rate: rate(0.02)},
+				{Age: 41, Duration: dur(1), Rate: rate(0.03)},
+				{Age: 41, Duration: dur(2)},
+				{Age: 42, Duration: dur(1), Rate: rate(0.00001)},
+			},
+		}},
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	stats := Summarize(sampleTable().Tables[0].Rates)
+	if stats.Cells != 5 || stats.Missing != 1 {
+		t.Fatalf("cell counts mismatch: %#v", stats)
+	}
+	if stats.MinAge != 40 || stats.MaxAge != 42 || !stats.HasDuration || stats.MaxDuration != 2 {
+		t.Fatalf("range mismatch: %#v", stats)
+	}
+	if stats.Min != 0.00001 || stats.Max != 0.03 {
+		t.Fatalf("min/max mismatch: %#v", stats)
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Render(&buf, sampleTable(), Options{Format: FormatMarkdown, PageSize: 2}); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"# Sample <Table>",
+		"| Provider Name | Example \\| Provider |",
+		"Line one. Line two.",
+		"#### Ages 40–41",
+		"#### Ages 42–42",
+		"| Age | Dur 1 | Dur 2 |",
+		"| 41 | 0.03 | — |",
+		"| 42 | 0.00001 | — |",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("markdown missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderHTMLEscapes(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Render(&buf, sampleTable(), Options{Format: FormatHTML, MaxColumns: 1}); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "<Table>") || !strings.Contains(out, "Sample &lt;Table&gt;") {
+		t.Fatalf("html did not escape table name:\n%s", out)
+	}
+	if !strings.Contains(out, "Ages 40–42, durations 2–2") {
+		t.Fatalf("html missing column page caption:\n%s", out)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat("HTML"); err != nil || f != FormatHTML {
+		t.Fatalf("ParseFormat(HTML) = %q, %v", f, err)
+	}
+	if _, err := ParseFormat("pdf"); err == nil {
+		t.Fatalf("expected error for unknown format")
+	}
+}
@@ -0,0 +1,121 @@
+package report
+
+import (
+	"sort"
+
+	"mort/internal/xtbml"
+)
+
+// Stats summarises the rate cells of a single table.
+type Stats struct {
+	Cells       int
+	Missing     int
+	Min         float64
+	Max         float64
+	Mean        float64
+	MinAge      int
+	MaxAge      int
+	HasDuration bool
+	MinDuration int
+	MaxDuration int
+}
+
+// Summarize computes Stats over a table's rate entries. Missing cells are
+// counted but excluded from the min/max/mean figures.
+func Summarize(rates []xtbml.RateEntryPayload) Stats {
+	var (
+		stats     Stats
+		sum       float64
+		populated int
+	)
+	for i, entry := range rates {
+		stats.Cells++
+		if i == 0 || entry.Age < stats.MinAge {
+			stats.MinAge = entry.Age
+		}
+		if i == 0 || entry.Age > stats.MaxAge {
+			stats.MaxAge = entry.Age
+		}
+		if entry.Duration != nil {
+			dur := *entry.Duration
+			if !stats.HasDuration || dur < stats.MinDuration {
+				stats.MinDuration = dur
+			}
+			if !stats.HasDuration || dur > stats.MaxDuration {
+				stats.MaxDuration = dur
+			}
+			stats.HasDuration = true
+		}
+		if entry.Rate == nil {
+			stats.Missing++
+			continue
+		}
+		rate := *entry.Rate
+		if populated == 0 || rate < stats.Min {
+			stats.Min = rate
+		}
+		if populated == 0 || rate > stats.Max {
+			stats.Max = rate
+		}
+		sum += rate
+		populated++
+	}
+	if populated > 0 {
+		stats.Mean = sum / float64(populated)
+	}
+	return stats
+}
+
+type cellKey struct {
+	age      int
+	duration int
+}
+
+// rateGrid arranges rate entries by age (rows) and duration (columns).
+type rateGrid struct {
+	ages      []int
+	durations []int
+	cells     map[cellKey]*float64
+}
+
+func newRateGrid(rates []xtbml.RateEntryPayload) rateGrid {
+	grid := rateGrid{cells: make(map[cellKey]*float64, len(rates))}
+	ageSet := make(map[int]struct{})
+	durSet := make(map[int]struct{})
+	for _, entry := range rates {
+		ageSet[entry.Age] = struct{}{}
+		key := cellKey{age: entry.Age, duration: -1}
+		if entry.Duration != nil {
+			key.duration = *entry.Duration
+			durSet[*entry.Duration] = struct{}{}
+		}
+		grid.cells[key] = entry.Rate
+	}
+	grid.ages = sortedKeys(ageSet)
+	grid.durations = sortedKeys(durSet)
+	return grid
+}
+
+func (g rateGrid) lookup(age, duration int) (*float64, bool) {
+	rate, ok := g.cells[cellKey{age: age, duration: duration}]
+	return rate, ok
+}
+
+// hasPlainCells reports whether any entry lacks a duration.
+func (g rateGrid) hasPlainCells() bool {
+	for _, age := range g.ages {
+		if _, ok := g.lookup(age, -1); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(set map[int]struct{}) []int {
+	out := make([]int, 0, len(set))
+	for k := range set {
+		out = append(out, k)
+	}
+	sort.Ints(out)
+	return out
+}
@@ -0,0 +1,252 @@
+// Package tablearchive keeps every downloaded revision of an XTbML table in a
+// content-addressed store, together with the change log entry that produced
+// it, so historical revisions can be listed and materialized for conversion.
+//
+// Layout under the archive root:
+//
+//	objects/<aa>/<sha256>.xml   revision bodies, one per distinct content
+//	index/t<N>.json             revision history of table N, oldest first
+package tablearchive
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoRevision is returned when a table has no revision matching a lookup.
+var ErrNoRevision = errors.New("no matching revision")
+
+// Revision describes one archived table body and the change that produced it.
+type Revision struct {
+	// Number is the 1-based position in the table's history.
+	Number int    `json:"number"`
+	Hash   string `json:"sha256"`
+	Size   int    `json:"size"`
+	// Archived is when the body was stored locally.
+	Archived time.Time `json:"archived"`
+
+	// Change log fields; LogID is the entry's TableIdentity.
+	LogID   int       `json:"logId,omitempty"`
+	Action  string    `json:"action"`
+	Comment string    `json:"comment,omitempty"`
+	UserID  string    `json:"userId,omitempty"`
+	LogDate time.Time `json:"logDate,omitzero"`
+}
+
+// Effective returns the time the revision became current upstream: its change
+// log date, or the archive time when the revision has no log entry.
+func (r Revision) Effective() time.Time {
+	if !r.LogDate.IsZero() {
+		return r.LogDate
+	}
+	return r.Archived
+}
+
+// Archive is a content-addressed revision store rooted at a directory. It is
+// safe for concurrent use.
+type Archive struct {
+	root string
+	mu   sync.Mutex
+	now  func() time.Time
+}
+
+// Open returns the archive rooted at root; directories are created on first
+// write.
+func Open(root string) *Archive {
+	return &Archive{root: root, now: time.Now}
+}
+
+// Root returns the archive directory.
+func (a *Archive) Root() string {
+	return a.root
+}
+
+func (a *Archive) objectPath(hash string) string {
+	return filepath.Join(a.root, "objects", hash[:2], hash+".xml")
+}
+
+func (a *Archive) indexPath(tableID int) string {
+	return filepath.Join(a.root, "index", fmt.Sprintf("t%d.json", tableID))
+}
+
+// Store archives body as the newest revision of tableID, described by rev
+// (Number, Hash, Size and Archived are filled in). Storing the same content
+// for the same change log entry again is a no-op, so retried syncs do not
+// grow the history. It returns the stored, or already present, revision.
+func (a *Archive) Store(tableID int, body []byte, rev Revision) (Revision, error) {
+	sum := sha256.Sum256(body)
+	rev.Hash = hex.EncodeToString(sum[:])
+	rev.Size = len(body)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	history, err := a.readHistory(tableID)
+	if err != nil {
+		return Revision{}, err
+	}
+	if n := len(history); n > 0 {
+		last := history[n-1]
+		if last.Hash == rev.Hash && last.LogID == rev.LogID && last.Action == rev.Action {
+			return last, nil
+		}
+	}
+
+	objPath := a.objectPath(rev.Hash)
+	if _, err := os.Stat(objPath); errors.Is(err, os.ErrNotExist) {
+		if err := writeAtomic(objPath, body); err != nil {
+			return Revision{}, fmt.Errorf("store object: %w", err)
+		}
+	} else if err != nil {
+		return Revision{}, err
+	}
+
+	rev.Number = len(history) + 1
+	rev.Archived = a.now().UTC()
+	history = append(history, rev)
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return Revision{}, err
+	}
+	if err := writeAtomic(a.indexPath(tableID), append(data, '\n')); err != nil {
+		return Revision{}, fmt.Errorf("write index: %w", err)
+	}
+	return rev, nil
+}
+
+// History returns every archived revision of tableID, oldest first.
+func (a *Archive) History(tableID int) ([]Revision, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.readHistory(tableID)
+}
+
+func (a *Archive) readHistory(tableID int) ([]Revision, error) {
+	data, err := os.ReadFile(a.indexPath(tableID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var history []Revision
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("decode index t%d: %w", tableID, err)
+	}
+	return history, nil
+}
+
+// Lookup finds a revision of tableID by number ("3"), hash prefix of at least
+// four characters ("9f2c…"), or "latest".
+func (a *Archive) Lookup(tableID int, ref string) (Revision, error) {
+	history, err := a.History(tableID)
+	if err != nil {
+		return Revision{}, err
+	}
+	if len(history) == 0 {
+		return Revision{}, fmt.Errorf("t%d: %w", tableID, ErrNoRevision)
+	}
+	ref = strings.TrimSpace(strings.ToLower(ref))
+	if ref == "" || ref == "latest" {
+		return history[len(history)-1], nil
+	}
+	if n, err := strconv.Atoi(ref); err == nil && len(ref) < 4 {
+		if n < 1 || n > len(history) {
+			return Revision{}, fmt.Errorf("t%d revision %d: %w", tableID, n, ErrNoRevision)
+		}
+		return history[n-1], nil
+	}
+	if len(ref) < 4 {
+		return Revision{}, fmt.Errorf("t%d revision %q: hash prefix needs at least 4 characters", tableID, ref)
+	}
+	var match *Revision
+	for i := range history {
+		if strings.HasPrefix(history[i].Hash, ref) {
+			if match != nil && match.Hash != history[i].Hash {
+				return Revision{}, fmt.Errorf("t%d revision %q is ambiguous", tableID, ref)
+			}
+			match = &history[i]
+		}
+	}
+	if match == nil {
+		return Revision{}, fmt.Errorf("t%d revision %q: %w", tableID, ref, ErrNoRevision)
+	}
+	return *match, nil
+}
+
+// AsOf returns the revision of tableID that was current at t: the newest
+// revision whose effective date is not after t.
+func (a *Archive) AsOf(tableID int, t time.Time) (Revision, error) {
+	history, err := a.History(tableID)
+	if err != nil {
+		return Revision{}, err
+	}
+	var best *Revision
+	for i := range history {
+		rev := &history[i]
+		if rev.Effective().After(t) {
+			continue
+		}
+		if best == nil || !rev.Effective().Before(best.Effective()) {
+			best = rev
+		}
+	}
+	if best == nil {
+		return Revision{}, fmt.Errorf("t%d as of %s: %w", tableID, t.Format(time.DateOnly), ErrNoRevision)
+	}
+	return *best, nil
+}
+
+// Read returns the archived body of rev, verifying its hash.
+func (a *Archive) Read(rev Revision) ([]byte, error) {
+	body, err := os.ReadFile(a.objectPath(rev.Hash))
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(body)
+	if hex.EncodeToString(sum[:]) != rev.Hash {
+		return nil, fmt.Errorf("archived object %s is corrupt", rev.Hash)
+	}
+	return body, nil
+}
+
+// Materialize writes the body of rev to dst.
+func (a *Archive) Materialize(rev Revision, dst string) error {
+	body, err := a.Read(rev)
+	if err != nil {
+		return err
+	}
+	return writeAtomic(dst, body)
+}
+
+func writeAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,124 @@
+package tablearchive
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestArchive(t *testing.T) *Archive {
+	t.Helper()
+	a := Open(t.TempDir())
+	clock := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	a.now = func() time.Time {
+		clock = clock.Add(time.Hour)
+		return clock
+	}
+	return a
+}
+
+func TestStoreAndHistory(t *testing.T) {
+	a := newTestArchive(t)
+	jan := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	mar := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	first, err := a.Store(7, []byte("v1"), Revision{LogID: 100, Action: "Add", UserID: "jdoe", LogDate: jan})
+	if err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	again, err := a.Store(7, []byte("v1"), Revision{LogID: 100, Action: "Add", UserID: "jdoe", LogDate: jan})
+	if err != nil {
+		t.Fatalf("Store() repeat error = %v", err)
+	}
+	if again != first {
+		t.Fatalf("repeat Store() = %+v, want existing %+v", again, first)
+	}
+	if _, err := a.Store(7, []byte("v2"), Revision{LogID: 101, Action: "Update", Comment: "Fixed age 90", LogDate: mar}); err != nil {
+		t.Fatalf("Store() v2 error = %v", err)
+	}
+	if _, err := a.Store(7, []byte("v1"), Revision{LogID: 102, Action: "Update", Comment: "Reverted"}); err != nil {
+		t.Fatalf("Store() revert error = %v", err)
+	}
+
+	history, err := a.History(7)
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("len(history) = %d, want 3", len(history))
+	}
+	if history[0].Number != 1 || history[2].Number != 3 || history[1].Comment != "Fixed age 90" {
+		t.Fatalf("unexpected history: %+v", history)
+	}
+	if history[0].Hash != history[2].Hash {
+		t.Fatal("identical content should share one object")
+	}
+	objects, err := filepath.Glob(filepath.Join(a.Root(), "objects", "*", "*.xml"))
+	if err != nil || len(objects) != 2 {
+		t.Fatalf("objects = %v, %v; want 2 distinct bodies", objects, err)
+	}
+}
+
+func TestLookupAndAsOf(t *testing.T) {
+	a := newTestArchive(t)
+	jan := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	mar := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	v1, _ := a.Store(3, []byte("v1"), Revision{Action: "Add", LogDate: jan})
+	v2, _ := a.Store(3, []byte("v2"), Revision{Action: "Update", LogDate: mar})
+
+	tests := []struct {
+		ref  string
+		want Revision
+	}{
+		{"", v2},
+		{"latest", v2},
+		{"1", v1},
+		{v1.Hash[:8], v1},
+		{v2.Hash, v2},
+	}
+	for _, tt := range tests {
+		got, err := a.Lookup(3, tt.ref)
+		if err != nil || got.Hash != tt.want.Hash {
+			t.Fatalf("Lookup(%q) = %+v, %v; want %s", tt.ref, got, err, tt.want.Hash)
+		}
+	}
+	if _, err := a.Lookup(3, "9"); !errors.Is(err, ErrNoRevision) {
+		t.Fatalf("Lookup(9) error = %v, want ErrNoRevision", err)
+	}
+
+	got, err := a.AsOf(3, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC))
+	if err != nil || got.Hash != v1.Hash {
+		t.Fatalf("AsOf(Feb) = %+v, %v; want v1", got, err)
+	}
+	got, err = a.AsOf(3, mar)
+	if err != nil || got.Hash != v2.Hash {
+		t.Fatalf("AsOf(Mar) = %+v, %v; want v2", got, err)
+	}
+	if _, err := a.AsOf(3, time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)); !errors.Is(err, ErrNoRevision) {
+		t.Fatalf("AsOf(before history) error = %v, want ErrNoRevision", err)
+	}
+}
+
+func TestMaterializeVerifiesContent(t *testing.T) {
+	a := newTestArchive(t)
+	rev, err := a.Store(1, []byte("<XTbML/>"), Revision{Action: "Add"})
+	if err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	dst := filepath.Join(t.TempDir(), "out", "t1.xml")
+	if err := a.Materialize(rev, dst); err != nil {
+		t.Fatalf("Materialize() error = %v", err)
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "<XTbML/>" {
+		t.Fatalf("materialized %q", data)
+	}
+
+	if err := os.WriteFile(a.objectPath(rev.Hash), []byte("tampered"), 0o644); err != nil {
+		t.Fatalf("tamper: %v", err)
+	}
+	if _, err := a.Read(rev); err == nil {
+		t.Fatal("Read() should reject a corrupt object")
+	}
+}
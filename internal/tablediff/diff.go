@@ -0,0 +1,316 @@
+// Package tablediff compares two versions of a converted rate table: the
+// classification and metadata fields that changed, and every rate cell that
+// moved, with absolute and relative deltas.
+package tablediff
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"mort/internal/xtbml"
+)
+
+// Cell change kinds.
+const (
+	CellChanged = "changed"
+	CellAdded   = "added"
+	CellRemoved = "removed"
+)
+
+// FieldChange is a classification or metadata field whose value differs.
+// Path names the field, e.g. "classification.comments" or
+// "tables[0].metadata.axes[Age].maxValue".
+type FieldChange struct {
+	Path   string `json:"path"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// CellChange is one rate cell that differs between the tables. Abs is
+// After-Before and Rel is Abs relative to Before; both are nil when either
+// rate is missing, and Rel is nil when Before is zero.
+type CellChange struct {
+	Kind     string   `json:"kind"`
+	Table    int      `json:"table"`
+	Age      int      `json:"age"`
+	Duration *int     `json:"duration,omitempty"`
+	Before   *float64 `json:"before"`
+	After    *float64 `json:"after"`
+	Abs      *float64 `json:"abs,omitempty"`
+	Rel      *float64 `json:"rel,omitempty"`
+}
+
+// TableStats summarises the rate differences of one rate table. Status is
+// CellAdded or CellRemoved when the table exists on one side only. The delta
+// figures cover changed cells whose rates are present on both sides.
+type TableStats struct {
+	Table    int     `json:"table"`
+	Status   string  `json:"status,omitempty"`
+	Compared int     `json:"compared"`
+	Changed  int     `json:"changed"`
+	Added    int     `json:"added"`
+	Removed  int     `json:"removed"`
+	MaxAbs   float64 `json:"maxAbs"`
+	MeanAbs  float64 `json:"meanAbs"`
+	MaxRel   float64 `json:"maxRel"`
+}
+
+// Differs reports whether any cell of the table differs.
+func (s TableStats) Differs() bool {
+	return s.Status != "" || s.Changed+s.Added+s.Removed > 0
+}
+
+// Result is the difference between two tables.
+type Result struct {
+	Fields []FieldChange `json:"fields"`
+	Tables []TableStats  `json:"tables"`
+	Cells  []CellChange  `json:"cells"`
+}
+
+// RatesChanged reports whether any rate cell differs.
+func (r *Result) RatesChanged() bool {
+	for _, stats := range r.Tables {
+		if stats.Differs() {
+			return true
+		}
+	}
+	return false
+}
+
+// Equal reports whether the tables have no differences at all.
+func (r *Result) Equal() bool {
+	return len(r.Fields) == 0 && !r.RatesChanged()
+}
+
+// Options tunes the comparison.
+type Options struct {
+	// Tolerance is the absolute rate difference at or below which two rates
+	// count as equal.
+	Tolerance float64
+}
+
+// Compare reports how b differs from a. Rate tables are matched by position
+// and cells by age and duration.
+func Compare(a, b *xtbml.ConvertedTable, opts Options) *Result {
+	res := &Result{Fields: []FieldChange{}, Tables: []TableStats{}, Cells: []CellChange{}}
+	res.field("identifier", a.Identifier, b.Identifier)
+	res.field("version", a.Version, b.Version)
+	res.compareClassification(a.Classification, b.Classification)
+
+	for i := 0; i < len(a.Tables) || i < len(b.Tables); i++ {
+		var before, after *xtbml.TablePayload
+		if i < len(a.Tables) {
+			before = &a.Tables[i]
+		}
+		if i < len(b.Tables) {
+			after = &b.Tables[i]
+		}
+		res.compareTable(i, before, after, opts)
+	}
+	return res
+}
+
+func (r *Result) field(path, before, after string) {
+	if before != after {
+		r.Fields = append(r.Fields, FieldChange{Path: path, Before: before, After: after})
+	}
+}
+
+func (r *Result) compareClassification(a, b *xtbml.ClassificationPayload) {
+	if a == nil {
+		a = &xtbml.ClassificationPayload{}
+	}
+	if b == nil {
+		b = &xtbml.ClassificationPayload{}
+	}
+	r.field("classification.tableIdentity", a.TableIdentity, b.TableIdentity)
+	r.field("classification.providerDomain", a.ProviderDomain, b.ProviderDomain)
+	r.field("classification.providerName", a.ProviderName, b.ProviderName)
+	r.field("classification.tableReference", a.TableReference, b.TableReference)
+	r.field("classification.contentType", classified(a.ContentType), classified(b.ContentType))
+	r.field("classification.tableName", a.TableName, b.TableName)
+	r.field("classification.tableDescription", a.TableDescription, b.TableDescription)
+	r.field("classification.comments", a.Comments, b.Comments)
+	r.field("classification.keywords", strings.Join(a.Keywords, ", "), strings.Join(b.Keywords, ", "))
+}
+
+func (r *Result) compareMetadata(index int, a, b *xtbml.TableMetaPayload) {
+	if a == nil {
+		a = &xtbml.TableMetaPayload{}
+	}
+	if b == nil {
+		b = &xtbml.TableMetaPayload{}
+	}
+	prefix := fmt.Sprintf("tables[%d].metadata.", index)
+	r.field(prefix+"scalingFactor", a.ScalingFactor, b.ScalingFactor)
+	r.field(prefix+"dataType", classified(a.DataType), classified(b.DataType))
+	r.field(prefix+"nation", classified(a.Nation), classified(b.Nation))
+	r.field(prefix+"tableDescription", a.TableDescription, b.TableDescription)
+
+	// Axes are matched by name so a reordered axis list is not a change.
+	before := make(map[string]xtbml.AxisDefinitionPayload, len(a.Axes))
+	for _, axis := range a.Axes {
+		before[axisName(axis)] = axis
+	}
+	seen := make(map[string]bool, len(b.Axes))
+	for _, axis := range b.Axes {
+		name := axisName(axis)
+		seen[name] = true
+		path := fmt.Sprintf("%saxes[%s]", prefix, name)
+		old, ok := before[name]
+		if !ok {
+			r.field(path, "", describeAxis(axis))
+			continue
+		}
+		r.field(path+".scaleType", classified(old.ScaleType), classified(axis.ScaleType))
+		r.field(path+".minValue", old.MinValue, axis.MinValue)
+		r.field(path+".maxValue", old.MaxValue, axis.MaxValue)
+		r.field(path+".increment", old.Increment, axis.Increment)
+	}
+	for _, axis := range a.Axes {
+		if name := axisName(axis); !seen[name] {
+			r.field(fmt.Sprintf("%saxes[%s]", prefix, name), describeAxis(axis), "")
+		}
+	}
+}
+
+type cellKey struct {
+	age         int
+	duration    int
+	hasDuration bool
+}
+
+func keyOf(entry xtbml.RateEntryPayload) cellKey {
+	if entry.Duration == nil {
+		return cellKey{age: entry.Age}
+	}
+	return cellKey{age: entry.Age, duration: *entry.Duration, hasDuration: true}
+}
+
+func (k cellKey) less(o cellKey) bool {
+	if k.age != o.age {
+		return k.age < o.age
+	}
+	if k.hasDuration != o.hasDuration {
+		return !k.hasDuration
+	}
+	return k.duration < o.duration
+}
+
+func (r *Result) compareTable(index int, a, b *xtbml.TablePayload, opts Options) {
+	stats := TableStats{Table: index}
+	var metaA, metaB *xtbml.TableMetaPayload
+	var ratesA, ratesB []xtbml.RateEntryPayload
+	switch {
+	case a == nil:
+		stats.Status = CellAdded
+	case b == nil:
+		stats.Status = CellRemoved
+	}
+	if a != nil {
+		metaA, ratesA = a.Metadata, a.Rates
+	}
+	if b != nil {
+		metaB, ratesB = b.Metadata, b.Rates
+	}
+	// An added or removed table is reported by its status, not field by field.
+	if a != nil && b != nil {
+		r.compareMetadata(index, metaA, metaB)
+	}
+
+	before := make(map[cellKey]*float64, len(ratesA))
+	for _, entry := range ratesA {
+		before[keyOf(entry)] = entry.Rate
+	}
+	after := make(map[cellKey]*float64, len(ratesB))
+	for _, entry := range ratesB {
+		after[keyOf(entry)] = entry.Rate
+	}
+	keys := make([]cellKey, 0, len(before)+len(after))
+	for key := range before {
+		keys = append(keys, key)
+	}
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].less(keys[j]) })
+
+	var sumAbs float64
+	var deltas int
+	for _, key := range keys {
+		oldRate, inA := before[key]
+		newRate, inB := after[key]
+		change := CellChange{Table: index, Age: key.age, Before: oldRate, After: newRate}
+		if key.hasDuration {
+			d := key.duration
+			change.Duration = &d
+		}
+		switch {
+		case !inA:
+			change.Kind = CellAdded
+			stats.Added++
+		case !inB:
+			change.Kind = CellRemoved
+			stats.Removed++
+		default:
+			stats.Compared++
+			if !ratesDiffer(oldRate, newRate, opts.Tolerance) {
+				continue
+			}
+			change.Kind = CellChanged
+			stats.Changed++
+			if oldRate != nil && newRate != nil {
+				abs := *newRate - *oldRate
+				change.Abs = &abs
+				sumAbs += math.Abs(abs)
+				deltas++
+				stats.MaxAbs = math.Max(stats.MaxAbs, math.Abs(abs))
+				if *oldRate != 0 {
+					rel := abs / math.Abs(*oldRate)
+					change.Rel = &rel
+					stats.MaxRel = math.Max(stats.MaxRel, math.Abs(rel))
+				}
+			}
+		}
+		r.Cells = append(r.Cells, change)
+	}
+	if deltas > 0 {
+		stats.MeanAbs = sumAbs / float64(deltas)
+	}
+	r.Tables = append(r.Tables, stats)
+}
+
+func ratesDiffer(a, b *float64, tolerance float64) bool {
+	if a == nil || b == nil {
+		return (a == nil) != (b == nil)
+	}
+	return math.Abs(*b-*a) > tolerance
+}
+
+func classified(v xtbml.ClassifiedValuePayload) string {
+	switch {
+	case v.Code == "" && v.Label == "":
+		return ""
+	case v.Code == "":
+		return v.Label
+	case v.Label == "":
+		return v.Code
+	default:
+		return fmt.Sprintf("%s (%s)", v.Label, v.Code)
+	}
+}
+
+func axisName(axis xtbml.AxisDefinitionPayload) string {
+	if axis.AxisName != "" {
+		return axis.AxisName
+	}
+	return axis.ID
+}
+
+func describeAxis(axis xtbml.AxisDefinitionPayload) string {
+	return fmt.Sprintf("%s to %s step %s", axis.MinValue, axis.MaxValue, axis.Increment)
+}
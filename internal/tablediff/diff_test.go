@@ -0,0 +1,118 @@
+package tablediff
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"mort/internal/xtbml"
+)
+
+func rate(v float64) *float64 { return &v }
+
+func dur(v int) *int { return &v }
+
+func sample(comments string, rates ...xtbml.RateEntryPayload) *xtbml.ConvertedTable {
+	return &xtbml.ConvertedTable{
+		Identifier: "sample",
+		Version:    "1.3",
+		Classification: &xtbml.ClassificationPayload{
+			TableIdentity: "12",
+			TableName:     "Sample",
+			Comments:      comments,
+			ContentType:   xtbml.ClassifiedValuePayload{Code: "4", Label: "Insured Lives Mortality"},
+		},
+		Tables: []xtbml.TablePayload{{
+			Metadata: &xtbml.TableMetaPayload{Axes: []xtbml.AxisDefinitionPayload{
+				{ID: "Age", AxisName: "Age", MinValue: "40", MaxValue: "42", Increment: "1"},
+			}},
+			Rates: rates,
+		}},
+	}
+}
+
+func TestCompareIdentical(t *testing.T) {
+	a := sample("note", xtbml.RateEntryPayload{Age: 40, Rate: rate(0.01)})
+	res := Compare(a, a, Options{})
+	if !res.Equal() || res.Verdict() != "No differences." {
+		t.Fatalf("Compare(a, a) = %+v", res)
+	}
+}
+
+func TestCompareCommentsOnly(t *testing.T) {
+	a := sample("old note", xtbml.RateEntryPayload{Age: 40, Rate: rate(0.01)})
+	b := sample("new note", xtbml.RateEntryPayload{Age: 40, Rate: rate(0.01)})
+	res := Compare(a, b, Options{})
+	if res.RatesChanged() {
+		t.Fatalf("rates reported as changed: %+v", res.Cells)
+	}
+	if len(res.Fields) != 1 || res.Fields[0] != (FieldChange{Path: "classification.comments", Before: "old note", After: "new note"}) {
+		t.Fatalf("fields = %+v", res.Fields)
+	}
+	if got := res.Verdict(); got != "Rates are identical; 1 field changed." {
+		t.Fatalf("Verdict() = %q", got)
+	}
+}
+
+func TestCompareRates(t *testing.T) {
+	a := sample("",
+		xtbml.RateEntryPayload{Age: 40, Duration: dur(1), Rate: rate(0.01)},
+		xtbml.RateEntryPayload{Age: 40, Duration: dur(2), Rate: rate(0.02)},
+		xtbml.RateEntryPayload{Age: 41, Duration: dur(1), Rate: rate(0.03)},
+		xtbml.RateEntryPayload{Age: 42, Duration: dur(1), Rate: nil})
+	b := sample("",
+		xtbml.RateEntryPayload{Age: 40, Duration: dur(1), Rate: rate(0.011)},
+		xtbml.RateEntryPayload{Age: 40, Duration: dur(2), Rate: rate(0.0200000001)},
+		xtbml.RateEntryPayload{Age: 42, Duration: dur(1), Rate: rate(0.05)},
+		xtbml.RateEntryPayload{Age: 43, Duration: dur(1), Rate: rate(0.06)})
+	b.Tables[0].Metadata.Axes[0].MaxValue = "43"
+
+	res := Compare(a, b, Options{Tolerance: 1e-9})
+	if len(res.Fields) != 1 || res.Fields[0].Path != "tables[0].metadata.axes[Age].maxValue" {
+		t.Fatalf("fields = %+v", res.Fields)
+	}
+	stats := res.Tables[0]
+	if stats.Compared != 3 || stats.Changed != 2 || stats.Added != 1 || stats.Removed != 1 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	if FormatRate(stats.MaxAbs) != "0.001" || FormatPercent(stats.MaxRel) != "+10.00%" {
+		t.Fatalf("deltas = %v, %v", stats.MaxAbs, stats.MaxRel)
+	}
+
+	var out bytes.Buffer
+	if err := WriteText(&out, res, TextOptions{A: "old", B: "new"}); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+	want := "--- old\n+++ new\n\n" +
+		"Fields:\n  tables[0].metadata.axes[Age].maxValue\n    - 42\n    + 43\n\n" +
+		"Rates:\n  table 0: 2 of 3 cells changed, 1 added, 1 removed; max |Δ| 0.001, mean |Δ| 0.001, max relative 10.00%\n" +
+		"    age 40 duration 1: 0.01 -> 0.011 (+0.001, +10.00%)\n" +
+		"    age 41 duration 1: removed 0.03\n" +
+		"    age 42 duration 1: blank -> 0.05\n" +
+		"    age 43 duration 1: added 0.06\n\n" +
+		"Rates changed in 1 table and 1 field changed.\n"
+	if out.String() != want {
+		t.Fatalf("WriteText() =\n%s\nwant\n%s", out.String(), want)
+	}
+
+	out.Reset()
+	if err := WriteText(&out, res, TextOptions{MaxCells: 1}); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "… 3 more cells") {
+		t.Fatalf("WriteText(MaxCells: 1) =\n%s", out.String())
+	}
+}
+
+func TestCompareAddedTable(t *testing.T) {
+	a := sample("", xtbml.RateEntryPayload{Age: 40, Rate: rate(0.01)})
+	b := sample("", xtbml.RateEntryPayload{Age: 40, Rate: rate(0.01)})
+	b.Tables = append(b.Tables, xtbml.TablePayload{Index: 1, Rates: []xtbml.RateEntryPayload{{Age: 40, Rate: rate(0.02)}}})
+	res := Compare(a, b, Options{})
+	if len(res.Tables) != 2 || res.Tables[1].Status != CellAdded || res.Tables[1].Added != 1 {
+		t.Fatalf("tables = %+v", res.Tables)
+	}
+	if res.Tables[0].Differs() {
+		t.Fatalf("table 0 should be unchanged: %+v", res.Tables[0])
+	}
+}
@@ -0,0 +1,165 @@
+package tablediff
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// TextOptions controls WriteText.
+type TextOptions struct {
+	// A and B label the two sides in the header.
+	A, B string
+	// MaxCells limits the cell changes listed per rate table; zero lists all.
+	MaxCells int
+}
+
+// Verdict summarises the result in one sentence, telling apart a table whose
+// rates changed from one where only descriptive fields did.
+func (r *Result) Verdict() string {
+	switch {
+	case r.Equal():
+		return "No differences."
+	case !r.RatesChanged():
+		return fmt.Sprintf("Rates are identical; %s changed.", plural(len(r.Fields), "field"))
+	case len(r.Fields) == 0:
+		return fmt.Sprintf("Rates changed in %s; classification and metadata are identical.", plural(r.changedTables(), "table"))
+	default:
+		return fmt.Sprintf("Rates changed in %s and %s changed.", plural(r.changedTables(), "table"), plural(len(r.Fields), "field"))
+	}
+}
+
+func (r *Result) changedTables() int {
+	n := 0
+	for _, stats := range r.Tables {
+		if stats.Differs() {
+			n++
+		}
+	}
+	return n
+}
+
+// WriteText renders the result for a terminal: field changes, then per-table
+// rate statistics and the changed cells, then the verdict.
+func WriteText(w io.Writer, r *Result, opts TextOptions) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "--- %s\n+++ %s\n", opts.A, opts.B)
+
+	if len(r.Fields) > 0 {
+		fmt.Fprintln(bw, "\nFields:")
+		for _, f := range r.Fields {
+			fmt.Fprintf(bw, "  %s\n", f.Path)
+			fmt.Fprintf(bw, "    - %s\n", orNone(f.Before))
+			fmt.Fprintf(bw, "    + %s\n", orNone(f.After))
+		}
+	}
+
+	if r.RatesChanged() {
+		fmt.Fprintln(bw, "\nRates:")
+	}
+	for _, stats := range r.Tables {
+		if !stats.Differs() {
+			continue
+		}
+		fmt.Fprintf(bw, "  %s\n", describeStats(stats))
+		listed, more := 0, 0
+		for _, c := range r.Cells {
+			if c.Table != stats.Table {
+				continue
+			}
+			if opts.MaxCells > 0 && listed >= opts.MaxCells {
+				more++
+				continue
+			}
+			listed++
+			fmt.Fprintf(bw, "    %s\n", describeCell(c))
+		}
+		if more > 0 {
+			fmt.Fprintf(bw, "    … %d more cells\n", more)
+		}
+	}
+
+	fmt.Fprintf(bw, "\n%s\n", r.Verdict())
+	return bw.Flush()
+}
+
+func describeStats(s TableStats) string {
+	head := fmt.Sprintf("table %d", s.Table)
+	switch s.Status {
+	case CellAdded:
+		return fmt.Sprintf("%s added with %s", head, plural(s.Added, "cell"))
+	case CellRemoved:
+		return fmt.Sprintf("%s removed with %s", head, plural(s.Removed, "cell"))
+	}
+	line := fmt.Sprintf("%s: %d of %d cells changed, %d added, %d removed", head, s.Changed, s.Compared, s.Added, s.Removed)
+	if s.MaxAbs > 0 {
+		line += fmt.Sprintf("; max |Δ| %s, mean |Δ| %s", FormatRate(s.MaxAbs), FormatRate(s.MeanAbs))
+	}
+	if s.MaxRel > 0 {
+		line += fmt.Sprintf(", max relative %.2f%%", s.MaxRel*100)
+	}
+	return line
+}
+
+func describeCell(c CellChange) string {
+	loc := fmt.Sprintf("age %d", c.Age)
+	if c.Duration != nil {
+		loc += fmt.Sprintf(" duration %d", *c.Duration)
+	}
+	switch c.Kind {
+	case CellAdded:
+		return fmt.Sprintf("%s: added %s", loc, formatOptional(c.After))
+	case CellRemoved:
+		return fmt.Sprintf("%s: removed %s", loc, formatOptional(c.Before))
+	}
+	line := fmt.Sprintf("%s: %s -> %s", loc, formatOptional(c.Before), formatOptional(c.After))
+	if c.Abs != nil {
+		sign := ""
+		if *c.Abs > 0 {
+			sign = "+"
+		}
+		line += fmt.Sprintf(" (%s%s", sign, FormatRate(*c.Abs))
+		if c.Rel != nil {
+			line += ", " + FormatPercent(*c.Rel)
+		}
+		line += ")"
+	}
+	return line
+}
+
+// FormatRate renders a rate or delta without exponent notation, rounded to
+// 12 significant digits so float noise in deltas does not show.
+func FormatRate(v float64) string {
+	rounded, err := strconv.ParseFloat(strconv.FormatFloat(v, 'g', 12, 64), 64)
+	if err != nil {
+		rounded = v
+	}
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}
+
+func formatOptional(v *float64) string {
+	if v == nil {
+		return "blank"
+	}
+	return FormatRate(*v)
+}
+
+// FormatPercent renders a relative delta as a signed percentage.
+func FormatPercent(v float64) string {
+	return fmt.Sprintf("%+.2f%%", v*100)
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
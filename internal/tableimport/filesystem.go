@@ -0,0 +1,85 @@
+package tableimport
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"mort/internal/xtbml"
+)
+
+// ImportDirectory imports every legacy file in srcDir matching format and
+// writes JSON outputs to dstDir, reporting each conversion via observer.
+func ImportDirectory(srcDir, dstDir string, format Format, opts xtbml.ConvertOptions, observer func(src, dst string)) error {
+	entries, err := os.ReadDir(srcDir)
+	if err != nil {
+		return fmt.Errorf("read src dir: %w", err)
+	}
+	if err := os.MkdirAll(dstDir, 0o755); err != nil {
+		return fmt.Errorf("ensure dst dir: %w", err)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	for _, entry := range entries {
+		if entry.IsDir() || !hasExtension(entry.Name(), format.Extensions()) {
+			continue
+		}
+
+		srcPath := filepath.Join(srcDir, entry.Name())
+		dstName := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())) + ".json"
+		dstPath := filepath.Join(dstDir, dstName)
+
+		if err := ImportFile(srcPath, dstPath, format, opts); err != nil {
+			return err
+		}
+		if observer != nil {
+			observer(srcPath, dstPath)
+		}
+	}
+	return nil
+}
+
+// ImportFile imports a single legacy file and its sidecar into JSON at dstPath,
+// validating the output against the JSON schema before writing it.
+func ImportFile(srcPath, dstPath string, format Format, opts xtbml.ConvertOptions) error {
+	sidecar, err := LoadSidecar(SidecarPath(srcPath))
+	if err != nil {
+		return fmt.Errorf("import %s: %w", srcPath, err)
+	}
+	f, err := os.Open(srcPath)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", srcPath, err)
+	}
+	defer f.Close()
+
+	table, err := Import(f, format, sidecar)
+	if err != nil {
+		return fmt.Errorf("import %s: %w", srcPath, err)
+	}
+	out, err := xtbml.EncodeWithOptions(table, opts)
+	if err != nil {
+		return fmt.Errorf("import %s: %w", srcPath, err)
+	}
+	if err := xtbml.ValidateJSON(out); err != nil {
+		return fmt.Errorf("validate %s: %w", srcPath, err)
+	}
+	if err := os.WriteFile(dstPath, out, 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", dstPath, err)
+	}
+	return nil
+}
+
+func hasExtension(name string, exts []string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, want := range exts {
+		if ext == want {
+			return true
+		}
+	}
+	return false
+}
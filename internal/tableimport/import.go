@@ -0,0 +1,244 @@
+package tableimport
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"mort/internal/xtbml"
+)
+
+// Import parses a legacy CSV or fixed-width rate file and combines it with
+// sidecar metadata into the converter's ConvertedTable structure.
+func Import(r io.Reader, format Format, sidecar *Sidecar) (*xtbml.ConvertedTable, error) {
+	if sidecar == nil {
+		return nil, fmt.Errorf("import: missing sidecar metadata")
+	}
+	header, rows, err := readRecords(r, format, sidecar.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	layout := sidecar.Layout
+	if layout == "" {
+		layout = detectLayout(header)
+	}
+
+	var tables map[int][]xtbml.RateEntryPayload
+	switch layout {
+	case LayoutLong:
+		tables, err = parseLong(header, rows)
+	case LayoutMatrix:
+		tables, err = parseMatrix(header, rows)
+	default:
+		err = fmt.Errorf("unknown layout %q", layout)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("no rate data found")
+	}
+
+	classification := sidecar.Classification
+	identifier := sidecar.Identifier
+	if identifier == "" {
+		identifier = xtbml.NormalizeIdentifier(classification.TableName)
+	}
+	version := sidecar.Version
+	if version == "" {
+		version = "unknown"
+	}
+
+	indexes := make([]int, 0, len(tables))
+	for idx := range tables {
+		indexes = append(indexes, idx)
+	}
+	sort.Ints(indexes)
+
+	out := &xtbml.ConvertedTable{
+		SchemaVersion:  xtbml.CurrentSchemaVersion,
+		Identifier:     identifier,
+		Version:        version,
+		Classification: &classification,
+		Tables:         make([]xtbml.TablePayload, len(indexes)),
+	}
+	for i, idx := range indexes {
+		rates := tables[idx]
+		meta := xtbml.TableMetaPayload{}
+		if idx < len(sidecar.Tables) {
+			meta = sidecar.Tables[idx]
+		}
+		if len(meta.Axes) == 0 {
+			meta.Axes = deriveAxes(rates)
+		}
+		out.Tables[i] = xtbml.TablePayload{
+			Index:    idx,
+			Metadata: &meta,
+			Rates:    rates,
+		}
+	}
+	xtbml.NormalizeVocabulary(out)
+	return out, nil
+}
+
+func detectLayout(header []string) Layout {
+	for _, name := range header {
+		if longColumnNames[strings.ToLower(name)] == "rate" {
+			return LayoutLong
+		}
+	}
+	return LayoutMatrix
+}
+
+var longColumnNames = map[string]string{
+	"age":      "age",
+	"duration": "duration",
+	"dur":      "duration",
+	"rate":     "rate",
+	"value":    "rate",
+	"q":        "rate",
+	"table":    "table",
+}
+
+func parseLong(header []string, rows []record) (map[int][]xtbml.RateEntryPayload, error) {
+	cols := map[string]int{}
+	for i, name := range header {
+		if canonical, ok := longColumnNames[strings.ToLower(name)]; ok {
+			if _, dup := cols[canonical]; dup {
+				return nil, fmt.Errorf("duplicate %s column in header", canonical)
+			}
+			cols[canonical] = i
+		}
+	}
+	if _, ok := cols["age"]; !ok {
+		return nil, fmt.Errorf("long layout header missing age column")
+	}
+	if _, ok := cols["rate"]; !ok {
+		return nil, fmt.Errorf("long layout header missing rate column")
+	}
+
+	tables := make(map[int][]xtbml.RateEntryPayload)
+	for _, row := range rows {
+		age, err := intCell(row, cols["age"], "age")
+		if err != nil {
+			return nil, err
+		}
+		entry := xtbml.RateEntryPayload{Age: age}
+		if idx, ok := cols["duration"]; ok && cell(row, idx) != "" {
+			dur, err := intCell(row, idx, "duration")
+			if err != nil {
+				return nil, err
+			}
+			entry.Duration = &dur
+		}
+		if entry.Rate, err = rateCell(row, cols["rate"]); err != nil {
+			return nil, err
+		}
+		table := 0
+		if idx, ok := cols["table"]; ok && cell(row, idx) != "" {
+			if table, err = intCell(row, idx, "table"); err != nil {
+				return nil, err
+			}
+		}
+		tables[table] = append(tables[table], entry)
+	}
+	return tables, nil
+}
+
+func parseMatrix(header []string, rows []record) (map[int][]xtbml.RateEntryPayload, error) {
+	if len(header) < 2 {
+		return nil, fmt.Errorf("matrix layout needs an age column and at least one duration column")
+	}
+	durations := make([]int, len(header)-1)
+	for i, name := range header[1:] {
+		dur, err := strconv.Atoi(name)
+		if err != nil {
+			return nil, fmt.Errorf("matrix header column %d: duration %q is not an integer", i+2, name)
+		}
+		durations[i] = dur
+	}
+
+	var rates []xtbml.RateEntryPayload
+	for _, row := range rows {
+		age, err := intCell(row, 0, "age")
+		if err != nil {
+			return nil, err
+		}
+		for i, dur := range durations {
+			rate, err := rateCell(row, i+1)
+			if err != nil {
+				return nil, err
+			}
+			rates = append(rates, xtbml.RateEntryPayload{Age: age, Duration: &dur, Rate: rate})
+		}
+	}
+	return map[int][]xtbml.RateEntryPayload{0: rates}, nil
+}
+
+func cell(row record, idx int) string {
+	if idx < 0 || idx >= len(row.cells) {
+		return ""
+	}
+	return row.cells[idx]
+}
+
+func intCell(row record, idx int, name string) (int, error) {
+	text := cell(row, idx)
+	val, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, fmt.Errorf("line %d: invalid %s %q", row.line, name, text)
+	}
+	return val, nil
+}
+
+func rateCell(row record, idx int) (*float64, error) {
+	text := cell(row, idx)
+	if text == "" {
+		return nil, nil
+	}
+	val, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: invalid rate %q", row.line, text)
+	}
+	return &val, nil
+}
+
+// deriveAxes builds age and duration axis definitions from the imported cells.
+func deriveAxes(rates []xtbml.RateEntryPayload) []xtbml.AxisDefinitionPayload {
+	ages := make(map[int]struct{})
+	durations := make(map[int]struct{})
+	for _, entry := range rates {
+		ages[entry.Age] = struct{}{}
+		if entry.Duration != nil {
+			durations[*entry.Duration] = struct{}{}
+		}
+	}
+	axes := []xtbml.AxisDefinitionPayload{axisFromValues("Age", xtbml.ClassifiedValuePayload{Code: "3", Label: "Age"}, ages)}
+	if len(durations) > 0 {
+		axes = append(axes, axisFromValues("Duration", xtbml.ClassifiedValuePayload{Code: "2", Label: "Ordinal Date"}, durations))
+	}
+	return axes
+}
+
+func axisFromValues(name string, scale xtbml.ClassifiedValuePayload, set map[int]struct{}) xtbml.AxisDefinitionPayload {
+	values := make([]int, 0, len(set))
+	for v := range set {
+		values = append(values, v)
+	}
+	sort.Ints(values)
+	increment := 1
+	if len(values) > 1 {
+		increment = values[1] - values[0]
+	}
+	return xtbml.AxisDefinitionPayload{
+		ID:        name,
+		ScaleType: scale,
+		AxisName:  name,
+		MinValue:  strconv.Itoa(values[0]),
+		MaxValue:  strconv.Itoa(values[len(values)-1]),
+		Increment: strconv.Itoa(increment),
+	}
+}
@@ -0,0 +1,98 @@
+package tableimport
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mort/internal/xtbml"
+)
+
+func importFixture(t *testing.T, name string, format Format) []byte {
+	t.Helper()
+	dst := filepath.Join(t.TempDir(), "out.json")
+	if err := ImportFile(filepath.Join("testdata", name), dst, format, xtbml.ConvertOptions{}); err != nil {
+		t.Fatalf("ImportFile(%s) error = %v", name, err)
+	}
+	raw, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	return raw
+}
+
+func validateAgainstSchema(t *testing.T, raw []byte) {
+	t.Helper()
+	if err := xtbml.ValidateJSON(raw); err != nil {
+		t.Fatalf("schema validation failed: %v\n%s", err, raw)
+	}
+}
+
+func TestImportLongCSV(t *testing.T) {
+	raw := importFixture(t, "select_long.csv", FormatCSV)
+	validateAgainstSchema(t, raw)
+
+	var table struct {
+		Identifier string `json:"identifier"`
+		Tables     []struct {
+			Metadata struct {
+				TableDescription string `json:"tableDescription"`
+				Axes             []struct {
+					ID       string `json:"id"`
+					MaxValue string `json:"maxValue"`
+				} `json:"axes"`
+			} `json:"metadata"`
+			Rates []struct {
+				Age      int      `json:"age"`
+				Duration *int     `json:"duration"`
+				Rate     *float64 `json:"rate"`
+			} `json:"rates"`
+		} `json:"tables"`
+	}
+	if err := json.Unmarshal(raw, &table); err != nil {
+		t.Fatalf("decode output: %v", err)
+	}
+	if table.Identifier != "legacy_select_table" || len(table.Tables) != 1 {
+		t.Fatalf("unexpected table: %s", raw)
+	}
+	got := table.Tables[0]
+	if got.Metadata.TableDescription != "Select rates" || len(got.Metadata.Axes) != 2 || got.Metadata.Axes[1].MaxValue != "2" {
+		t.Fatalf("metadata mismatch: %#v", got.Metadata)
+	}
+	if len(got.Rates) != 4 || *got.Rates[1].Duration != 2 || *got.Rates[1].Rate != 0.0012 || got.Rates[3].Rate != nil {
+		t.Fatalf("rates mismatch: %s", raw)
+	}
+}
+
+func TestImportMatrixCSV(t *testing.T) {
+	raw := importFixture(t, "ultimate_matrix.csv", FormatCSV)
+	validateAgainstSchema(t, raw)
+	if !strings.Contains(string(raw), `"age": 51,`) || strings.Count(string(raw), `"age"`) != 6 {
+		t.Fatalf("matrix rates mismatch: %s", raw)
+	}
+}
+
+func TestImportFixedWidth(t *testing.T) {
+	raw := importFixture(t, "fixed_columns.txt", FormatFixedWidth)
+	validateAgainstSchema(t, raw)
+	if !strings.Contains(string(raw), `"rate": 0.0055`) || !strings.Contains(string(raw), `"rate": null`) {
+		t.Fatalf("fixed-width rates mismatch: %s", raw)
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	sidecar := &Sidecar{Classification: xtbml.ClassificationPayload{TableName: "Broken"}}
+	cases := map[string]string{
+		"bad rate":        "age,rate\n40,abc\n",
+		"missing age":     "duration,rate\n1,0.1\n",
+		"bad matrix head": "age,one\n40,0.1\n",
+		"no rows":         "age,rate\n",
+	}
+	for name, input := range cases {
+		if _, err := Import(strings.NewReader(input), FormatCSV, sidecar); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
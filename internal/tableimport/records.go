@@ -0,0 +1,161 @@
+package tableimport
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Format identifies the legacy file format being imported.
+type Format string
+
+const (
+	FormatCSV        Format = "csv"
+	FormatFixedWidth Format = "fixed"
+)
+
+// ParseFormat maps a CLI format name to a Format.
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "csv":
+		return FormatCSV, nil
+	case "fixed", "fixed-width", "fw":
+		return FormatFixedWidth, nil
+	default:
+		return "", fmt.Errorf("unknown import format %q", name)
+	}
+}
+
+// Extensions lists the file extensions handled by the format.
+func (f Format) Extensions() []string {
+	if f == FormatFixedWidth {
+		return []string{".txt", ".dat"}
+	}
+	return []string{".csv"}
+}
+
+type record struct {
+	line  int
+	cells []string
+}
+
+// readRecords returns the header and data rows of a legacy file.
+func readRecords(r io.Reader, format Format, columns []ColumnSpec) ([]string, []record, error) {
+	switch format {
+	case FormatCSV:
+		return readCSV(r)
+	case FormatFixedWidth:
+		return readFixedWidth(r, columns)
+	default:
+		return nil, nil, fmt.Errorf("unknown import format %q", format)
+	}
+}
+
+func readCSV(r io.Reader) ([]string, []record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	var (
+		header []string
+		rows   []record
+	)
+	for {
+		cells, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("read csv: %w", err)
+		}
+		if blankRecord(cells) {
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+		if header == nil {
+			header = trimAll(cells)
+			continue
+		}
+		rows = append(rows, record{line: line, cells: trimAll(cells)})
+	}
+	if header == nil {
+		return nil, nil, fmt.Errorf("read csv: missing header row")
+	}
+	return header, rows, nil
+}
+
+func readFixedWidth(r io.Reader, columns []ColumnSpec) ([]string, []record, error) {
+	var (
+		header []string
+		rows   []record
+	)
+	if len(columns) > 0 {
+		header = make([]string, len(columns))
+		for i, col := range columns {
+			header[i] = col.Name
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(strings.TrimSpace(text), "#") {
+			continue
+		}
+		if len(columns) == 0 {
+			if header == nil {
+				header = strings.Fields(text)
+				continue
+			}
+			rows = append(rows, record{line: line, cells: strings.Fields(text)})
+			continue
+		}
+		cells := make([]string, len(columns))
+		for i, col := range columns {
+			cells[i] = sliceColumn(text, col)
+		}
+		rows = append(rows, record{line: line, cells: cells})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("read fixed-width: %w", err)
+	}
+	if header == nil {
+		return nil, nil, fmt.Errorf("read fixed-width: missing header line")
+	}
+	return header, rows, nil
+}
+
+func sliceColumn(text string, col ColumnSpec) string {
+	if col.Start >= len(text) {
+		return ""
+	}
+	end := col.Start + col.Width
+	if end > len(text) {
+		end = len(text)
+	}
+	return strings.TrimSpace(text[col.Start:end])
+}
+
+func blankRecord(cells []string) bool {
+	for _, cell := range cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func trimAll(cells []string) []string {
+	out := make([]string, len(cells))
+	for i, cell := range cells {
+		out[i] = strings.TrimSpace(cell)
+	}
+	return out
+}
@@ -0,0 +1,73 @@
+package tableimport
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"mort/internal/xtbml"
+)
+
+// Layout describes how rates are arranged in a legacy file.
+type Layout string
+
+const (
+	// LayoutLong has one rate per row with age, optional duration and rate columns.
+	LayoutLong Layout = "long"
+	// LayoutMatrix has one row per age and one column per duration.
+	LayoutMatrix Layout = "matrix"
+)
+
+// Sidecar carries the table metadata that legacy rate files cannot express.
+// It is read from <name>.meta.json next to the data file.
+type Sidecar struct {
+	Identifier     string                      `json:"identifier,omitempty"`
+	Version        string                      `json:"version,omitempty"`
+	Layout         Layout                      `json:"layout,omitempty"`
+	Classification xtbml.ClassificationPayload `json:"classification"`
+	// Tables holds metadata for each table index found in the data file.
+	Tables []xtbml.TableMetaPayload `json:"tables,omitempty"`
+	// Columns describes fixed-width record positions. When empty, fixed-width
+	// files are split on whitespace and the first line is the header.
+	Columns []ColumnSpec `json:"columns,omitempty"`
+}
+
+// ColumnSpec locates a field within a fixed-width record. Start is zero-based.
+type ColumnSpec struct {
+	Name  string `json:"name"`
+	Start int    `json:"start"`
+	Width int    `json:"width"`
+}
+
+// SidecarPath returns the metadata path that accompanies dataPath.
+func SidecarPath(dataPath string) string {
+	return strings.TrimSuffix(dataPath, filepath.Ext(dataPath)) + ".meta.json"
+}
+
+// LoadSidecar reads and checks a sidecar metadata file.
+func LoadSidecar(path string) (*Sidecar, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	var sidecar Sidecar
+	if err := json.Unmarshal(raw, &sidecar); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+	if strings.TrimSpace(sidecar.Classification.TableName) == "" {
+		return nil, fmt.Errorf("%s: classification missing table name", path)
+	}
+	switch sidecar.Layout {
+	case "", LayoutLong, LayoutMatrix:
+	default:
+		return nil, fmt.Errorf("%s: unknown layout %q", path, sidecar.Layout)
+	}
+	for _, col := range sidecar.Columns {
+		if col.Start < 0 || col.Width <= 0 {
+			return nil, fmt.Errorf("%s: invalid column %q", path, col.Name)
+		}
+	}
+	return &sidecar, nil
+}
@@ -0,0 +1,83 @@
+package tuiapp
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"mort/internal/xtbml"
+)
+
+// syntheticPayload returns a select table payload with ages x durations rates
+// at the given schema version. Version 1 payloads have no schemaVersion field
+// and keep the source label of their content type.
+func syntheticPayload(b *testing.B, id, ages, durations, version int) []byte {
+	b.Helper()
+	table := &xtbml.ConvertedTable{
+		SchemaVersion: xtbml.CurrentSchemaVersion,
+		Identifier:    fmt.Sprintf("synthetic_%d", id),
+		Version:       "unknown",
+		Classification: &xtbml.ClassificationPayload{
+			TableIdentity:    strconv.Itoa(id),
+			ProviderName:     "Benchmark",
+			ContentType:      xtbml.ClassifiedValuePayload{Code: "85", Label: "CSO/CET"},
+			TableName:        fmt.Sprintf("Synthetic Select Table %d", id),
+			TableDescription: "Synthetic select and ultimate rates",
+			Keywords:         []string{"Aggregate"},
+		},
+	}
+	tp := xtbml.TablePayload{Index: 0, Metadata: &xtbml.TableMetaPayload{ScalingFactor: "0"}}
+	for age := range ages {
+		for d := 1; d <= durations; d++ {
+			duration, rate := d, 0.0005+float64(age)*0.00123+float64(d)*0.0001
+			tp.Rates = append(tp.Rates, xtbml.RateEntryPayload{Age: age, Duration: &duration, Rate: &rate})
+		}
+	}
+	table.Tables = []xtbml.TablePayload{tp}
+	raw, err := xtbml.EncodeTable(table)
+	if err != nil {
+		b.Fatal(err)
+	}
+	current := []byte(`"schemaVersion": ` + strconv.Itoa(xtbml.CurrentSchemaVersion))
+	switch {
+	case version == 1:
+		raw = bytes.Replace(raw, append(current, ",\n  "...), nil, 1)
+		raw = bytes.Replace(raw, []byte(`"label": "CSO/CET"`), []byte(`"label": "CSO / CET"`), 1)
+	case version != xtbml.CurrentSchemaVersion:
+		raw = bytes.Replace(raw, current, []byte(`"schemaVersion": `+strconv.Itoa(version)), 1)
+	}
+	return raw
+}
+
+// BenchmarkLoadTableSummaries lists a directory of 100 select tables, both in
+// the current layout and as version 1 payloads, which predate the
+// schemaVersion field and are upgraded through every migration on load.
+func BenchmarkLoadTableSummaries(b *testing.B) {
+	for _, version := range []int{xtbml.CurrentSchemaVersion, 1} {
+		b.Run(fmt.Sprintf("v%d", version), func(b *testing.B) {
+			dir := b.TempDir()
+			var total int64
+			for id := range 100 {
+				raw := syntheticPayload(b, id+1, 100, 25, version)
+				if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("t%d.json", id+1)), raw, 0o644); err != nil {
+					b.Fatal(err)
+				}
+				total += int64(len(raw))
+			}
+			b.SetBytes(total)
+			b.ReportAllocs()
+			for b.Loop() {
+				summaries, err := LoadTableSummaries(dir)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(summaries) != 100 {
+					b.Fatalf("expected 100 summaries, got %d", len(summaries))
+				}
+			}
+		})
+	}
+}
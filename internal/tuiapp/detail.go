@@ -3,7 +3,6 @@ package tuiapp
 import (
 	"encoding/json"
 	"fmt"
-	"os"
 
 	"mort/internal/xtbml"
 )
@@ -13,9 +12,14 @@ type TableDetail = xtbml.ConvertedTable
 
 // LoadTableDetail reads a single JSON file into a TableDetail structure.
 func LoadTableDetail(path string) (*TableDetail, error) {
-	raw, err := os.ReadFile(path)
+	return LoadTableDetailWithOptions(path, LoadOptions{})
+}
+
+// LoadTableDetailWithOptions mirrors LoadTableDetail and applies opts.
+func LoadTableDetailWithOptions(path string, opts LoadOptions) (*TableDetail, error) {
+	raw, err := readTableFile(path, opts, false)
 	if err != nil {
-		return nil, fmt.Errorf("read %s: %w", path, err)
+		return nil, err
 	}
 	var detail xtbml.ConvertedTable
 	if err := json.Unmarshal(raw, &detail); err != nil {
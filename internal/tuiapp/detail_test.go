@@ -1,7 +1,9 @@
 package tuiapp
 
 import (
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -18,3 +20,26 @@ func TestLoadTableDetail(t *testing.T) {
 		t.Fatalf("expected rates in detail: %#v", detail.Tables)
 	}
 }
+
+func TestLoadTableDetailValidation(t *testing.T) {
+	path := filepath.Join("testdata", "json", "table_alpha.json")
+	if _, err := LoadTableDetailWithOptions(path, LoadOptions{Validate: true}); err != nil {
+		t.Fatalf("valid fixture rejected: %v", err)
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.json")
+	raw := `{"identifier":"bad","version":"1","classification":{"tableName":"Bad"},"tables":[{"index":0,"rates":[{"rate":0.1}]}]}`
+	if err := os.WriteFile(bad, []byte(raw), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	if _, err := LoadTableDetail(bad); err != nil {
+		t.Fatalf("unvalidated load should succeed: %v", err)
+	}
+	_, err := LoadTableDetailWithOptions(bad, LoadOptions{Validate: true})
+	if err == nil || !strings.Contains(err.Error(), "/tables/0/rates/0") {
+		t.Fatalf("expected pointer to offending rate, got %v", err)
+	}
+	if _, err := LoadTableSummaryWithOptions(bad, LoadOptions{Validate: true}); err == nil {
+		t.Fatalf("expected summary validation error")
+	}
+}
@@ -2,6 +2,7 @@ package tuiapp
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -10,8 +11,16 @@ import (
 	"strings"
 
 	"github.com/lithammer/fuzzysearch/fuzzy"
+
+	"mort/internal/xtbml"
 )
 
+// LoadOptions controls how converted JSON files are read.
+type LoadOptions struct {
+	// Validate checks every file against the JSON schema before decoding it.
+	Validate bool
+}
+
 // TableSummary is the minimal data needed to list and search tables.
 type TableSummary struct {
 	TableIdentity string
@@ -19,8 +28,11 @@ type TableSummary struct {
 	Name          string
 	Provider      string
 	Summary       string
-	FilePath      string
-	Keywords      []string
+	// ContentType is the canonical content type label, so searching by it
+	// finds every table of that type whatever its source label.
+	ContentType string
+	FilePath    string
+	Keywords    []string
 }
 
 type convertedTable struct {
@@ -31,11 +43,20 @@ type convertedTable struct {
 		ProviderName     string   `json:"providerName"`
 		TableDescription string   `json:"tableDescription"`
 		Keywords         []string `json:"keywords"`
+		ContentType      struct {
+			Label string `json:"label"`
+		} `json:"contentType"`
 	} `json:"classification"`
 }
 
 // LoadTableSummaries reads all *.json files in dir and returns sorted summaries.
+// JSON files that are not table payloads, such as sync state, are skipped.
 func LoadTableSummaries(dir string) ([]TableSummary, error) {
+	return LoadTableSummariesWithOptions(dir, LoadOptions{})
+}
+
+// LoadTableSummariesWithOptions mirrors LoadTableSummaries and applies opts to every file.
+func LoadTableSummariesWithOptions(dir string, opts LoadOptions) ([]TableSummary, error) {
 	files, err := os.ReadDir(dir)
 	if err != nil {
 		return nil, fmt.Errorf("read json dir: %w", err)
@@ -47,7 +68,10 @@ func LoadTableSummaries(dir string) ([]TableSummary, error) {
 			continue
 		}
 		path := filepath.Join(dir, file.Name())
-		summary, err := LoadTableSummary(path)
+		summary, err := LoadTableSummaryWithOptions(path, opts)
+		if errors.Is(err, xtbml.ErrNotTable) {
+			continue
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -77,9 +101,14 @@ func LoadTableSummaries(dir string) ([]TableSummary, error) {
 
 // LoadTableSummary loads a single table summary from a JSON file.
 func LoadTableSummary(path string) (*TableSummary, error) {
-	raw, err := os.ReadFile(path)
+	return LoadTableSummaryWithOptions(path, LoadOptions{})
+}
+
+// LoadTableSummaryWithOptions mirrors LoadTableSummary and applies opts.
+func LoadTableSummaryWithOptions(path string, opts LoadOptions) (*TableSummary, error) {
+	raw, err := readTableFile(path, opts, true)
 	if err != nil {
-		return nil, fmt.Errorf("read %s: %w", path, err)
+		return nil, err
 	}
 	var ct convertedTable
 	if err := json.Unmarshal(raw, &ct); err != nil {
@@ -91,11 +120,81 @@ func LoadTableSummary(path string) (*TableSummary, error) {
 		Name:          ct.Classification.TableName,
 		Provider:      ct.Classification.ProviderName,
 		Summary:       ct.Classification.TableDescription,
+		ContentType:   ct.Classification.ContentType.Label,
 		FilePath:      path,
 		Keywords:      ct.Classification.Keywords,
 	}, nil
 }
 
+// readTableFile reads a payload, upgrading older schema versions in memory
+// before it is validated or decoded. With headerOnly, unvalidated payloads
+// are upgraded without their rates, which summaries never read.
+func readTableFile(path string, opts LoadOptions, headerOnly bool) ([]byte, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	if headerOnly && !opts.Validate {
+		raw, err = xtbml.MigrateHeaderJSON(raw)
+	} else {
+		raw, _, err = xtbml.MigrateJSON(raw)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+	if opts.Validate {
+		if err := xtbml.ValidateJSON(raw); err != nil {
+			return nil, fmt.Errorf("validate %s: %w", path, err)
+		}
+	}
+	return raw, nil
+}
+
+// ResolveTablePath locates the JSON file for ref, which may be a file path, a
+// normalized identifier, a table identity or a file name inside dir.
+func ResolveTablePath(dir, ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return "", fmt.Errorf("empty table reference")
+	}
+	if info, err := os.Stat(ref); err == nil && !info.IsDir() {
+		return ref, nil
+	}
+
+	summaries, err := LoadTableSummaries(dir)
+	if err != nil {
+		return "", err
+	}
+	base := strings.TrimSuffix(ref, ".json")
+	var matches []string
+	for _, summary := range summaries {
+		name := strings.TrimSuffix(filepath.Base(summary.FilePath), ".json")
+		if summary.Identifier == ref || summary.TableIdentity == ref || name == base {
+			matches = append(matches, summary.FilePath)
+		}
+	}
+	if len(matches) == 0 {
+		// Identifiers renamed to resolve a collision keep an alias.
+		if aliases, err := xtbml.LoadAliases(dir); err == nil {
+			if id := aliases.Resolve(ref); id != ref {
+				for _, summary := range summaries {
+					if summary.Identifier == id {
+						matches = append(matches, summary.FilePath)
+					}
+				}
+			}
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no table matching %q in %s", ref, dir)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("table reference %q is ambiguous: %s", ref, strings.Join(matches, ", "))
+	}
+}
+
 // FilterSummaries performs a fuzzy search over names and identifiers.
 func FilterSummaries(items []TableSummary, query string) []TableSummary {
 	query = strings.TrimSpace(query)
@@ -118,6 +217,7 @@ func FilterSummaries(items []TableSummary, query string) []TableSummary {
 			item.TableIdentity,
 			item.Provider,
 			item.Summary,
+			item.ContentType,
 			extra,
 		}, " "))
 
@@ -1,8 +1,11 @@
 package tuiapp
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
+
+	"mort/internal/xtbml"
 )
 
 func TestLoadTableSummaries(t *testing.T) {
@@ -26,7 +29,7 @@ func TestFilterSummaries(t *testing.T) {
 	items := []TableSummary{
 		{Name: "2015 VBT Table", Identifier: "table_alpha", TableIdentity: "alpha", Provider: "Provider A", Keywords: []string{"2015", "vbt"}},
 		{Name: "Beta Table", Identifier: "table_beta", TableIdentity: "beta"},
-		{Name: "2015 Experience Study", Identifier: "table_gamma", TableIdentity: "gamma", Provider: "Provider C"},
+		{Name: "2015 Experience Study", Identifier: "table_gamma", TableIdentity: "gamma", Provider: "Provider C", ContentType: "CSO/CET"},
 	}
 
 	filtered := FilterSummaries(items, "beta")
@@ -39,8 +42,52 @@ func TestFilterSummaries(t *testing.T) {
 		t.Fatalf("FilterSummaries() multi-token match failed: %#v", filtered)
 	}
 
+	filtered = FilterSummaries(items, "cso/cet")
+	if len(filtered) != 1 || filtered[0].Identifier != "table_gamma" {
+		t.Fatalf("FilterSummaries() content type match failed: %#v", filtered)
+	}
+
 	filtered = FilterSummaries(items, "")
 	if len(filtered) != len(items) {
 		t.Fatalf("empty query should return all")
 	}
 }
+
+func TestResolveTablePath(t *testing.T) {
+	dir := filepath.Join("testdata", "json")
+	want := filepath.Join(dir, "table_beta.json")
+	for _, ref := range []string{"table_beta", "beta", "table_beta.json", want} {
+		got, err := ResolveTablePath(dir, ref)
+		if err != nil {
+			t.Fatalf("ResolveTablePath(%q) error = %v", ref, err)
+		}
+		if got != want {
+			t.Fatalf("ResolveTablePath(%q) = %q, want %q", ref, got, want)
+		}
+	}
+	if _, err := ResolveTablePath(dir, "gamma"); err == nil {
+		t.Fatalf("expected error for unknown reference")
+	}
+}
+
+func TestResolveTablePathFollowsAliases(t *testing.T) {
+	dir := t.TempDir()
+	data, err := os.ReadFile(filepath.Join("testdata", "json", "table_beta.json"))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	want := filepath.Join(dir, "table_beta.json")
+	if err := os.WriteFile(want, data, 0o644); err != nil {
+		t.Fatalf("write table: %v", err)
+	}
+	if err := (xtbml.Aliases{"old_beta": "table_beta"}).Save(dir); err != nil {
+		t.Fatalf("save aliases: %v", err)
+	}
+	got, err := ResolveTablePath(dir, "old_beta")
+	if err != nil {
+		t.Fatalf("ResolveTablePath() error = %v", err)
+	}
+	if got != want {
+		t.Fatalf("ResolveTablePath() = %q, want %q", got, want)
+	}
+}
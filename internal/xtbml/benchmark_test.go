@@ -0,0 +1,252 @@
+package xtbml
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// corpusDir is the library of published tables, read by the benchmarks that
+// measure real files. They are skipped when it is missing.
+const corpusDir = "../../xml"
+
+// largestCorpusFiles returns the n largest XML files in the corpus.
+func largestCorpusFiles(b *testing.B, n int) []string {
+	b.Helper()
+	entries, err := os.ReadDir(corpusDir)
+	if err != nil {
+		b.Skipf("corpus not available: %v", err)
+	}
+	type sized struct {
+		name string
+		size int64
+	}
+	var files []sized
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) != ".xml" {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			files = append(files, sized{entry.Name(), info.Size()})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].size > files[j].size })
+	var names []string
+	for _, f := range files[:min(n, len(files))] {
+		names = append(names, f.name)
+	}
+	return names
+}
+
+// BenchmarkConvertLargest compares the in-memory converter with ConvertStream
+// on the largest published tables. Compare B/op for the memory saving:
+//
+//	go test ./internal/xtbml -run '^$' -bench ConvertLargest -benchmem
+func BenchmarkConvertLargest(b *testing.B) {
+	for _, name := range largestCorpusFiles(b, 3) {
+		data, err := os.ReadFile(filepath.Join(corpusDir, name))
+		if err != nil {
+			b.Fatalf("read %s: %v", name, err)
+		}
+		b.Run("memory/"+name, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := ConvertXTbmlWithOptions(bytes.NewReader(data), ConvertOptions{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run("stream/"+name, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := ConvertStream(bytes.NewReader(data), io.Discard, ConvertOptions{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// syntheticXTbML builds an XTbML document of tables rate tables covering ages
+// 0 to ages-1. With durations above zero each age is a nested select axis of
+// that many durations, the shape of generational and select-and-ultimate
+// tables.
+func syntheticXTbML(tables, ages, durations int) []byte {
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="utf-8"?>` + "\n<XTbML>\n")
+	b.WriteString("  <ContentClassification>\n    <TableIdentity>9001</TableIdentity>\n    <ProviderName>Benchmark</ProviderName>\n")
+	b.WriteString("    <ContentType tc=\"85\">CSO / CET</ContentType>\n    <TableName>Synthetic Select Table</TableName>\n")
+	b.WriteString("    <KeyWord>Aggregate</KeyWord>\n  </ContentClassification>\n")
+	for t := 0; t < tables; t++ {
+		b.WriteString("  <Table>\n    <MetaData>\n      <ScalingFactor>0</ScalingFactor>\n")
+		b.WriteString("      <DataType tc=\"2\">Floating Point</DataType>\n      <Nation tc=\"1\">United States of America</Nation>\n")
+		fmt.Fprintf(&b, "      <AxisDef id=\"Age\">\n        <ScaleType tc=\"3\">Age</ScaleType>\n        <AxisName>Age</AxisName>\n        <MinScaleValue>0</MinScaleValue>\n        <MaxScaleValue>%d</MaxScaleValue>\n        <Increment>1</Increment>\n      </AxisDef>\n", ages-1)
+		if durations > 0 {
+			fmt.Fprintf(&b, "      <AxisDef id=\"Duration\">\n        <ScaleType tc=\"4\">Duration</ScaleType>\n        <AxisName>Duration</AxisName>\n        <MinScaleValue>1</MinScaleValue>\n        <MaxScaleValue>%d</MaxScaleValue>\n        <Increment>1</Increment>\n      </AxisDef>\n", durations)
+		}
+		b.WriteString("    </MetaData>\n    <Values>\n")
+		if durations == 0 {
+			b.WriteString("      <Axis>\n")
+		}
+		for age := 0; age < ages; age++ {
+			if durations == 0 {
+				fmt.Fprintf(&b, "        <Y t=\"%d\">%.6f</Y>\n", age, 0.0005+float64(age)*0.00123)
+				continue
+			}
+			fmt.Fprintf(&b, "      <Axis t=\"%d\">\n", age)
+			for d := 1; d <= durations; d++ {
+				fmt.Fprintf(&b, "        <Y t=\"%d\">%.6f</Y>\n", d, 0.0005+float64(age)*0.00123+float64(d)*0.0001)
+			}
+			b.WriteString("      </Axis>\n")
+		}
+		if durations == 0 {
+			b.WriteString("      </Axis>\n")
+		}
+		b.WriteString("    </Values>\n  </Table>\n")
+	}
+	b.WriteString("</XTbML>\n")
+	return b.Bytes()
+}
+
+// syntheticFixtures range from a small ultimate table to a multi-table select
+// document of the size of the largest published tables.
+var syntheticFixtures = []struct {
+	name                    string
+	tables, ages, durations int
+}{
+	{"ultimate_1x121", 1, 121, 0},
+	{"select_1x100x25", 1, 100, 25},
+	{"select_8x121x25", 8, 121, 25},
+}
+
+func benchmarkFixtures(b *testing.B, run func(b *testing.B, data []byte)) {
+	for _, fx := range syntheticFixtures {
+		data := syntheticXTbML(fx.tables, fx.ages, fx.durations)
+		b.Run(fx.name, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			run(b, data)
+		})
+	}
+}
+
+func BenchmarkParseDocument(b *testing.B) {
+	benchmarkFixtures(b, func(b *testing.B, data []byte) {
+		for b.Loop() {
+			if _, err := parseDocument(data, ParseLenient); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkParseRates(b *testing.B) {
+	benchmarkFixtures(b, func(b *testing.B, data []byte) {
+		for b.Loop() {
+			if _, err := ParseRates(bytes.NewReader(data)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkConvertFromBytes(b *testing.B) {
+	for _, layout := range []struct {
+		name string
+		opts ConvertOptions
+	}{{"plain", ConvertOptions{}}, {"canonical", ConvertOptions{Canonical: true}}} {
+		b.Run(layout.name, func(b *testing.B) {
+			benchmarkFixtures(b, func(b *testing.B, data []byte) {
+				for b.Loop() {
+					if _, _, err := convertFromBytes(data, layout.opts); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkConvertCorpus converts every table in the library in memory,
+// leaving out file I/O, identifier assignment and schema validation. One
+// iteration is enough:
+//
+//	go test ./internal/xtbml -run '^$' -bench ConvertCorpus -benchtime 1x
+//
+// The budget is the whole library in under 6 seconds on one core, at least
+// 12 MB/s.
+func BenchmarkConvertCorpus(b *testing.B) {
+	entries, err := os.ReadDir(corpusDir)
+	if err != nil {
+		b.Skipf("corpus not available: %v", err)
+	}
+	var docs [][]byte
+	var total int64
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) != ".xml" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(corpusDir, entry.Name()))
+		if err != nil {
+			b.Fatal(err)
+		}
+		docs = append(docs, data)
+		total += int64(len(data))
+	}
+	b.SetBytes(total)
+	b.ReportAllocs()
+	for b.Loop() {
+		for _, data := range docs {
+			// A few published files are not well-formed XML; they fail the
+			// same way on every run.
+			_, _, _ = convertFromBytes(data, ConvertOptions{})
+		}
+	}
+}
+
+// BenchmarkConvertDirectory measures xtbmlconvert itself: ConvertDirectoryReport
+// over a copy of the library, including the identifier scan, reading every
+// file and writing its JSON. Tables that do not convert are left out of the
+// copy, since the first failure stops a directory conversion.
+//
+//	go test ./internal/xtbml -run '^$' -bench ConvertDirectory -benchtime 1x
+//
+// The budget is the whole library in under 10 seconds on one core.
+func BenchmarkConvertDirectory(b *testing.B) {
+	entries, err := os.ReadDir(corpusDir)
+	if err != nil {
+		b.Skipf("corpus not available: %v", err)
+	}
+	src, dst := b.TempDir(), b.TempDir()
+	opts := ConvertOptions{Mode: ParseLenient}
+	var total int64
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) != ".xml" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(corpusDir, entry.Name()))
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, _, err := convertFromBytes(data, opts); err != nil {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(src, entry.Name()), data, 0o644); err != nil {
+			b.Fatal(err)
+		}
+		total += int64(len(data))
+	}
+	b.SetBytes(total)
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := ConvertDirectoryReport(src, dst, opts, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
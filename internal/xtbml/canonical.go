@@ -0,0 +1,169 @@
+package xtbml
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// EncodeCanonical serializes a ConvertedTable in the canonical layout used for
+// committed JSON: keys in schema order, fixed-point floats (never exponents),
+// flat objects and scalar arrays kept on one line, and a trailing newline.
+// The same table always produces the same bytes.
+func EncodeCanonical(table *ConvertedTable) ([]byte, error) {
+	raw, err := EncodeTable(table)
+	if err != nil {
+		return nil, err
+	}
+	return CanonicalizeJSON(raw)
+}
+
+// CanonicalizeJSON rewrites any JSON document into the canonical layout while
+// preserving its key order.
+func CanonicalizeJSON(raw []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	root, err := readJSONNode(dec)
+	if err != nil {
+		return nil, fmt.Errorf("canonicalize json: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("canonicalize json: trailing data")
+	}
+	var buf bytes.Buffer
+	root.write(&buf, 0)
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// jsonNode is an order-preserving JSON tree.
+type jsonNode struct {
+	kind   json.Delim // '{' or '[' for containers, 0 for scalars
+	keys   []string
+	items  []*jsonNode
+	scalar string
+}
+
+func readJSONNode(dec *json.Decoder) (*jsonNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		node := &jsonNode{kind: t}
+		for dec.More() {
+			if t == '{' {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				node.keys = append(node.keys, keyTok.(string))
+			}
+			child, err := readJSONNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			node.items = append(node.items, child)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case json.Number:
+		return &jsonNode{scalar: canonicalNumber(t)}, nil
+	case string:
+		return &jsonNode{scalar: canonicalString(t)}, nil
+	case bool:
+		return &jsonNode{scalar: strconv.FormatBool(t)}, nil
+	case nil:
+		return &jsonNode{scalar: "null"}, nil
+	default:
+		return nil, fmt.Errorf("unexpected token %v", tok)
+	}
+}
+
+// canonicalNumber keeps integers verbatim and prints every other number in
+// shortest fixed-point form so 1e-05 and 0.00001 cannot both appear.
+func canonicalNumber(n json.Number) string {
+	s := n.String()
+	if !strings.ContainsAny(s, ".eE") {
+		return s
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return s
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func canonicalString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func (n *jsonNode) flat() bool {
+	for _, item := range n.items {
+		if item.kind != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (n *jsonNode) write(buf *bytes.Buffer, depth int) {
+	if n.kind == 0 {
+		buf.WriteString(n.scalar)
+		return
+	}
+	openDelim, closeDelim := "{", "}"
+	if n.kind == '[' {
+		openDelim, closeDelim = "[", "]"
+	}
+	if len(n.items) == 0 {
+		buf.WriteString(openDelim + closeDelim)
+		return
+	}
+
+	if n.flat() {
+		buf.WriteString(openDelim)
+		if n.kind == '{' {
+			buf.WriteByte(' ')
+		}
+		for i, item := range n.items {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			if n.kind == '{' {
+				buf.WriteString(canonicalString(n.keys[i]) + ": ")
+			}
+			buf.WriteString(item.scalar)
+		}
+		if n.kind == '{' {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(closeDelim)
+		return
+	}
+
+	indent := strings.Repeat("  ", depth+1)
+	buf.WriteString(openDelim + "\n")
+	for i, item := range n.items {
+		buf.WriteString(indent)
+		if n.kind == '{' {
+			buf.WriteString(canonicalString(n.keys[i]) + ": ")
+		}
+		item.write(buf, depth+1)
+		if i < len(n.items)-1 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('\n')
+	}
+	buf.WriteString(strings.Repeat("  ", depth) + closeDelim)
+}
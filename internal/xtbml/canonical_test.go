@@ -0,0 +1,44 @@
+package xtbml
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCanonicalizeJSON(t *testing.T) {
+	raw := `{"z":1,"a":{"rate":1e-05,"big":1.5E+3,"n":12},"list":[1,2.50],"nested":[{"k":"<v>"}],"empty":{},"none":[]}`
+	got, err := CanonicalizeJSON([]byte(raw))
+	if err != nil {
+		t.Fatalf("CanonicalizeJSON() error = %v", err)
+	}
+	want := strings.Join([]string{
+		`{`,
+		`  "z": 1,`,
+		`  "a": { "rate": 0.00001, "big": 1500, "n": 12 },`,
+		`  "list": [1, 2.5],`,
+		`  "nested": [`,
+		`    { "k": "<v>" }`,
+		`  ],`,
+		`  "empty": {},`,
+		`  "none": []`,
+		`}`,
+		``,
+	}, "\n")
+	if string(got) != want {
+		t.Fatalf("CanonicalizeJSON() mismatch:\n%s", lineDiff(want, string(got)))
+	}
+
+	again, err := CanonicalizeJSON(got)
+	if err != nil {
+		t.Fatalf("CanonicalizeJSON() second pass error = %v", err)
+	}
+	if string(again) != string(got) {
+		t.Fatalf("CanonicalizeJSON() is not idempotent:\n%s", lineDiff(string(got), string(again)))
+	}
+}
+
+func TestCanonicalizeJSONRejectsTrailingData(t *testing.T) {
+	if _, err := CanonicalizeJSON([]byte(`{} {}`)); err == nil {
+		t.Fatal("CanonicalizeJSON() error = nil, want trailing data error")
+	}
+}
@@ -8,22 +8,83 @@ import (
 	"sort"
 )
 
+// ConvertOptions tunes the conversion pipeline. The zero value reproduces
+// ConvertXTbml.
+type ConvertOptions struct {
+	// Canonical writes output with EncodeCanonical instead of EncodeTable.
+	Canonical bool
+	// Identifier replaces the identifier derived from the table name, as
+	// assigned by AssignIdentifiers.
+	Identifier string
+	// Mode selects strict or lenient parsing; the zero value is lenient.
+	Mode ParseMode
+	// Extensions keeps unmodelled XTbML elements and attributes in the
+	// output; see CaptureExtensions.
+	Extensions bool
+	// Stream converts files with ConvertStream, in bounded memory and
+	// without schema validation.
+	Stream bool
+	// Validate checks each converted file against the JSON schema before it
+	// is written. Stream ignores it.
+	Validate bool
+}
+
 // ConvertXTbml reads an XTbML XML payload and returns normalized JSON bytes.
 func ConvertXTbml(r io.Reader) ([]byte, error) {
+	return ConvertXTbmlWithOptions(r, ConvertOptions{})
+}
+
+// ConvertXTbmlWithOptions mirrors ConvertXTbml and applies opts.
+func ConvertXTbmlWithOptions(r io.Reader, opts ConvertOptions) ([]byte, error) {
 	data, err := io.ReadAll(r)
 	if err != nil {
 		return nil, fmt.Errorf("read input: %w", err)
 	}
-	return convertFromBytes(data)
+	out, _, err := convertFromBytes(data, opts)
+	return out, err
+}
+
+func convertFromBytes(data []byte, opts ConvertOptions) ([]byte, []Anomaly, error) {
+	_, out, warnings, err := convertPayload(data, opts)
+	return out, warnings, err
+}
+
+// convertPayload mirrors convertFromBytes and also returns the payload it
+// encoded.
+func convertPayload(data []byte, opts ConvertOptions) (*ConvertedTable, []byte, []Anomaly, error) {
+	payload, warnings, err := ParseTableMode(data, opts.Mode)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if opts.Identifier != "" {
+		payload.Identifier = opts.Identifier
+	}
+	if opts.Extensions {
+		if err := CaptureExtensions(data, payload); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	out, err := EncodeWithOptions(payload, opts)
+	return payload, out, warnings, err
+}
+
+// ParseTable parses an XTbML payload into the converter's table structure
+// without encoding it. Anomalies are handled leniently and not reported.
+func ParseTable(data []byte) (*ConvertedTable, error) {
+	payload, _, err := ParseTableMode(data, ParseLenient)
+	return payload, err
 }
 
-func convertFromBytes(data []byte) ([]byte, error) {
-	doc, err := parseDocument(data)
+// ParseTableMode mirrors ParseTable under mode. In lenient mode it also
+// returns the anomalies it tolerated.
+func ParseTableMode(data []byte, mode ParseMode) (*ConvertedTable, []Anomaly, error) {
+	doc, err := parseDocument(data, mode)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	payload := ConvertedTable{
+		SchemaVersion:  CurrentSchemaVersion,
 		Identifier:     NormalizeIdentifier(doc.classification.TableName),
 		Version:        doc.version,
 		Classification: toClassificationPayload(doc.classification),
@@ -66,22 +127,46 @@ func convertFromBytes(data []byte) ([]byte, error) {
 		}
 	}
 
+	NormalizeVocabulary(&payload)
+	return &payload, doc.warnings, nil
+}
+
+// EncodeWithOptions serializes table in the layout selected by opts.
+func EncodeWithOptions(table *ConvertedTable, opts ConvertOptions) ([]byte, error) {
+	if opts.Canonical {
+		return EncodeCanonical(table)
+	}
+	return EncodeTable(table)
+}
+
+// EncodeTable serializes a ConvertedTable using the converter's JSON layout.
+// A nil keyword list is written as an empty array, as the schema requires.
+func EncodeTable(table *ConvertedTable) ([]byte, error) {
+	if table.Classification != nil && table.Classification.Keywords == nil {
+		class := *table.Classification
+		class.Keywords = []string{}
+		copied := *table
+		copied.Classification = &class
+		table = &copied
+	}
 	var buf bytes.Buffer
 	enc := json.NewEncoder(&buf)
 	enc.SetIndent("", "  ")
-	if err := enc.Encode(payload); err != nil {
+	if err := enc.Encode(table); err != nil {
 		return nil, fmt.Errorf("encode json: %w", err)
 	}
-
 	return buf.Bytes(), nil
 }
 
 // ConvertedTable represents the normalized JSON payload consumed by UI layers.
+// SchemaVersion is the payload format version; Version is the XTbML source version.
 type ConvertedTable struct {
+	SchemaVersion  int                    `json:"schemaVersion"`
 	Identifier     string                 `json:"identifier"`
 	Version        string                 `json:"version"`
 	Classification *ClassificationPayload `json:"classification"`
 	Tables         []TablePayload         `json:"tables"`
+	Extensions     *Extensions            `json:"extensions,omitempty"`
 }
 
 type ClassificationPayload struct {
@@ -94,12 +179,14 @@ type ClassificationPayload struct {
 	TableDescription string                 `json:"tableDescription"`
 	Comments         string                 `json:"comments"`
 	Keywords         []string               `json:"keywords"`
+	Extensions       *Extensions            `json:"extensions,omitempty"`
 }
 
 type TablePayload struct {
-	Index    int                `json:"index"`
-	Metadata *TableMetaPayload  `json:"metadata,omitempty"`
-	Rates    []RateEntryPayload `json:"rates,omitempty"`
+	Index      int                `json:"index"`
+	Metadata   *TableMetaPayload  `json:"metadata,omitempty"`
+	Rates      []RateEntryPayload `json:"rates,omitempty"`
+	Extensions *Extensions        `json:"extensions,omitempty"`
 }
 
 type TableMetaPayload struct {
@@ -108,20 +195,26 @@ type TableMetaPayload struct {
 	Nation           ClassifiedValuePayload  `json:"nation"`
 	TableDescription string                  `json:"tableDescription"`
 	Axes             []AxisDefinitionPayload `json:"axes"`
+	Extensions       *Extensions             `json:"extensions,omitempty"`
 }
 
 type AxisDefinitionPayload struct {
-	ID        string                 `json:"id"`
-	ScaleType ClassifiedValuePayload `json:"scaleType"`
-	AxisName  string                 `json:"axisName"`
-	MinValue  string                 `json:"minValue"`
-	MaxValue  string                 `json:"maxValue"`
-	Increment string                 `json:"increment"`
+	ID         string                 `json:"id"`
+	ScaleType  ClassifiedValuePayload `json:"scaleType"`
+	AxisName   string                 `json:"axisName"`
+	MinValue   string                 `json:"minValue"`
+	MaxValue   string                 `json:"maxValue"`
+	Increment  string                 `json:"increment"`
+	Extensions *Extensions            `json:"extensions,omitempty"`
 }
 
+// ClassifiedValuePayload is a coded value. Label is the canonical label for
+// Code (see NormalizeVocabulary); RawLabel keeps the source label when it
+// differs.
 type ClassifiedValuePayload struct {
-	Code  string `json:"code"`
-	Label string `json:"label"`
+	Code     string `json:"code"`
+	Label    string `json:"label"`
+	RawLabel string `json:"rawLabel,omitempty"`
 }
 
 type RateEntryPayload struct {
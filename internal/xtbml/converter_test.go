@@ -1,28 +1,77 @@
 package xtbml
 
 import (
+	"bytes"
 	"encoding/json"
+	"flag"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
+// updateGolden rewrites the canonical snapshots under testdata/json:
+//
+//	go test ./internal/xtbml -run Golden -update-golden
+var updateGolden = flag.Bool("update-golden", false, "rewrite golden JSON snapshots in testdata/json")
+
+// goldenFixtures lists the XML fixtures with committed canonical snapshots.
+var goldenFixtures = []string{"table_small", "table_select"}
+
 func TestConvertXTbml_Golden(t *testing.T) {
-	xmlPath := filepath.Join("testdata", "table_small.xml")
-	jsonPath := filepath.Join("testdata", "json", "table_small.json")
+	for _, name := range goldenFixtures {
+		t.Run(name, func(t *testing.T) {
+			xmlPath := filepath.Join("testdata", name+".xml")
+			jsonPath := filepath.Join("testdata", "json", name+".json")
 
-	xmlFile, err := os.Open(xmlPath)
-	if err != nil {
-		t.Fatalf("open xml fixture: %v", err)
+			xmlFile, err := os.Open(xmlPath)
+			if err != nil {
+				t.Fatalf("open xml fixture: %v", err)
+			}
+			defer xmlFile.Close()
+
+			gotBytes, err := ConvertXTbmlWithOptions(xmlFile, ConvertOptions{Canonical: true})
+			if err != nil {
+				t.Fatalf("ConvertXTbmlWithOptions() error = %v", err)
+			}
+			if err := ValidateJSON(gotBytes); err != nil {
+				t.Fatalf("ValidateJSON() error = %v", err)
+			}
+
+			wantBytes, err := os.ReadFile(jsonPath)
+			if err != nil && !(*updateGolden && os.IsNotExist(err)) {
+				t.Fatalf("read golden json: %v", err)
+			}
+
+			if *updateGolden {
+				if bytes.Equal(gotBytes, wantBytes) {
+					return
+				}
+				if err := os.WriteFile(jsonPath, gotBytes, 0o644); err != nil {
+					t.Fatalf("write golden json: %v", err)
+				}
+				t.Logf("updated %s:\n%s", jsonPath, lineDiff(string(wantBytes), string(gotBytes)))
+				return
+			}
+			if !bytes.Equal(gotBytes, wantBytes) {
+				t.Fatalf("canonical output differs from %s (rerun with -update-golden to accept):\n%s",
+					jsonPath, lineDiff(string(wantBytes), string(gotBytes)))
+			}
+		})
 	}
-	defer xmlFile.Close()
+}
 
-	gotBytes, err := ConvertXTbml(xmlFile)
+func TestConvertXTbmlMatchesCanonicalGolden(t *testing.T) {
+	xmlBytes, err := os.ReadFile(filepath.Join("testdata", "table_small.xml"))
+	if err != nil {
+		t.Fatalf("read xml fixture: %v", err)
+	}
+	gotBytes, err := ConvertXTbml(bytes.NewReader(xmlBytes))
 	if err != nil {
 		t.Fatalf("ConvertXTbml() error = %v", err)
 	}
-
-	wantBytes, err := os.ReadFile(jsonPath)
+	wantBytes, err := os.ReadFile(filepath.Join("testdata", "json", "table_small.json"))
 	if err != nil {
 		t.Fatalf("read golden json: %v", err)
 	}
@@ -39,6 +88,48 @@ func TestConvertXTbml_Golden(t *testing.T) {
 	}
 }
 
+// lineDiff renders a minimal line diff of want against got, prefixing removed
+// lines with "-" and added lines with "+".
+func lineDiff(want, got string) string {
+	a := strings.Split(strings.TrimSuffix(want, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	if want == "" {
+		a = nil
+	}
+
+	// lcs[i][j] is the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			fmt.Fprintf(&out, "+%s\n", b[j])
+			j++
+		default:
+			fmt.Fprintf(&out, "-%s\n", a[i])
+			i++
+		}
+	}
+	return out.String()
+}
+
 func equalJSON(a, b any) bool {
 	return jsonDeepEqual(a, b)
 }
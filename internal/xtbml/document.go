@@ -13,24 +13,68 @@ type documentData struct {
 	classification *ContentClassification
 	tableMetas     []TableMeta
 	rates          []RatePoint
+	warnings       []Anomaly
 }
 
-func parseDocument(data []byte) (*documentData, error) {
-	dec := xml.NewDecoder(bytes.NewReader(data))
-	parser := newRateParser()
-	doc := &documentData{version: "unknown"}
-	tableIndex := -1
+func parseDocument(data []byte, mode ParseMode) (*documentData, error) {
+	doc := &documentData{}
+	walker := newDocumentWalker(bytes.NewReader(data), mode)
+	walker.onTable = func(index int) error {
+		doc.ensureMetaCapacity(index + 1)
+		return nil
+	}
+	walker.onMeta = func(index int, meta TableMeta) error {
+		doc.tableMetas[index] = meta
+		return nil
+	}
+	if err := walker.run(); err != nil {
+		return nil, err
+	}
+	doc.version = walker.version
+	doc.classification = walker.classification
+	doc.rates = walker.rates.points
+	doc.warnings = walker.log.found
+	return doc, nil
+}
+
+// documentWalker decodes an XTbML document token by token and reports its
+// tables, metadata and rates in source order, so callers can either collect
+// them or write them out as they arrive.
+type documentWalker struct {
+	dec   *xml.Decoder
+	log   *anomalyLog
+	rates *rateParser
+
+	// version is the root's version attribute, or "unknown" without one.
+	version        string
+	sawRoot        bool
+	classification *ContentClassification
+	tableIndex     int
+
+	// onTable is called at the start of each <Table>, and onMeta with the
+	// MetaData of the current table. Either may be nil.
+	onTable func(index int) error
+	onMeta  func(index int, meta TableMeta) error
+}
+
+func newDocumentWalker(r io.Reader, mode ParseMode) *documentWalker {
+	dec := xml.NewDecoder(r)
+	log := &anomalyLog{mode: mode, dec: dec}
+	return &documentWalker{dec: dec, log: log, rates: newRateParser(log), version: "unknown", tableIndex: -1}
+}
 
+func (w *documentWalker) run() error {
+	dec, log := w.dec, w.log
 	for {
 		tok, err := dec.Token()
 		if err != nil {
 			if err == io.EOF {
 				break
 			}
-			return nil, fmt.Errorf("decode xtbml: %w", err)
+			return fmt.Errorf("decode xtbml: %w", err)
 		}
-		if err := parser.consume(dec, tok); err != nil {
-			return nil, err
+		if err := w.rates.consume(dec, tok); err != nil {
+			return err
 		}
 
 		start, ok := tok.(xml.StartElement)
@@ -38,50 +82,68 @@ func parseDocument(data []byte) (*documentData, error) {
 			continue
 		}
 
-		switch strings.ToLower(start.Name.Local) {
+		switch lowerName(start.Name.Local) {
 		case "xtbml":
-			if doc.version == "unknown" {
+			if !w.sawRoot {
+				w.sawRoot = true
 				if v := versionFromAttrs(start.Attr); v != "" {
-					doc.version = v
+					w.version = v
+				} else if err := log.reportCause(ErrMissingVersion, "%v; recorded as %q", ErrMissingVersion, w.version); err != nil {
+					return err
 				}
 			}
 		case "table":
-			tableIndex++
-			doc.ensureMetaCapacity(tableIndex + 1)
+			w.tableIndex++
+			if w.onTable != nil {
+				if err := w.onTable(w.tableIndex); err != nil {
+					return err
+				}
+			}
 		case "contentclassification":
-			if doc.classification != nil {
+			if w.classification != nil {
+				if err := log.report("duplicate ContentClassification ignored"); err != nil {
+					return err
+				}
 				if err := dec.Skip(); err != nil {
-					return nil, fmt.Errorf("skip duplicate content classification: %w", err)
+					return fmt.Errorf("skip duplicate content classification: %w", err)
 				}
 				continue
 			}
 			classification, err := decodeClassificationElement(dec, start)
 			if err != nil {
-				return nil, err
+				return err
 			}
-			doc.classification = classification
+			w.classification = classification
 		case "metadata":
+			if w.tableIndex < 0 {
+				if err := log.report("MetaData outside a Table ignored"); err != nil {
+					return err
+				}
+			}
 			meta, err := decodeMetaElement(dec, start)
 			if err != nil {
-				return nil, err
+				return err
 			}
-			if tableIndex >= 0 && tableIndex < len(doc.tableMetas) {
-				doc.tableMetas[tableIndex] = meta
+			if w.tableIndex >= 0 && w.onMeta != nil {
+				if err := w.onMeta(w.tableIndex, meta); err != nil {
+					return err
+				}
 			}
 		}
 	}
 
-	rates, err := parser.result()
-	if err != nil {
-		return nil, err
+	if !w.sawRoot {
+		if err := log.report("XTbML root element missing; version recorded as %q", "unknown"); err != nil {
+			return err
+		}
 	}
-	doc.rates = rates
-
-	if doc.classification == nil {
-		return nil, fmt.Errorf("content classification missing table name")
+	if _, err := w.rates.result(); err != nil {
+		return err
 	}
-
-	return doc, nil
+	if w.classification == nil {
+		return fmt.Errorf("content classification missing table name")
+	}
+	return nil
 }
 
 func (d *documentData) ensureMetaCapacity(size int) {
@@ -0,0 +1,220 @@
+package xtbml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Extensions holds the XTbML content a decoder does not model: attributes of
+// the element other than the ones it reads, including namespace declarations,
+// and child elements it does not recognise.
+type Extensions struct {
+	Attributes []ExtensionAttribute `json:"attributes,omitempty"`
+	Elements   []ExtensionNode      `json:"elements,omitempty"`
+}
+
+// ExtensionAttribute is an attribute kept verbatim. Namespace is the resolved
+// namespace URI; namespace declarations keep the name "xmlns" or the namespace
+// "xmlns".
+type ExtensionAttribute struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Value     string `json:"value"`
+}
+
+// ExtensionNode is an unmodelled element kept as a generic tree. Text is the
+// element's character data with surrounding whitespace trimmed.
+type ExtensionNode struct {
+	Name       string               `json:"name"`
+	Namespace  string               `json:"namespace,omitempty"`
+	Attributes []ExtensionAttribute `json:"attributes,omitempty"`
+	Text       string               `json:"text,omitempty"`
+	Children   []ExtensionNode      `json:"children,omitempty"`
+}
+
+// modelledChildren lists, for each element the converter models, the child
+// elements its decoder reads. Children not listed are extensions. Values and
+// the leaf elements are not searched further.
+var modelledChildren = map[string]map[string]bool{
+	"xtbml": {"contentclassification": true, "table": true},
+	"contentclassification": {
+		"tableidentity": true, "providerdomain": true, "providername": true, "tablereference": true,
+		"contenttype": true, "tablename": true, "tabledescription": true, "comments": true, "keyword": true,
+	},
+	"table":    {"metadata": true, "values": true},
+	"metadata": {"scalingfactor": true, "datatype": true, "nation": true, "tabledescription": true, "axisdef": true},
+	"axisdef":  {"scaletype": true, "axisname": true, "minscalevalue": true, "maxscalevalue": true, "increment": true},
+}
+
+// modelledAttributes lists the attributes the decoders read on modelled
+// elements.
+var modelledAttributes = map[string]map[string]bool{
+	"xtbml":   {"version": true},
+	"axisdef": {"id": true},
+}
+
+// CaptureExtensions reads data again and attaches everything the converter
+// does not model to table, which must have been parsed from the same data:
+// to the root, the classification, each rate table, its metadata and each
+// axis definition. A repeated ContentClassification or MetaData outside a
+// Table is kept as a root extension element.
+func CaptureExtensions(data []byte, table *ConvertedTable) error {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	type frame struct {
+		name string
+		ext  *Extensions
+	}
+	var (
+		stack          []frame
+		sawRoot        bool
+		sawClass       bool
+		tableIndex     = -1
+		axisIndex      int
+		tableExts      = map[int]*Extensions{}
+		metaExts       = map[int]*Extensions{}
+		axisExts       = map[int]map[int]*Extensions{}
+		rootExt, class Extensions
+	)
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("decode xtbml extensions: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			continue
+		case xml.StartElement:
+			name := lowerName(t.Name.Local)
+			var parent *frame
+			if len(stack) > 0 {
+				parent = &stack[len(stack)-1]
+			}
+
+			var ext *Extensions
+			switch {
+			case parent == nil && name == "xtbml" && !sawRoot:
+				sawRoot = true
+				ext = &rootExt
+			case parent == nil:
+				// Without an XTbML root, top-level elements are read as if
+				// they were its children.
+				parent = &frame{name: "xtbml", ext: &rootExt}
+			}
+			if ext == nil && modelledChildren[parent.name][name] {
+				switch name {
+				case "contentclassification":
+					if !sawClass {
+						sawClass = true
+						ext = &class
+					}
+				case "table":
+					tableIndex++
+					axisIndex = 0
+					ext = &Extensions{}
+					tableExts[tableIndex] = ext
+				case "metadata":
+					if parent.name == "table" {
+						ext = &Extensions{}
+						metaExts[tableIndex] = ext
+					}
+				case "axisdef":
+					ext = &Extensions{}
+					if axisExts[tableIndex] == nil {
+						axisExts[tableIndex] = map[int]*Extensions{}
+					}
+					axisExts[tableIndex][axisIndex] = ext
+					axisIndex++
+				default:
+					// A modelled leaf, or Values: nothing to capture inside.
+					if err := dec.Skip(); err != nil {
+						return fmt.Errorf("decode xtbml extensions: %w", err)
+					}
+					continue
+				}
+			}
+			if ext == nil {
+				node, err := decodeExtensionNode(dec, t)
+				if err != nil {
+					return err
+				}
+				parent.ext.Elements = append(parent.ext.Elements, node)
+				continue
+			}
+			for _, attr := range t.Attr {
+				if !modelledAttributes[name][strings.ToLower(attr.Name.Local)] || attr.Name.Space != "" {
+					ext.Attributes = append(ext.Attributes, extensionAttribute(attr))
+				}
+			}
+			stack = append(stack, frame{name: name, ext: ext})
+		}
+	}
+
+	table.Extensions = nonEmpty(&rootExt)
+	if table.Classification != nil {
+		table.Classification.Extensions = nonEmpty(&class)
+	}
+	for i := range table.Tables {
+		tp := &table.Tables[i]
+		tp.Extensions = nonEmpty(tableExts[tp.Index])
+		if tp.Metadata == nil {
+			continue
+		}
+		tp.Metadata.Extensions = nonEmpty(metaExts[tp.Index])
+		for j := range tp.Metadata.Axes {
+			tp.Metadata.Axes[j].Extensions = nonEmpty(axisExts[tp.Index][j])
+		}
+	}
+	return nil
+}
+
+func nonEmpty(ext *Extensions) *Extensions {
+	if ext == nil || (len(ext.Attributes) == 0 && len(ext.Elements) == 0) {
+		return nil
+	}
+	return ext
+}
+
+func extensionAttribute(attr xml.Attr) ExtensionAttribute {
+	return ExtensionAttribute{Name: attr.Name.Local, Namespace: attr.Name.Space, Value: attr.Value}
+}
+
+// xmlNode decodes any element into a generic tree.
+type xmlNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Text     string     `xml:",chardata"`
+	Children []xmlNode  `xml:",any"`
+}
+
+func decodeExtensionNode(dec *xml.Decoder, start xml.StartElement) (ExtensionNode, error) {
+	var node xmlNode
+	if err := dec.DecodeElement(&node, &start); err != nil {
+		return ExtensionNode{}, fmt.Errorf("decode xtbml extensions: %w", err)
+	}
+	return node.extension(), nil
+}
+
+func (n xmlNode) extension() ExtensionNode {
+	out := ExtensionNode{
+		Name:      n.XMLName.Local,
+		Namespace: n.XMLName.Space,
+		Text:      strings.TrimSpace(n.Text),
+	}
+	for _, attr := range n.Attrs {
+		out.Attributes = append(out.Attributes, extensionAttribute(attr))
+	}
+	for _, child := range n.Children {
+		out.Children = append(out.Children, child.extension())
+	}
+	return out
+}
@@ -0,0 +1,102 @@
+package xtbml
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const extensionsDoc = `<XTbML version="1.0" xmlns:soa="urn:soa" soa:origin="export">
+  <ContentClassification lang="en">
+    <TableIdentity>9</TableIdentity>
+    <TableName>Extended</TableName>
+    <soa:Reviewer role="lead">Pat</soa:Reviewer>
+  </ContentClassification>
+  <ContentClassification><TableName>Duplicate</TableName></ContentClassification>
+  <Table basis="ALB">
+    <MetaData>
+      <Nation tc="1">USA</Nation>
+      <Smoker>Nonsmoker</Smoker>
+      <AxisDef id="Age" units="years">
+        <ScaleType tc="3">Age</ScaleType>
+        <AxisName>Age</AxisName>
+        <Interpolation><Method>linear</Method></Interpolation>
+      </AxisDef>
+    </MetaData>
+    <Values><Axis><Y t="40">0.1</Y></Axis></Values>
+    <Notes>Checked</Notes>
+  </Table>
+</XTbML>`
+
+func TestCaptureExtensions(t *testing.T) {
+	data := []byte(extensionsDoc)
+	// The document repeats its ContentClassification, so only lenient
+	// parsing accepts it.
+	table, _, err := ParseTableMode(data, ParseLenient)
+	if err != nil {
+		t.Fatalf("ParseTableMode() err = %v", err)
+	}
+	if err := CaptureExtensions(data, table); err != nil {
+		t.Fatalf("CaptureExtensions() err = %v", err)
+	}
+
+	root := table.Extensions
+	if root == nil || len(root.Attributes) != 2 || len(root.Elements) != 1 {
+		t.Fatalf("root extensions = %+v", root)
+	}
+	if a := root.Attributes[1]; a.Name != "origin" || a.Namespace != "urn:soa" || a.Value != "export" {
+		t.Fatalf("namespaced root attribute = %+v", a)
+	}
+	if root.Elements[0].Name != "ContentClassification" || root.Elements[0].Children[0].Text != "Duplicate" {
+		t.Fatalf("duplicate classification not kept: %+v", root.Elements[0])
+	}
+
+	class := table.Classification.Extensions
+	if class == nil || class.Attributes[0].Name != "lang" || len(class.Elements) != 1 {
+		t.Fatalf("classification extensions = %+v", class)
+	}
+	reviewer := class.Elements[0]
+	if reviewer.Name != "Reviewer" || reviewer.Namespace != "urn:soa" || reviewer.Text != "Pat" || reviewer.Attributes[0].Value != "lead" {
+		t.Fatalf("reviewer = %+v", reviewer)
+	}
+
+	tp := table.Tables[0]
+	if tp.Extensions == nil || tp.Extensions.Attributes[0].Value != "ALB" || tp.Extensions.Elements[0].Name != "Notes" {
+		t.Fatalf("table extensions = %+v", tp.Extensions)
+	}
+	if meta := tp.Metadata.Extensions; meta == nil || len(meta.Elements) != 1 || meta.Elements[0].Text != "Nonsmoker" {
+		t.Fatalf("metadata extensions = %+v", meta)
+	}
+	axis := tp.Metadata.Axes[0].Extensions
+	if axis == nil || axis.Attributes[0].Name != "units" || axis.Elements[0].Children[0].Text != "linear" {
+		t.Fatalf("axis extensions = %+v", axis)
+	}
+}
+
+func TestConvertWithExtensionsValidates(t *testing.T) {
+	for _, canonical := range []bool{false, true} {
+		out, err := ConvertXTbmlWithOptions(strings.NewReader(extensionsDoc), ConvertOptions{Extensions: true, Canonical: canonical, Mode: ParseLenient})
+		if err != nil {
+			t.Fatalf("convert: %v", err)
+		}
+		if err := ValidateJSON(out); err != nil {
+			t.Fatalf("ValidateJSON(canonical=%v) = %v", canonical, err)
+		}
+		decoded, err := DecodeTable(out)
+		if err != nil || decoded.Tables[0].Metadata.Axes[0].Extensions == nil {
+			t.Fatalf("round trip lost extensions: %v", err)
+		}
+	}
+
+	out, err := ConvertXTbmlWithOptions(strings.NewReader(extensionsDoc), ConvertOptions{Mode: ParseLenient})
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if _, ok := doc["extensions"]; ok || strings.Contains(string(out), "extensions") {
+		t.Fatalf("extensions written without opting in:\n%s", out)
+	}
+}
@@ -1,6 +1,7 @@
 package xtbml
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -15,18 +16,125 @@ func ConvertDirectory(srcDir, dstDir string) error {
 
 // ConvertDirectoryWithObserver mirrors ConvertDirectory and reports each conversion via observer.
 func ConvertDirectoryWithObserver(srcDir, dstDir string, observer func(src, dst string)) error {
+	return ConvertDirectoryWithOptions(srcDir, dstDir, ConvertOptions{}, observer)
+}
+
+// ConvertDirectoryWithOptions mirrors ConvertDirectoryWithObserver and applies opts to every file.
+func ConvertDirectoryWithOptions(srcDir, dstDir string, opts ConvertOptions, observer func(src, dst string)) error {
+	_, err := ConvertDirectoryReport(srcDir, dstDir, opts, observer)
+	return err
+}
+
+// DirectoryReport describes how ConvertDirectoryReport assigned identifiers.
+type DirectoryReport struct {
+	// Collisions lists the table names that normalize to the same identifier.
+	Collisions []Collision
+	// Renamed maps identifiers a previous conversion wrote to the identifier
+	// the same output file carries now. They are recorded in AliasFileName.
+	Renamed map[string]string
+	// Warnings lists the anomalies tolerated in lenient mode, by source file.
+	Warnings []FileWarnings
+	// UnknownCodes lists the classified values whose code is not in the
+	// registry, by source file.
+	UnknownCodes []FileUnknownCodes
+}
+
+// FileWarnings is the anomalies tolerated while converting one file.
+type FileWarnings struct {
+	File     string    `json:"file"`
+	Warnings []Anomaly `json:"warnings"`
+}
+
+// FileUnknownCodes is the unregistered codes met while converting one file.
+type FileUnknownCodes struct {
+	File    string        `json:"file"`
+	Unknown []UnknownCode `json:"unknown"`
+}
+
+// ConvertDirectoryReport mirrors ConvertDirectoryWithOptions and keeps
+// identifiers unique across the directory. It first reads every table's
+// classification and resolves colliding names with AssignIdentifiers, then
+// converts each file with its assigned identifier. When an output file
+// previously carried a different identifier, the old one is kept as an alias
+// in dstDir/AliasFileName so existing links still resolve.
+func ConvertDirectoryReport(srcDir, dstDir string, opts ConvertOptions, observer func(src, dst string)) (*DirectoryReport, error) {
 	entries, err := os.ReadDir(srcDir)
 	if err != nil {
-		return fmt.Errorf("read src dir: %w", err)
+		return nil, fmt.Errorf("read src dir: %w", err)
 	}
 	if err := os.MkdirAll(dstDir, 0o755); err != nil {
-		return fmt.Errorf("ensure dst dir: %w", err)
+		return nil, fmt.Errorf("ensure dst dir: %w", err)
+	}
+
+	sources, err := readIdentifierSources(srcDir, entries, false)
+	if err != nil {
+		return nil, err
+	}
+	assigned, collisions := AssignIdentifiers(sources)
+
+	var warnings []FileWarnings
+	var unknown []FileUnknownCodes
+	renamed := make(map[string]string)
+	current := make(map[string]bool, len(assigned))
+	for _, id := range assigned {
+		current[id] = true
+	}
+	for _, src := range sources {
+		name := filepath.Base(src.File)
+		dstName := strings.TrimSuffix(name, filepath.Ext(name)) + ".json"
+		dstPath := filepath.Join(dstDir, dstName)
+
+		previous := PayloadIdentifier(dstPath)
+		fileOpts := opts
+		fileOpts.Identifier = assigned[src.File]
+		payload, found, err := convertFile(src.File, dstPath, fileOpts)
+		if err != nil {
+			return nil, err
+		}
+		if len(found) > 0 {
+			warnings = append(warnings, FileWarnings{File: src.File, Warnings: found})
+		}
+		if codes := UnknownCodes(payload); len(codes) > 0 {
+			unknown = append(unknown, FileUnknownCodes{File: src.File, Unknown: codes})
+		}
+		if previous != "" && previous != fileOpts.Identifier && !current[previous] {
+			renamed[previous] = fileOpts.Identifier
+		}
+		if observer != nil {
+			observer(src.File, dstPath)
+		}
+	}
+
+	aliases, err := LoadAliases(dstDir)
+	if err != nil {
+		return nil, err
+	}
+	if aliases.Update(renamed, current) {
+		if err := aliases.Save(dstDir); err != nil {
+			return nil, err
+		}
+	}
+	return &DirectoryReport{Collisions: collisions, Renamed: renamed, Warnings: warnings, UnknownCodes: unknown}, nil
+}
+
+// ReadIdentifierSources reads the classification of every *.xml file in
+// srcDir, in name order, for AssignIdentifiers. Callers that convert files one
+// at a time use it to assign the identifiers ConvertDirectoryReport would.
+// Files whose classification cannot be read are left out: they cannot be
+// converted either, and must not hold up the identifiers of the others.
+func ReadIdentifierSources(srcDir string) ([]IdentifierSource, error) {
+	entries, err := os.ReadDir(srcDir)
+	if err != nil {
+		return nil, fmt.Errorf("read src dir: %w", err)
 	}
+	return readIdentifierSources(srcDir, entries, true)
+}
 
+func readIdentifierSources(srcDir string, entries []os.DirEntry, skipUnreadable bool) ([]IdentifierSource, error) {
 	sort.Slice(entries, func(i, j int) bool {
 		return entries[i].Name() < entries[j].Name()
 	})
-
+	var sources []IdentifierSource
 	for _, entry := range entries {
 		if entry.IsDir() {
 			continue
@@ -34,34 +142,104 @@ func ConvertDirectoryWithObserver(srcDir, dstDir string, observer func(src, dst
 		if !strings.HasSuffix(strings.ToLower(entry.Name()), ".xml") {
 			continue
 		}
+		src, err := readIdentifierSource(filepath.Join(srcDir, entry.Name()))
+		if err != nil {
+			if skipUnreadable {
+				continue
+			}
+			return nil, err
+		}
+		sources = append(sources, src)
+	}
+	return sources, nil
+}
 
-		srcPath := filepath.Join(srcDir, entry.Name())
-		dstName := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())) + ".json"
-		dstPath := filepath.Join(dstDir, dstName)
+func readIdentifierSource(path string) (IdentifierSource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return IdentifierSource{}, fmt.Errorf("read %s: %w", path, err)
+	}
+	defer f.Close()
+	class, err := ParseContentClassification(f)
+	if err != nil {
+		return IdentifierSource{}, fmt.Errorf("convert %s: %w", path, err)
+	}
+	return IdentifierSource{File: path, TableIdentity: class.TableIdentity, Base: NormalizeIdentifier(class.TableName)}, nil
+}
 
-		if err := ConvertFile(srcPath, dstPath); err != nil {
-			return err
+// PayloadIdentifier returns the identifier of the payload at path, or ""
+// when there is none. It stops reading at the identifier, which the
+// converter writes ahead of the rates.
+func PayloadIdentifier(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	dec := json.NewDecoder(f)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return ""
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return ""
 		}
-		if observer != nil {
-			observer(srcPath, dstPath)
+		if key == "identifier" {
+			var id string
+			if dec.Decode(&id) != nil {
+				return ""
+			}
+			return id
+		}
+		var skip json.RawMessage
+		if dec.Decode(&skip) != nil {
+			return ""
 		}
 	}
-
-	return nil
+	return ""
 }
 
 // ConvertFile converts a single XML file at srcPath into JSON at dstPath.
 func ConvertFile(srcPath, dstPath string) error {
+	return ConvertFileWithOptions(srcPath, dstPath, ConvertOptions{})
+}
+
+// ConvertFileWithOptions mirrors ConvertFile and applies opts. With
+// opts.Validate, output that violates the JSON schema is never written.
+func ConvertFileWithOptions(srcPath, dstPath string, opts ConvertOptions) error {
+	_, err := ConvertFileReport(srcPath, dstPath, opts)
+	return err
+}
+
+// ConvertFileReport mirrors ConvertFileWithOptions and returns the anomalies
+// tolerated in lenient mode.
+func ConvertFileReport(srcPath, dstPath string, opts ConvertOptions) ([]Anomaly, error) {
+	_, warnings, err := convertFile(srcPath, dstPath, opts)
+	return warnings, err
+}
+
+// convertFile mirrors ConvertFileReport and also returns the payload it wrote,
+// as an outline without rates when streaming.
+func convertFile(srcPath, dstPath string, opts ConvertOptions) (*ConvertedTable, []Anomaly, error) {
+	if opts.Stream {
+		return convertFileStream(srcPath, dstPath, opts)
+	}
 	data, err := os.ReadFile(srcPath)
 	if err != nil {
-		return fmt.Errorf("read %s: %w", srcPath, err)
+		return nil, nil, fmt.Errorf("read %s: %w", srcPath, err)
 	}
-	out, err := convertFromBytes(data)
+	payload, out, warnings, err := convertPayload(data, opts)
 	if err != nil {
-		return fmt.Errorf("convert %s: %w", srcPath, err)
+		return nil, nil, fmt.Errorf("convert %s: %w", srcPath, err)
+	}
+	if opts.Validate {
+		if err := ValidateJSON(out); err != nil {
+			return nil, nil, fmt.Errorf("validate %s: %w", srcPath, err)
+		}
 	}
 	if err := os.WriteFile(dstPath, out, 0o644); err != nil {
-		return fmt.Errorf("write %s: %w", dstPath, err)
+		return nil, nil, fmt.Errorf("write %s: %w", dstPath, err)
 	}
-	return nil
+	return payload, warnings, nil
 }
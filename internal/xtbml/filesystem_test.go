@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -42,3 +43,76 @@ func TestConvertDirectory(t *testing.T) {
 		t.Fatalf("json mismatch\n got: %s\nwant: %s", gotBytes, wantBytes)
 	}
 }
+
+func TestConvertFileRejectsSchemaViolations(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "bad.xml")
+	dst := filepath.Join(dir, "bad.json")
+	xml := `<XTbML version="1.3"><ContentClassification><TableName>!!!</TableName></ContentClassification>` +
+		`<Table><Values><Axis><Y t="40">0.1</Y></Axis></Values></Table></XTbML>`
+	if err := os.WriteFile(src, []byte(xml), 0o644); err != nil {
+		t.Fatalf("write src: %v", err)
+	}
+
+	err := ConvertFileWithOptions(src, dst, ConvertOptions{Validate: true})
+	if err == nil || !strings.Contains(err.Error(), "/identifier") {
+		t.Fatalf("ConvertFileWithOptions() error = %v, want identifier violation", err)
+	}
+	if _, statErr := os.Stat(dst); !os.IsNotExist(statErr) {
+		t.Fatalf("invalid output should not be written, stat err = %v", statErr)
+	}
+}
+
+func writeSmallTable(t *testing.T, dir, name, identity, tableName string) {
+	t.Helper()
+	xmlBytes, err := os.ReadFile(filepath.Join("testdata", "table_small.xml"))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	doc := strings.Replace(string(xmlBytes), "tbl-001", identity, 1)
+	doc = strings.Replace(doc, "Sample Table", tableName, 1)
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(doc), 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+}
+
+func TestConvertDirectoryReportResolvesCollisions(t *testing.T) {
+	src := t.TempDir()
+	dst := t.TempDir()
+	writeSmallTable(t, src, "a.xml", "7", "Sample Table")
+	writeSmallTable(t, src, "b.xml", "3", "Sample Table")
+	writeSmallTable(t, src, "c.xml", "5", "Other Table")
+
+	report, err := ConvertDirectoryReport(src, dst, ConvertOptions{}, nil)
+	if err != nil {
+		t.Fatalf("ConvertDirectoryReport() error = %v", err)
+	}
+	if len(report.Collisions) != 1 || report.Collisions[0].Identifier != "sample_table" {
+		t.Fatalf("collisions = %+v", report.Collisions)
+	}
+	for name, want := range map[string]string{"a.json": "sample_table_7", "b.json": "sample_table", "c.json": "other_table"} {
+		if got := PayloadIdentifier(filepath.Join(dst, name)); got != want {
+			t.Fatalf("%s identifier = %q, want %q", name, got, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dst, AliasFileName)); !os.IsNotExist(err) {
+		t.Fatalf("alias file written without renames: %v", err)
+	}
+
+	// Renaming a table keeps its old identifier as an alias.
+	writeSmallTable(t, src, "c.xml", "5", "Other Table Revised")
+	report, err = ConvertDirectoryReport(src, dst, ConvertOptions{}, nil)
+	if err != nil {
+		t.Fatalf("ConvertDirectoryReport() error = %v", err)
+	}
+	if report.Renamed["other_table"] != "other_table_revised" {
+		t.Fatalf("renamed = %v", report.Renamed)
+	}
+	aliases, err := LoadAliases(dst)
+	if err != nil {
+		t.Fatalf("LoadAliases() error = %v", err)
+	}
+	if got := aliases.Resolve("other_table"); got != "other_table_revised" {
+		t.Fatalf("Resolve(other_table) = %q", got)
+	}
+}
@@ -0,0 +1,196 @@
+package xtbml
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AliasFileName is the alias map ConvertDirectoryReport keeps in the
+// destination directory. It is not a table payload, so readers that skip
+// ErrNotTable ignore it.
+const AliasFileName = "identifier_aliases.json"
+
+// IdentifierSource is one table competing for an identifier: Base is the
+// identifier NormalizeIdentifier derives from its TableName.
+type IdentifierSource struct {
+	File          string `json:"file"`
+	TableIdentity string `json:"tableIdentity"`
+	Base          string `json:"-"`
+}
+
+// CollisionMember is a table that shares a base identifier and the identifier
+// it was assigned.
+type CollisionMember struct {
+	IdentifierSource
+	Identifier string `json:"identifier"`
+}
+
+// Collision lists the tables whose names normalize to the same identifier.
+type Collision struct {
+	Identifier string            `json:"identifier"`
+	Tables     []CollisionMember `json:"tables"`
+}
+
+// AssignIdentifiers gives every source a unique identifier and returns them
+// by File along with the collisions it resolved. Sources sharing a base are
+// ordered by table identity (numerically when both are numbers, then by
+// file); the first keeps the base and the others get the base plus
+// "_<tableIdentity>", or "_<file name>" when the identity is empty or already
+// taken. The result depends only on the set of sources.
+func AssignIdentifiers(sources []IdentifierSource) (map[string]string, []Collision) {
+	groups := make(map[string][]IdentifierSource)
+	taken := make(map[string]bool)
+	for _, src := range sources {
+		groups[src.Base] = append(groups[src.Base], src)
+		taken[src.Base] = true
+	}
+	bases := make([]string, 0, len(groups))
+	for base := range groups {
+		bases = append(bases, base)
+	}
+	sort.Strings(bases)
+
+	assigned := make(map[string]string, len(sources))
+	var collisions []Collision
+	for _, base := range bases {
+		group := groups[base]
+		if len(group) == 1 {
+			assigned[group[0].File] = base
+			continue
+		}
+		sort.SliceStable(group, func(i, j int) bool {
+			return identityLess(group[i], group[j])
+		})
+		collision := Collision{Identifier: base}
+		for i, src := range group {
+			id := base
+			if i > 0 {
+				id = disambiguate(base, src, taken)
+				taken[id] = true
+			}
+			assigned[src.File] = id
+			collision.Tables = append(collision.Tables, CollisionMember{IdentifierSource: src, Identifier: id})
+		}
+		collisions = append(collisions, collision)
+	}
+	return assigned, collisions
+}
+
+func identityLess(a, b IdentifierSource) bool {
+	ai, aErr := strconv.Atoi(strings.TrimSpace(a.TableIdentity))
+	bi, bErr := strconv.Atoi(strings.TrimSpace(b.TableIdentity))
+	switch {
+	case aErr == nil && bErr == nil && ai != bi:
+		return ai < bi
+	case aErr == nil && bErr != nil:
+		return true
+	case aErr != nil && bErr == nil:
+		return false
+	case a.TableIdentity != b.TableIdentity:
+		return a.TableIdentity < b.TableIdentity
+	default:
+		return a.File < b.File
+	}
+}
+
+func disambiguate(base string, src IdentifierSource, taken map[string]bool) string {
+	stem := strings.TrimSuffix(filepath.Base(src.File), filepath.Ext(src.File))
+	for _, suffix := range []string{NormalizeIdentifier(src.TableIdentity), NormalizeIdentifier(stem)} {
+		if suffix == "" {
+			continue
+		}
+		if id := base + "_" + suffix; !taken[id] {
+			return id
+		}
+	}
+	for n := 2; ; n++ {
+		if id := fmt.Sprintf("%s_%d", base, n); !taken[id] {
+			return id
+		}
+	}
+}
+
+// Aliases maps identifiers that no longer name a table to their replacement.
+type Aliases map[string]string
+
+type aliasFile struct {
+	Aliases Aliases `json:"aliases"`
+}
+
+// LoadAliases reads the alias map in dir. A missing file yields an empty map.
+func LoadAliases(dir string) (Aliases, error) {
+	data, err := os.ReadFile(filepath.Join(dir, AliasFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return Aliases{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read aliases: %w", err)
+	}
+	var file aliasFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("decode aliases: %w", err)
+	}
+	if file.Aliases == nil {
+		file.Aliases = Aliases{}
+	}
+	return file.Aliases, nil
+}
+
+// Save writes the alias map into dir.
+func (a Aliases) Save(dir string) error {
+	data, err := json.MarshalIndent(aliasFile{Aliases: a}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode aliases: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, AliasFileName), append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write aliases: %w", err)
+	}
+	return nil
+}
+
+// Resolve follows aliases from id and returns the current identifier, or id
+// itself when it is not an alias.
+func (a Aliases) Resolve(id string) string {
+	seen := map[string]bool{}
+	for {
+		next, ok := a[id]
+		if !ok || seen[id] {
+			return id
+		}
+		seen[id] = true
+		id = next
+	}
+}
+
+// Update records that the tables in renamed (old identifier to new) moved,
+// drops aliases shadowed by a current identifier and points every alias at
+// a current identifier. current must hold every identifier in use. It
+// reports whether the map changed.
+func (a Aliases) Update(renamed map[string]string, current map[string]bool) bool {
+	changed := false
+	for old, id := range renamed {
+		if a[old] != id {
+			a[old] = id
+			changed = true
+		}
+	}
+	for old := range a {
+		if current[old] {
+			delete(a, old)
+			changed = true
+		}
+	}
+	for old := range a {
+		if resolved := a.Resolve(old); resolved != a[old] {
+			a[old] = resolved
+			changed = true
+		}
+	}
+	return changed
+}
@@ -0,0 +1,91 @@
+package xtbml
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAssignIdentifiers(t *testing.T) {
+	sources := []IdentifierSource{
+		{File: "xml/t20.xml", TableIdentity: "20", Base: "cso_1980"},
+		{File: "xml/t3.xml", TableIdentity: "3", Base: "cso_1980"},
+		{File: "xml/t100.xml", TableIdentity: "100", Base: "cso_1980"},
+		{File: "xml/t4.xml", TableIdentity: "4", Base: "vbt_2015"},
+		{File: "xml/x.xml", TableIdentity: "", Base: "cso_1980"},
+	}
+	assigned, collisions := AssignIdentifiers(sources)
+
+	want := map[string]string{
+		"xml/t3.xml":   "cso_1980",
+		"xml/t20.xml":  "cso_1980_20",
+		"xml/t100.xml": "cso_1980_100",
+		"xml/x.xml":    "cso_1980_x",
+		"xml/t4.xml":   "vbt_2015",
+	}
+	if !reflect.DeepEqual(assigned, want) {
+		t.Fatalf("AssignIdentifiers() = %v, want %v", assigned, want)
+	}
+	if len(collisions) != 1 || collisions[0].Identifier != "cso_1980" || len(collisions[0].Tables) != 4 {
+		t.Fatalf("collisions = %+v", collisions)
+	}
+	if first := collisions[0].Tables[0]; first.File != "xml/t3.xml" || first.Identifier != "cso_1980" {
+		t.Fatalf("first collision member = %+v", first)
+	}
+
+	// Order of the input must not matter.
+	reversed := make([]IdentifierSource, len(sources))
+	for i, src := range sources {
+		reversed[len(sources)-1-i] = src
+	}
+	again, _ := AssignIdentifiers(reversed)
+	if !reflect.DeepEqual(again, want) {
+		t.Fatalf("AssignIdentifiers(reversed) = %v, want %v", again, want)
+	}
+}
+
+func TestAssignIdentifiersAvoidsExistingBase(t *testing.T) {
+	sources := []IdentifierSource{
+		{File: "a.xml", TableIdentity: "1", Base: "scale"},
+		{File: "b.xml", TableIdentity: "2", Base: "scale"},
+		{File: "c.xml", TableIdentity: "9", Base: "scale_2"},
+	}
+	assigned, _ := AssignIdentifiers(sources)
+	if assigned["b.xml"] != "scale_b" || assigned["c.xml"] != "scale_2" {
+		t.Fatalf("AssignIdentifiers() = %v", assigned)
+	}
+}
+
+func TestAliases(t *testing.T) {
+	dir := t.TempDir()
+	aliases, err := LoadAliases(dir)
+	if err != nil || len(aliases) != 0 {
+		t.Fatalf("LoadAliases(empty) = %v, %v", aliases, err)
+	}
+
+	aliases.Update(map[string]string{"old": "mid"}, map[string]bool{"mid": true})
+	changed := aliases.Update(map[string]string{"mid": "new"}, map[string]bool{"new": true})
+	if !changed || aliases["old"] != "new" || aliases["mid"] != "new" {
+		t.Fatalf("aliases = %v, want old and mid pointing at new", aliases)
+	}
+	if aliases.Update(nil, map[string]bool{"new": true}) {
+		t.Fatal("Update() without renames reported a change")
+	}
+	// An alias whose name is reused by a current table is dropped.
+	if !aliases.Update(nil, map[string]bool{"old": true, "new": true}) || aliases["old"] != "" {
+		t.Fatalf("aliases = %v, want old dropped", aliases)
+	}
+
+	if err := aliases.Save(dir); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	loaded, err := LoadAliases(dir)
+	if err != nil {
+		t.Fatalf("LoadAliases() error = %v", err)
+	}
+	if got := loaded.Resolve("mid"); got != "new" {
+		t.Fatalf("Resolve(mid) = %q, want new", got)
+	}
+	if got := loaded.Resolve("other"); got != "other" {
+		t.Fatalf("Resolve(other) = %q, want other", got)
+	}
+}
@@ -0,0 +1,199 @@
+package xtbml
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CurrentSchemaVersion is the payload format version written by the converter.
+// Payloads without a schemaVersion field predate versioning and are version 1.
+const CurrentSchemaVersion = 3
+
+// ErrNotTable is returned when a JSON document is not a converted table payload.
+var ErrNotTable = errors.New("not a converted table payload")
+
+// migration upgrades a decoded payload from version from to from+1 in place.
+type migration struct {
+	from  int
+	apply func(doc map[string]any) error
+}
+
+// migrations must stay ordered by from and cover every version below
+// CurrentSchemaVersion.
+var migrations = []migration{
+	// Version 2 only introduces the explicit schemaVersion field.
+	{from: 1, apply: func(map[string]any) error { return nil }},
+	// Version 3 labels classified values by code and keeps the source label
+	// in rawLabel.
+	{from: 2, apply: normalizeVocabularyDoc},
+}
+
+// PayloadSchemaVersion reports the schema version declared by raw payload JSON.
+func PayloadSchemaVersion(raw []byte) (int, error) {
+	var head struct {
+		SchemaVersion *int            `json:"schemaVersion"`
+		Identifier    *string         `json:"identifier"`
+		Tables        json.RawMessage `json:"tables"`
+	}
+	if err := json.Unmarshal(raw, &head); err != nil {
+		return 0, fmt.Errorf("decode json: %w", err)
+	}
+	if head.Identifier == nil || head.Tables == nil {
+		return 0, ErrNotTable
+	}
+	if head.SchemaVersion == nil {
+		return 1, nil
+	}
+	return *head.SchemaVersion, nil
+}
+
+// MigrateJSON upgrades raw payload JSON to CurrentSchemaVersion. changed is
+// false, and raw is returned untouched, when the payload is already current.
+// Upgraded output is compact and keeps every field of the source document;
+// MigrateFile rewrites it in the converter's layout.
+func MigrateJSON(raw []byte) (out []byte, changed bool, err error) {
+	version, err := PayloadSchemaVersion(raw)
+	if err != nil {
+		return nil, false, err
+	}
+	if version > CurrentSchemaVersion {
+		return nil, false, fmt.Errorf("payload schema version %d is newer than supported version %d", version, CurrentSchemaVersion)
+	}
+	if version < 1 {
+		return nil, false, fmt.Errorf("invalid payload schema version %d", version)
+	}
+	if version == CurrentSchemaVersion {
+		return raw, false, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, false, fmt.Errorf("decode json: %w", err)
+	}
+	for _, m := range migrations {
+		if m.from != version {
+			continue
+		}
+		if err := m.apply(doc); err != nil {
+			return nil, false, fmt.Errorf("migrate schema version %d: %w", version, err)
+		}
+		version++
+		doc["schemaVersion"] = version
+	}
+	if version != CurrentSchemaVersion {
+		return nil, false, fmt.Errorf("no migration path from schema version %d", version)
+	}
+
+	out, err = json.Marshal(doc)
+	if err != nil {
+		return nil, false, fmt.Errorf("encode migrated payload: %w", err)
+	}
+	return out, true, nil
+}
+
+// MigrateHeaderJSON is MigrateJSON for readers that only need a payload's
+// identifier and classification. The rate tables are left out of the upgrade
+// and the result has an empty tables list, which spares decoding every rate
+// of an older payload.
+func MigrateHeaderJSON(raw []byte) ([]byte, error) {
+	version, err := PayloadSchemaVersion(raw)
+	if err != nil {
+		return nil, err
+	}
+	if version == CurrentSchemaVersion {
+		return raw, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, fmt.Errorf("decode json: %w", err)
+	}
+	fields["tables"] = json.RawMessage("[]")
+	header, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("encode payload header: %w", err)
+	}
+	out, _, err := MigrateJSON(header)
+	return out, err
+}
+
+// DecodeTable decodes payload JSON of any supported schema version, upgrading
+// older payloads in memory.
+func DecodeTable(raw []byte) (*ConvertedTable, error) {
+	upgraded, _, err := MigrateJSON(raw)
+	if err != nil {
+		return nil, err
+	}
+	var table ConvertedTable
+	if err := json.Unmarshal(upgraded, &table); err != nil {
+		return nil, fmt.Errorf("decode json: %w", err)
+	}
+	return &table, nil
+}
+
+// MigrateFile rewrites the payload at path in place when it uses an older
+// schema version and reports whether the file changed.
+func MigrateFile(path string) (bool, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("read %s: %w", path, err)
+	}
+	upgraded, changed, err := MigrateJSON(raw)
+	if err != nil {
+		return false, fmt.Errorf("migrate %s: %w", path, err)
+	}
+	if !changed {
+		return false, nil
+	}
+	if err := ValidateJSON(upgraded); err != nil {
+		return false, fmt.Errorf("validate %s: %w", path, err)
+	}
+	table, err := DecodeTable(upgraded)
+	if err != nil {
+		return false, fmt.Errorf("migrate %s: %w", path, err)
+	}
+	out, err := EncodeTable(table)
+	if err != nil {
+		return false, fmt.Errorf("migrate %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, out, 0o644); err != nil {
+		return false, fmt.Errorf("write %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// MigrateDirectory upgrades every table payload in dir, reporting rewritten
+// files via observer. JSON files that are not table payloads are skipped.
+func MigrateDirectory(dir string, observer func(path string)) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("read json dir: %w", err)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(strings.ToLower(entry.Name()), ".json") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		changed, err := MigrateFile(path)
+		if errors.Is(err, ErrNotTable) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if changed && observer != nil {
+			observer(path)
+		}
+	}
+	return nil
+}
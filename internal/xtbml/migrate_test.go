@@ -0,0 +1,116 @@
+package xtbml
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const legacyPayload = `{
+  "identifier": "legacy",
+  "version": "unknown",
+  "classification": { "tableIdentity": "7", "tableName": "Legacy" },
+  "tables": [ { "index": 0, "rates": [ { "age": 40, "rate": 0.00001 } ] } ]
+}`
+
+func TestDecodeTableMigratesLegacyPayload(t *testing.T) {
+	table, err := DecodeTable([]byte(legacyPayload))
+	if err != nil {
+		t.Fatalf("DecodeTable() error = %v", err)
+	}
+	if table.SchemaVersion != CurrentSchemaVersion || table.Identifier != "legacy" {
+		t.Fatalf("unexpected table: %#v", table)
+	}
+	if rate := table.Tables[0].Rates[0].Rate; rate == nil || *rate != 0.00001 {
+		t.Fatalf("rate lost in migration: %#v", table.Tables[0].Rates[0])
+	}
+}
+
+func TestMigrateJSON(t *testing.T) {
+	out, changed, err := MigrateJSON([]byte(legacyPayload))
+	if err != nil || !changed {
+		t.Fatalf("MigrateJSON() changed = %v, err = %v", changed, err)
+	}
+	if v, err := PayloadSchemaVersion(out); err != nil || v != CurrentSchemaVersion {
+		t.Fatalf("PayloadSchemaVersion() = %d, %v", v, err)
+	}
+
+	again, changed, err := MigrateJSON(out)
+	if err != nil || changed || string(again) != string(out) {
+		t.Fatalf("current payload should be untouched: changed = %v, err = %v", changed, err)
+	}
+
+	future := strings.Replace(legacyPayload, `{`, `{"schemaVersion": 99,`, 1)
+	if _, _, err := MigrateJSON([]byte(future)); err == nil {
+		t.Fatalf("expected error for newer schema version")
+	}
+	if _, _, err := MigrateJSON([]byte(`{"last_log_ms": 1}`)); !errors.Is(err, ErrNotTable) {
+		t.Fatalf("MigrateJSON() error = %v, want ErrNotTable", err)
+	}
+}
+
+func TestMigrateHeaderJSON(t *testing.T) {
+	out, err := MigrateHeaderJSON([]byte(legacyPayload))
+	if err != nil {
+		t.Fatalf("MigrateHeaderJSON() error = %v", err)
+	}
+	header, err := DecodeTable(out)
+	if err != nil {
+		t.Fatalf("DecodeTable() error = %v", err)
+	}
+	full, err := DecodeTable([]byte(legacyPayload))
+	if err != nil {
+		t.Fatalf("DecodeTable() error = %v", err)
+	}
+	if header.Identifier != full.Identifier || !reflect.DeepEqual(header.Classification, full.Classification) {
+		t.Fatalf("header = %#v, want classification %#v", header, full.Classification)
+	}
+	if header.Tables == nil || len(header.Tables) != 0 {
+		t.Fatalf("expected an empty tables list, got %#v", header.Tables)
+	}
+
+	current, _, err := MigrateJSON([]byte(legacyPayload))
+	if err != nil {
+		t.Fatalf("MigrateJSON() error = %v", err)
+	}
+	if again, err := MigrateHeaderJSON(current); err != nil || string(again) != string(current) {
+		t.Fatalf("current payload should be untouched: err = %v", err)
+	}
+	if _, err := MigrateHeaderJSON([]byte(`{"last_log_ms": 1}`)); !errors.Is(err, ErrNotTable) {
+		t.Fatalf("MigrateHeaderJSON() error = %v, want ErrNotTable", err)
+	}
+}
+
+func TestMigrateDirectory(t *testing.T) {
+	dir := t.TempDir()
+	legacyPath := filepath.Join(dir, "legacy.json")
+	statePath := filepath.Join(dir, "changelog_state.json")
+	if err := os.WriteFile(legacyPath, []byte(legacyPayload), 0o644); err != nil {
+		t.Fatalf("write legacy: %v", err)
+	}
+	if err := os.WriteFile(statePath, []byte(`{"last_log_ms": 1}`), 0o644); err != nil {
+		t.Fatalf("write state: %v", err)
+	}
+
+	var migrated []string
+	if err := MigrateDirectory(dir, func(path string) { migrated = append(migrated, path) }); err != nil {
+		t.Fatalf("MigrateDirectory() error = %v", err)
+	}
+	if len(migrated) != 1 || migrated[0] != legacyPath {
+		t.Fatalf("migrated = %v", migrated)
+	}
+	raw, err := os.ReadFile(legacyPath)
+	if err != nil {
+		t.Fatalf("read migrated: %v", err)
+	}
+	if err := ValidateJSON(raw); err != nil {
+		t.Fatalf("migrated payload invalid: %v", err)
+	}
+	state, err := os.ReadFile(statePath)
+	if err != nil || string(state) != `{"last_log_ms": 1}` {
+		t.Fatalf("state file should be untouched: %s, %v", state, err)
+	}
+}
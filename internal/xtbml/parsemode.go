@@ -0,0 +1,80 @@
+package xtbml
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+)
+
+// ParseMode selects how the parser treats anomalies in XTbML input: a
+// duplicate ContentClassification, MetaData or Values outside a Table, a
+// missing XTbML root or version attribute, or a <Y> that is not a number or
+// lacks its axis value.
+type ParseMode int
+
+const (
+	// ParseLenient records each anomaly as a warning and still converts:
+	// duplicates and stray blocks are ignored, the version is recorded as
+	// "unknown", non-numeric rates become blank cells and rates without an
+	// axis value are dropped. It is the zero value.
+	ParseLenient ParseMode = iota
+	// ParseStrict fails on the first anomaly.
+	ParseStrict
+)
+
+func (m ParseMode) String() string {
+	if m == ParseStrict {
+		return "strict"
+	}
+	return "lenient"
+}
+
+// ErrMissingVersion is the cause of the anomaly reported for an XTbML root
+// without a version attribute. Most published tables omit it.
+var ErrMissingVersion = errors.New("XTbML version attribute missing")
+
+// Anomaly is an irregularity in XTbML input. Under ParseStrict it is returned
+// as the parse error; under ParseLenient it is returned as a warning.
+type Anomaly struct {
+	// Line is the 1-based source line the parser had reached.
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+
+	cause error
+}
+
+func (a Anomaly) Error() string {
+	return fmt.Sprintf("line %d: %s", a.Line, a.Message)
+}
+
+// Unwrap returns the sentinel the anomaly was reported for, such as
+// ErrMissingVersion, or nil.
+func (a Anomaly) Unwrap() error {
+	return a.cause
+}
+
+// anomalyLog applies a ParseMode to the anomalies met while decoding.
+type anomalyLog struct {
+	mode  ParseMode
+	dec   *xml.Decoder
+	found []Anomaly
+}
+
+// report returns the anomaly as an error in strict mode and records it in
+// lenient mode.
+func (l *anomalyLog) report(format string, args ...any) error {
+	return l.reportCause(nil, format, args...)
+}
+
+// reportCause mirrors report for an anomaly that unwraps to cause.
+func (l *anomalyLog) reportCause(cause error, format string, args ...any) error {
+	a := Anomaly{Message: fmt.Sprintf(format, args...), cause: cause}
+	if l.dec != nil {
+		a.Line, _ = l.dec.InputPos()
+	}
+	if l.mode == ParseStrict {
+		return a
+	}
+	l.found = append(l.found, a)
+	return nil
+}
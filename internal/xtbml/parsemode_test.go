@@ -0,0 +1,104 @@
+package xtbml
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func anomalyDoc(root, body string) []byte {
+	classification := `<ContentClassification><TableIdentity>7</TableIdentity><TableName>Sample</TableName></ContentClassification>`
+	doc := classification + body
+	if root != "" {
+		doc = "<" + root + ` version="1.0">` + "\n" + doc + "\n</" + root + ">"
+	}
+	return []byte(doc)
+}
+
+func TestParseTableModeAnomalies(t *testing.T) {
+	table := `<Table><MetaData><Nation tc="1">USA</Nation></MetaData><Values><Axis><Y t="40">0.1</Y>%s</Axis></Values></Table>`
+	cases := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"duplicate classification", anomalyDoc("XTbML", strings.Replace(table, "%s", "", 1)+`<ContentClassification><TableName>Other</TableName></ContentClassification>`), "duplicate ContentClassification"},
+		{"metadata outside table", anomalyDoc("XTbML", `<MetaData/>`+strings.Replace(table, "%s", "", 1)), "MetaData outside a Table"},
+		{"values outside table", anomalyDoc("XTbML", `<Values><Axis><Y t="1">0.5</Y></Axis></Values>`+strings.Replace(table, "%s", "", 1)), "Values outside a Table"},
+		{"missing root", anomalyDoc("", strings.Replace(table, "%s", "", 1)), "XTbML root element missing"},
+		{"non-numeric rate", anomalyDoc("XTbML", strings.Replace(table, "%s", `<Y t="41">n/a</Y>`, 1)), `rate "n/a" is not a number`},
+		{"rate without age", anomalyDoc("XTbML", strings.Replace(table, "%s", `<Y>0.2</Y>`, 1)), "missing age identifier"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			payload, warnings, err := ParseTableMode(tc.data, ParseLenient)
+			if err != nil {
+				t.Fatalf("lenient err = %v", err)
+			}
+			if len(warnings) != 1 || !strings.Contains(warnings[0].Message, tc.want) || warnings[0].Line == 0 {
+				t.Fatalf("lenient warnings = %+v, want one containing %q", warnings, tc.want)
+			}
+			if payload.Classification.TableName != "Sample" || len(payload.Tables) != 1 || payload.Tables[0].Rates[0].Age != 40 {
+				t.Fatalf("lenient payload = %+v", payload)
+			}
+
+			_, _, err = ParseTableMode(tc.data, ParseStrict)
+			var anomaly Anomaly
+			if !errors.As(err, &anomaly) || !strings.Contains(anomaly.Message, tc.want) {
+				t.Fatalf("strict err = %v, want anomaly containing %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseTableModeLenientKeepsBlankRate(t *testing.T) {
+	data := anomalyDoc("XTbML", `<Table><Values><Axis><Y t="40">0.1</Y><Y t="41">-</Y></Axis></Values></Table>`)
+	payload, _, err := ParseTableMode(data, ParseLenient)
+	if err != nil {
+		t.Fatalf("ParseTableMode() err = %v", err)
+	}
+	rates := payload.Tables[0].Rates
+	if len(rates) != 2 || rates[1].Age != 41 || rates[1].Rate != nil {
+		t.Fatalf("rates = %+v, want age 41 blank", rates)
+	}
+}
+
+func TestParseTableModeCleanInput(t *testing.T) {
+	data := anomalyDoc("XTbML", `<Table><Values><Axis><Y t="40">0.1</Y></Axis></Values></Table>`)
+	for _, mode := range []ParseMode{ParseLenient, ParseStrict} {
+		payload, warnings, err := ParseTableMode(data, mode)
+		if err != nil || len(warnings) != 0 || payload.Version != "1.0" {
+			t.Fatalf("%s: payload = %+v, warnings = %v, err = %v", mode, payload, warnings, err)
+		}
+	}
+}
+
+func TestParseTableModeMissingVersion(t *testing.T) {
+	data := []byte(`<XTbML>` + string(anomalyDoc("", `<Table><Values><Axis><Y t="40">0.1</Y></Axis></Values></Table>`)) + `</XTbML>`)
+	payload, warnings, err := ParseTableMode(data, ParseLenient)
+	if err != nil || payload.Version != "unknown" {
+		t.Fatalf("lenient: payload = %+v, err = %v", payload, err)
+	}
+	if len(warnings) != 1 || !errors.Is(warnings[0], ErrMissingVersion) {
+		t.Fatalf("lenient warnings = %+v, want a missing version warning", warnings)
+	}
+
+	_, _, err = ParseTableMode(data, ParseStrict)
+	if !errors.Is(err, ErrMissingVersion) {
+		t.Fatalf("strict err = %v, want ErrMissingVersion", err)
+	}
+}
+
+func TestConvertXTbmlIsLenientByDefault(t *testing.T) {
+	data := anomalyDoc("XTbML", `<Table><Values><Axis><Y t="40">0.1</Y><Y t="41">n/a</Y></Axis></Values></Table>`)
+	if _, warnings, err := convertFromBytes(data, ConvertOptions{}); err != nil || len(warnings) != 1 {
+		t.Fatalf("zero ConvertOptions: warnings = %+v, err = %v; want one warning", warnings, err)
+	}
+	if _, err := ConvertXTbml(bytes.NewReader(data)); err != nil {
+		t.Fatalf("ConvertXTbml() err = %v, want lenient conversion", err)
+	}
+	if _, err := ParseTable(data); err != nil {
+		t.Fatalf("ParseTable() err = %v, want lenient parsing", err)
+	}
+}
@@ -1,6 +1,7 @@
 package xtbml
 
 import (
+	"bytes"
 	"encoding/xml"
 	"fmt"
 	"io"
@@ -19,7 +20,7 @@ type RatePoint struct {
 // ParseRates reads XTbML <Values> blocks, supporting both single-axis and nested axes.
 func ParseRates(r io.Reader) ([]RatePoint, error) {
 	dec := xml.NewDecoder(r)
-	parser := newRateParser()
+	parser := newRateParser(&anomalyLog{mode: ParseStrict, dec: dec})
 
 	for {
 		tok, err := dec.Token()
@@ -37,33 +38,75 @@ func ParseRates(r io.Reader) ([]RatePoint, error) {
 	return parser.result()
 }
 
+// attrInt returns the integer value of the attribute called name, matched
+// case-insensitively. Rate entries carry a single lower-case t attribute, so
+// an exact match is tried before EqualFold.
 func attrInt(attrs []xml.Attr, name string) (int, bool) {
-	for _, attr := range attrs {
-		if strings.EqualFold(attr.Name.Local, name) {
-			val, err := strconv.Atoi(strings.TrimSpace(attr.Value))
-			if err != nil {
-				return 0, false
-			}
-			return val, true
+	for i := range attrs {
+		attr := &attrs[i]
+		if attr.Name.Local != name && !strings.EqualFold(attr.Name.Local, name) {
+			continue
+		}
+		val, err := strconv.Atoi(attr.Value)
+		if err != nil {
+			val, err = strconv.Atoi(strings.TrimSpace(attr.Value))
 		}
+		return val, err == nil
 	}
 	return 0, false
 }
 
-func intPtr(v int) *int {
-	p := new(int)
-	*p = v
-	return p
+// lowerName returns the lower-case form of an element name. The names the
+// parsers match on are recognised without allocating; anything else falls
+// back to strings.ToLower.
+func lowerName(local string) string {
+	switch local {
+	case "Y":
+		return "y"
+	case "Axis":
+		return "axis"
+	case "Values":
+		return "values"
+	case "Table":
+		return "table"
+	case "MetaData":
+		return "metadata"
+	case "ContentClassification":
+		return "contentclassification"
+	case "XTbML":
+		return "xtbml"
+	}
+	return strings.ToLower(local)
 }
 
-func floatPtr(v float64) *float64 {
-	p := new(float64)
-	*p = v
-	return p
+// slabSize is the number of values per slab block.
+const slabSize = 256
+
+// slab hands out pointers into preallocated blocks, so the optional ages and
+// rates of a table's cells share one allocation per block instead of one
+// each.
+type slab[T any] struct {
+	block []T
+}
+
+func (s *slab[T]) ptr(v T) *T {
+	if len(s.block) == cap(s.block) {
+		s.block = make([]T, 0, slabSize)
+	}
+	s.block = append(s.block, v)
+	return &s.block[len(s.block)-1]
 }
 
 type rateParser struct {
+	log *anomalyLog
+	// emit receives each rate as it is decoded; when nil, rates collect in
+	// points.
+	emit          func(RatePoint) error
 	points        []RatePoint
+	count         int
+	ints          slab[int]
+	floats        slab[float64]
+	text          []byte
 	tableIndex    int
 	inValues      bool
 	axisDepth     int
@@ -71,23 +114,24 @@ type rateParser struct {
 	currentAge    int
 }
 
-func newRateParser() *rateParser {
-	return &rateParser{tableIndex: -1}
+func newRateParser(log *anomalyLog) *rateParser {
+	return &rateParser{log: log, tableIndex: -1}
 }
 
 func (rp *rateParser) consume(dec *xml.Decoder, tok xml.Token) error {
 	switch t := tok.(type) {
 	case xml.StartElement:
-		name := strings.ToLower(t.Name.Local)
+		name := lowerName(t.Name.Local)
 		switch name {
 		case "table":
 			rp.tableIndex++
 		case "values":
-			if rp.tableIndex >= 0 {
-				rp.inValues = true
-				rp.axisDepth = 0
-				rp.hasCurrentAge = false
+			if rp.tableIndex < 0 {
+				return rp.log.report("Values outside a Table ignored")
 			}
+			rp.inValues = true
+			rp.axisDepth = 0
+			rp.hasCurrentAge = false
 		case "axis":
 			if !rp.inValues {
 				return nil
@@ -115,18 +159,21 @@ func (rp *rateParser) consume(dec *xml.Decoder, tok xml.Token) error {
 
 func (rp *rateParser) decodeRateEntry(dec *xml.Decoder, start xml.StartElement) error {
 	valueAttr, hasAttr := attrInt(start.Attr, "t")
-	var text string
-	if err := dec.DecodeElement(&text, &start); err != nil {
-		return fmt.Errorf("decode rate entry: %w", err)
+	raw, err := rp.readText(dec)
+	if err != nil {
+		return err
 	}
-	text = strings.TrimSpace(text)
 	var ratePtr *float64
-	if text != "" {
+	if len(raw) > 0 {
+		text := string(raw)
 		rate, err := strconv.ParseFloat(text, 64)
 		if err != nil {
-			return fmt.Errorf("parse rate: %w", err)
+			if err := rp.log.report("rate %q is not a number; recorded as blank", text); err != nil {
+				return err
+			}
+		} else {
+			ratePtr = rp.floats.ptr(rate)
 		}
-		ratePtr = floatPtr(rate)
 	}
 
 	var (
@@ -136,31 +183,60 @@ func (rp *rateParser) decodeRateEntry(dec *xml.Decoder, start xml.StartElement)
 
 	if rp.axisDepth > 1 {
 		if !rp.hasCurrentAge {
-			return fmt.Errorf("nested axis missing age identifier")
+			return rp.log.report("nested axis missing age identifier; rate dropped")
 		}
 		if !hasAttr {
-			return fmt.Errorf("nested axis missing duration identifier")
+			return rp.log.report("nested axis missing duration identifier; rate dropped")
 		}
 		age = rp.currentAge
-		duration = intPtr(valueAttr)
+		duration = rp.ints.ptr(valueAttr)
 	} else {
 		if !hasAttr {
-			return fmt.Errorf("rate entry missing age identifier")
+			return rp.log.report("rate entry missing age identifier; rate dropped")
 		}
 		age = valueAttr
 	}
 
-	rp.points = append(rp.points, RatePoint{
+	point := RatePoint{
 		Table:    rp.tableIndex,
 		Age:      age,
 		Duration: duration,
 		Rate:     ratePtr,
-	})
+	}
+	rp.count++
+	if rp.emit != nil {
+		return rp.emit(point)
+	}
+	rp.points = append(rp.points, point)
 	return nil
 }
 
+// readText returns the trimmed character data of the element just opened and
+// consumes its end tag, skipping any nested elements as DecodeElement would.
+// It replaces DecodeElement for rates, which reflects on every cell. The
+// result is only valid until the next call.
+func (rp *rateParser) readText(dec *xml.Decoder) ([]byte, error) {
+	rp.text = rp.text[:0]
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("decode rate entry: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			rp.text = append(rp.text, t...)
+		case xml.StartElement:
+			if err := dec.Skip(); err != nil {
+				return nil, fmt.Errorf("decode rate entry: %w", err)
+			}
+		case xml.EndElement:
+			return bytes.TrimSpace(rp.text), nil
+		}
+	}
+}
+
 func (rp *rateParser) handleEndElement(end xml.EndElement) {
-	name := strings.ToLower(end.Name.Local)
+	name := lowerName(end.Name.Local)
 	switch name {
 	case "axis":
 		if rp.inValues && rp.axisDepth > 0 {
@@ -177,7 +253,7 @@ func (rp *rateParser) handleEndElement(end xml.EndElement) {
 }
 
 func (rp *rateParser) result() ([]RatePoint, error) {
-	if len(rp.points) == 0 {
+	if rp.count == 0 {
 		return nil, fmt.Errorf("no rate data found")
 	}
 	return rp.points, nil
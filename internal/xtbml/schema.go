@@ -0,0 +1,92 @@
+package xtbml
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"mort/schemas"
+)
+
+// SchemaViolation is a single schema failure located by a JSON pointer.
+type SchemaViolation struct {
+	Pointer string
+	Message string
+}
+
+func (v SchemaViolation) String() string {
+	pointer := v.Pointer
+	if pointer == "" {
+		pointer = "/"
+	}
+	return pointer + ": " + v.Message
+}
+
+// SchemaError lists every violation found while validating a payload.
+type SchemaError struct {
+	Violations []SchemaViolation
+}
+
+func (e *SchemaError) Error() string {
+	parts := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		parts[i] = v.String()
+	}
+	return "schema validation failed: " + strings.Join(parts, "; ")
+}
+
+var compileSchema = sync.OnceValues(func() (*jsonschema.Schema, error) {
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(schemas.XTbMLURL, bytes.NewReader(schemas.XTbML)); err != nil {
+		return nil, fmt.Errorf("load schema: %w", err)
+	}
+	schema, err := compiler.Compile(schemas.XTbMLURL)
+	if err != nil {
+		return nil, fmt.Errorf("compile schema: %w", err)
+	}
+	return schema, nil
+})
+
+// ValidateJSON checks raw converter output against the published JSON schema.
+// Violations are returned as a *SchemaError.
+func ValidateJSON(raw []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var payload any
+	if err := dec.Decode(&payload); err != nil {
+		return fmt.Errorf("decode json: %w", err)
+	}
+	return validatePayload(payload)
+}
+
+func validatePayload(payload any) error {
+	schema, err := compileSchema()
+	if err != nil {
+		return err
+	}
+	err = schema.Validate(payload)
+	if err == nil {
+		return nil
+	}
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		return err
+	}
+	return &SchemaError{Violations: collectViolations(verr, nil)}
+}
+
+// collectViolations flattens the validator's error tree into its leaves.
+func collectViolations(verr *jsonschema.ValidationError, out []SchemaViolation) []SchemaViolation {
+	if len(verr.Causes) == 0 {
+		return append(out, SchemaViolation{Pointer: verr.InstanceLocation, Message: verr.Message})
+	}
+	for _, cause := range verr.Causes {
+		out = collectViolations(cause, out)
+	}
+	return out
+}
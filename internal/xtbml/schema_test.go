@@ -1,40 +1,68 @@
 package xtbml
 
 import (
-	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
-
-	"github.com/santhosh-tekuri/jsonschema/v5"
 )
 
 func TestSampleJSONMatchesSchema(t *testing.T) {
-	schemaPath := filepath.Join("..", "..", "schemas", "xtbml.schema.json")
 	jsonPath := filepath.Join("testdata", "json", "table_small.json")
 
-	absSchema, err := filepath.Abs(schemaPath)
+	raw, err := os.ReadFile(jsonPath)
 	if err != nil {
-		t.Fatalf("abs schema: %v", err)
+		t.Fatalf("read json: %v", err)
 	}
-	schemaURL := "file://" + filepath.ToSlash(absSchema)
 
-	schema, err := jsonschema.Compile(schemaURL)
-	if err != nil {
-		t.Fatalf("compile schema: %v", err)
+	if err := ValidateJSON(raw); err != nil {
+		t.Fatalf("schema validation failed: %v", err)
 	}
+}
 
-	raw, err := os.ReadFile(jsonPath)
-	if err != nil {
-		t.Fatalf("read json: %v", err)
-	}
+func TestValidateJSONReportsPointers(t *testing.T) {
+	raw := []byte(`{
+  "identifier": "Bad Identifier",
+  "version": "1.3",
+  "classification": { "tableName": "" },
+  "tables": [ { "index": 0, "rates": [ { "age": -1, "rate": 0.1 } ] } ]
+}`)
 
-	var payload any
-	if err := json.Unmarshal(raw, &payload); err != nil {
-		t.Fatalf("unmarshal payload: %v", err)
+	err := ValidateJSON(raw)
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("ValidateJSON() error = %v, want *SchemaError", err)
+	}
+	want := map[string]bool{
+		"/identifier":               false,
+		"/classification/tableName": false,
+		"/tables/0/rates/0/age":     false,
+	}
+	for _, v := range schemaErr.Violations {
+		if _, ok := want[v.Pointer]; ok {
+			want[v.Pointer] = true
+		}
 	}
+	for pointer, seen := range want {
+		if !seen {
+			t.Fatalf("missing violation for %s in %v", pointer, schemaErr)
+		}
+	}
+}
 
-	if err := schema.Validate(payload); err != nil {
+func TestConvertedAccentedIdentifierMatchesSchema(t *testing.T) {
+	xml := `<XTbML version="1.3"><ContentClassification><TableName>AVÖ 1996 R - Männer</TableName></ContentClassification>` +
+		`<Table><Values><Axis><Y t="40">0.1</Y></Axis></Values></Table></XTbML>`
+
+	out, err := ConvertXTbml(strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("ConvertXTbml() error = %v", err)
+	}
+	if !strings.Contains(string(out), `"identifier": "avö_1996_r_männer"`) {
+		t.Fatalf("identifier not kept as normalized: %s", out)
+	}
+	if err := ValidateJSON(out); err != nil {
 		t.Fatalf("schema validation failed: %v", err)
 	}
 }
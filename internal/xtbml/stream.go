@@ -0,0 +1,328 @@
+package xtbml
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ConvertStream converts the XTbML document read from r and writes its JSON
+// payload to w as the rates are decoded, so memory stays bounded by the
+// largest metadata block rather than by the number of cells. The output is
+// byte-for-byte what ConvertXTbmlWithOptions produces, in either layout.
+//
+// Streaming needs the ContentClassification before the first rate and each
+// table's MetaData before its Values, as every published table has them. On
+// error, w may hold a partial payload. Extensions need a second pass over the
+// input, so with opts.Extensions the document is buffered and converted in
+// memory.
+func ConvertStream(r io.Reader, w io.Writer, opts ConvertOptions) ([]Anomaly, error) {
+	_, warnings, err := convertStream(r, w, opts)
+	return warnings, err
+}
+
+// convertStream mirrors ConvertStream and also returns the payload it wrote.
+// Unless opts.Extensions buffered the document, the payload is an outline
+// with the classification and table metadata but no rates.
+func convertStream(r io.Reader, w io.Writer, opts ConvertOptions) (*ConvertedTable, []Anomaly, error) {
+	if opts.Extensions {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, nil, fmt.Errorf("read input: %w", err)
+		}
+		payload, out, warnings, err := convertPayload(data, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, err := w.Write(out); err != nil {
+			return nil, nil, fmt.Errorf("write json: %w", err)
+		}
+		return payload, warnings, nil
+	}
+
+	walker := newDocumentWalker(r, opts.Mode)
+	sw := &streamWriter{
+		w:          bufio.NewWriter(w),
+		walker:     walker,
+		canonical:  opts.Canonical,
+		identifier: opts.Identifier,
+		open:       -1,
+	}
+	walker.onTable = sw.startTable
+	walker.onMeta = sw.setMeta
+	walker.rates.emit = sw.writeRate
+	if err := walker.run(); err != nil {
+		return nil, nil, err
+	}
+	if err := sw.finish(); err != nil {
+		return nil, nil, err
+	}
+	return &sw.outline, walker.log.found, nil
+}
+
+// convertFileStream mirrors ConvertFileReport through ConvertStream. Output
+// goes to a temporary file renamed over dstPath on success, so a failed
+// conversion never leaves a partial payload behind. The payload is not
+// validated against the schema, which would need it in memory.
+func convertFileStream(srcPath, dstPath string, opts ConvertOptions) (*ConvertedTable, []Anomaly, error) {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read %s: %w", srcPath, err)
+	}
+	defer src.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(dstPath), "."+filepath.Base(dstPath)+".*")
+	if err != nil {
+		return nil, nil, fmt.Errorf("write %s: %w", dstPath, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	payload, warnings, err := convertStream(bufio.NewReader(src), tmp, opts)
+	if err != nil {
+		tmp.Close()
+		return nil, nil, fmt.Errorf("convert %s: %w", srcPath, err)
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return nil, nil, fmt.Errorf("write %s: %w", dstPath, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, nil, fmt.Errorf("write %s: %w", dstPath, err)
+	}
+	if err := os.Rename(tmp.Name(), dstPath); err != nil {
+		return nil, nil, fmt.Errorf("write %s: %w", dstPath, err)
+	}
+	return payload, warnings, nil
+}
+
+// streamWriter writes the payload layout of EncodeTable, or of
+// EncodeCanonical when canonical is set, one piece at a time. Like the
+// in-memory path it omits tables without rates.
+type streamWriter struct {
+	w          *bufio.Writer
+	walker     *documentWalker
+	canonical  bool
+	identifier string
+
+	headerDone bool
+	tables     int
+	// meta is the metadata of the table being read; open is the index of
+	// the table whose rates are being written, or -1.
+	meta  TableMeta
+	open  int
+	rates int
+	buf   []byte
+	// outline collects the classification and table metadata written so
+	// far, without rates.
+	outline ConvertedTable
+}
+
+func (s *streamWriter) startTable(index int) error {
+	s.closeTable()
+	s.meta = TableMeta{}
+	return nil
+}
+
+func (s *streamWriter) setMeta(index int, meta TableMeta) error {
+	if s.open == index {
+		return fmt.Errorf("table %d: MetaData after Values cannot be streamed", index)
+	}
+	s.meta = meta
+	return nil
+}
+
+func (s *streamWriter) writeRate(point RatePoint) error {
+	if point.Table != s.open {
+		if err := s.openTable(point.Table); err != nil {
+			return err
+		}
+	}
+	if point.Rate != nil && (math.IsNaN(*point.Rate) || math.IsInf(*point.Rate, 0)) {
+		return fmt.Errorf("encode json: unsupported value: %v", *point.Rate)
+	}
+
+	const depth = 4
+	b := s.buf[:0]
+	if s.rates > 0 {
+		b = append(b, ",\n"...)
+	}
+	b = appendIndent(b, depth)
+	if s.canonical {
+		b = append(b, `{ "age": `...)
+		b = strconv.AppendInt(b, int64(point.Age), 10)
+		if point.Duration != nil {
+			b = append(b, `, "duration": `...)
+			b = strconv.AppendInt(b, int64(*point.Duration), 10)
+		}
+		b = append(b, `, "rate": `...)
+		if point.Rate == nil {
+			b = append(b, "null"...)
+		} else {
+			b = strconv.AppendFloat(b, *point.Rate, 'f', -1, 64)
+		}
+		b = append(b, " }"...)
+	} else {
+		b = append(b, "{\n"...)
+		b = appendIndent(b, depth+1)
+		b = append(b, `"age": `...)
+		b = strconv.AppendInt(b, int64(point.Age), 10)
+		b = append(b, ",\n"...)
+		if point.Duration != nil {
+			b = appendIndent(b, depth+1)
+			b = append(b, `"duration": `...)
+			b = strconv.AppendInt(b, int64(*point.Duration), 10)
+			b = append(b, ",\n"...)
+		}
+		b = appendIndent(b, depth+1)
+		b = append(b, `"rate": `...)
+		if point.Rate == nil {
+			b = append(b, "null"...)
+		} else {
+			b = appendJSONFloat(b, *point.Rate)
+		}
+		b = append(b, '\n')
+		b = appendIndent(b, depth)
+		b = append(b, '}')
+	}
+	s.buf = b
+	s.rates++
+	_, err := s.w.Write(b)
+	return err
+}
+
+// openTable starts the JSON object of a rate table, writing the payload
+// header first if this is the first table.
+func (s *streamWriter) openTable(index int) error {
+	s.closeTable()
+	if err := s.writeHeader(); err != nil {
+		return err
+	}
+	if s.tables > 0 {
+		s.w.WriteString(",\n")
+	}
+	s.w.WriteString("    {\n      \"index\": ")
+	s.w.WriteString(strconv.Itoa(index))
+	s.w.WriteString(",\n      \"metadata\": ")
+	meta := metaPayload(s.meta)
+	normalizeMetaVocabulary(meta)
+	if err := s.writeValue(meta, 3); err != nil {
+		return err
+	}
+	s.outline.Tables = append(s.outline.Tables, TablePayload{Index: index, Metadata: meta})
+	s.w.WriteString(",\n      \"rates\": [\n")
+	s.tables++
+	s.open = index
+	s.rates = 0
+	return nil
+}
+
+func (s *streamWriter) closeTable() {
+	if s.open < 0 {
+		return
+	}
+	s.w.WriteString("\n      ]\n    }")
+	s.open = -1
+}
+
+func (s *streamWriter) writeHeader() error {
+	if s.headerDone {
+		return nil
+	}
+	class := s.walker.classification
+	if class == nil {
+		return fmt.Errorf("ContentClassification must precede the rates to stream")
+	}
+	identifier := s.identifier
+	if identifier == "" {
+		identifier = NormalizeIdentifier(class.TableName)
+	}
+	classification := toClassificationPayload(class)
+	classification.ContentType = canonicalValue(VocabularyContentType, classification.ContentType)
+	if classification.Keywords == nil {
+		classification.Keywords = []string{}
+	}
+
+	s.w.WriteString("{\n  \"schemaVersion\": ")
+	s.w.WriteString(strconv.Itoa(CurrentSchemaVersion))
+	for _, field := range []struct {
+		key   string
+		value any
+	}{
+		{"identifier", identifier},
+		{"version", s.walker.version},
+		{"classification", classification},
+	} {
+		s.w.WriteString(",\n  \"" + field.key + "\": ")
+		if err := s.writeValue(field.value, 1); err != nil {
+			return err
+		}
+	}
+	s.w.WriteString(",\n  \"tables\": [\n")
+	s.outline = ConvertedTable{SchemaVersion: CurrentSchemaVersion, Identifier: identifier, Version: s.walker.version, Classification: classification}
+	s.headerDone = true
+	return nil
+}
+
+func (s *streamWriter) finish() error {
+	s.closeTable()
+	if !s.headerDone {
+		return fmt.Errorf("no rate data found")
+	}
+	s.w.WriteString("\n  ]\n}\n")
+	return s.w.Flush()
+}
+
+// writeValue writes v as it would appear nested depth levels deep in the
+// payload.
+func (s *streamWriter) writeValue(v any, depth int) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encode json: %w", err)
+	}
+	var out bytes.Buffer
+	if s.canonical {
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		node, err := readJSONNode(dec)
+		if err != nil {
+			return fmt.Errorf("encode json: %w", err)
+		}
+		node.write(&out, depth)
+	} else if err := json.Indent(&out, raw, strings.Repeat("  ", depth), "  "); err != nil {
+		return fmt.Errorf("encode json: %w", err)
+	}
+	_, err = s.w.Write(out.Bytes())
+	return err
+}
+
+func appendIndent(b []byte, depth int) []byte {
+	for range depth {
+		b = append(b, "  "...)
+	}
+	return b
+}
+
+// appendJSONFloat formats f the way encoding/json does: fixed-point, or an
+// exponent for very small and very large magnitudes.
+func appendJSONFloat(b []byte, f float64) []byte {
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	b = strconv.AppendFloat(b, f, format, -1, 64)
+	if format == 'e' {
+		// Shorten e-09 to e-9, as encoding/json does.
+		if n := len(b); n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	return b
+}
@@ -0,0 +1,108 @@
+package xtbml
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestConvertStreamMatchesInMemory(t *testing.T) {
+	fixtures := []string{"table_small", "table_select", "table_meta", "basic_version"}
+	docs := map[string][]byte{
+		"two tables with a gap": anomalyDoc("XTbML", `<Table><Values><Axis><Y t="1">0.1</Y></Axis></Values></Table>`+
+			`<Table><MetaData><Nation tc="1">USA</Nation></MetaData></Table>`+
+			`<Table><Values><Axis t="30"><Y t="1">1e-9</Y><Y t="2"></Y></Axis></Values><Values><Axis><Y t="5">2.5e21</Y></Axis></Values></Table>`),
+		"escaped text": []byte(`<XTbML><ContentClassification><TableName>A &amp; B &lt;select&gt;</TableName><KeyWord>x</KeyWord></ContentClassification>` +
+			`<Table><Values><Axis><Y t="0">-0</Y></Axis></Values></Table></XTbML>`),
+	}
+	for _, name := range fixtures {
+		data, err := os.ReadFile(filepath.Join("testdata", name+".xml"))
+		if err != nil {
+			t.Fatalf("read fixture: %v", err)
+		}
+		docs[name] = data
+	}
+
+	names := make([]string, 0, len(docs))
+	for name := range docs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, opts := range []ConvertOptions{{}, {Canonical: true}, {Identifier: "renamed"}} {
+			want, wantErr := ConvertXTbmlWithOptions(bytes.NewReader(docs[name]), opts)
+			var got bytes.Buffer
+			_, err := ConvertStream(bytes.NewReader(docs[name]), &got, opts)
+			if (err != nil) != (wantErr != nil) {
+				t.Fatalf("%s %+v: stream err = %v, in-memory err = %v", name, opts, err, wantErr)
+			}
+			if wantErr == nil && !bytes.Equal(got.Bytes(), want) {
+				t.Fatalf("%s %+v: stream output differs:\n%s", name, opts, lineDiff(string(want), got.String()))
+			}
+		}
+	}
+}
+
+func TestConvertStreamErrors(t *testing.T) {
+	cases := map[string]struct {
+		data []byte
+		want string
+	}{
+		"classification after rates": {
+			[]byte(`<XTbML version="1.0"><Table><Values><Axis><Y t="1">0.1</Y></Axis></Values></Table><ContentClassification><TableName>Late</TableName></ContentClassification></XTbML>`),
+			"must precede",
+		},
+		"metadata after values": {
+			anomalyDoc("XTbML", `<Table><Values><Axis><Y t="1">0.1</Y></Axis></Values><MetaData/></Table>`),
+			"MetaData after Values",
+		},
+		"no rates": {anomalyDoc("XTbML", `<Table><MetaData/></Table>`), "no rate data"},
+		"strict anomaly": {
+			anomalyDoc("XTbML", `<Table><Values><Axis><Y t="1">n/a</Y></Axis></Values></Table>`),
+			"not a number",
+		},
+	}
+	for name, tc := range cases {
+		var out bytes.Buffer
+		_, err := ConvertStream(bytes.NewReader(tc.data), &out, ConvertOptions{Mode: ParseStrict})
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: err = %v, want %q", name, err, tc.want)
+		}
+	}
+}
+
+func TestConvertFileReportStream(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "odd.xml")
+	doc := anomalyDoc("XTbML", `<Table><Values><Axis><Y t="1">0.1</Y><Y t="2">n/a</Y></Axis></Values></Table>`)
+	if err := os.WriteFile(src, doc, 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	dst := filepath.Join(dir, "odd.json")
+	warnings, err := ConvertFileReport(src, dst, ConvertOptions{Stream: true, Mode: ParseLenient})
+	if err != nil || len(warnings) != 1 {
+		t.Fatalf("ConvertFileReport() = %v, %v", warnings, err)
+	}
+	out, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	if err := ValidateJSON(out); err != nil {
+		t.Fatalf("streamed output invalid: %v", err)
+	}
+
+	failed := filepath.Join(dir, "failed.json")
+	if _, err := ConvertFileReport(src, failed, ConvertOptions{Stream: true, Mode: ParseStrict}); err == nil {
+		t.Fatal("strict stream conversion succeeded")
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if entry.Name() != "odd.xml" && entry.Name() != "odd.json" {
+			t.Fatalf("failed conversion left %s behind", entry.Name())
+		}
+	}
+}
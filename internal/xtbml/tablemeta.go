@@ -46,7 +46,7 @@ func ParseTableMetas(r io.Reader) ([]TableMeta, error) {
 		if !ok {
 			continue
 		}
-		switch strings.ToLower(start.Name.Local) {
+		switch lowerName(start.Name.Local) {
 		case "table":
 			tableIndex++
 			metas = append(metas, TableMeta{})
@@ -0,0 +1,204 @@
+package xtbml
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Vocabulary names an XTbML code list whose values carry a tc code and a
+// label.
+type Vocabulary string
+
+const (
+	VocabularyContentType Vocabulary = "contentType"
+	VocabularyNation      Vocabulary = "nation"
+	VocabularyDataType    Vocabulary = "dataType"
+	VocabularyScaleType   Vocabulary = "scaleType"
+)
+
+// codeLists maps tc codes to canonical labels. Labels in the source XML drift
+// ("CSO / CET" and "CSO/CET" both use code 85) while the codes stay stable, so
+// the converter labels values by code. Where the library uses more than one
+// spelling, the canonical label is the most common one.
+var codeLists = map[Vocabulary]map[string]string{
+	VocabularyContentType: {
+		"1":  "Healthy Lives Mortality",
+		"2":  "Disabled Lives Mortality",
+		"3":  "Generational Mortality",
+		"4":  "Insured Lives Mortality",
+		"5":  "Termination Voluntary",
+		"8":  "Disability Recovery",
+		"14": "Remarriage",
+		"18": "Premium Persistency",
+		"22": "Projection Scale",
+		"50": "Claim Cost (in Disability)",
+		"57": "Life Table",
+		"77": "ADB, AD&D",
+		"78": "Annuitant Mortality",
+		"80": "Claim Incidence",
+		"82": "Claim Termination",
+		"83": "Group Life",
+		"84": "Population Mortality",
+		"85": "CSO/CET",
+		"86": "Selection Factors",
+	},
+	VocabularyNation: {
+		"1": "United States of America",
+	},
+	VocabularyDataType: {
+		"2": "Floating Point",
+	},
+	VocabularyScaleType: {
+		"1": "Dates",
+		"2": "Ordinal Date",
+		"3": "Age",
+	},
+}
+
+// catchAllCodes are codes that only say a value is outside the code list, as
+// scale type 0 does for "Month", "Year" and "Unknown". The source label is
+// their only description, so they are known codes but never relabelled.
+var catchAllCodes = map[Vocabulary]map[string]bool{
+	VocabularyScaleType: {"0": true},
+}
+
+// labelAliases maps misspelt source labels to their canonical spelling. They
+// apply to values without a canonical label for their code, catch-all codes
+// included.
+var labelAliases = map[Vocabulary]map[string]string{
+	VocabularyScaleType: {"Ordinali Date": "Ordinal Date"},
+}
+
+// CanonicalLabel returns the canonical label for code in vocab and whether the
+// code has one. Catch-all codes have none.
+func CanonicalLabel(vocab Vocabulary, code string) (string, bool) {
+	label, ok := codeLists[vocab][strings.TrimSpace(code)]
+	return label, ok
+}
+
+// knownCode reports whether code is registered in vocab, either with a
+// canonical label or as a catch-all.
+func knownCode(vocab Vocabulary, code string) bool {
+	code = strings.TrimSpace(code)
+	_, ok := codeLists[vocab][code]
+	return ok || catchAllCodes[vocab][code]
+}
+
+// canonicalValue relabels v by its code, or by its label when the code has no
+// canonical label, keeping the source label in RawLabel when it differs.
+// Other values are returned unchanged.
+func canonicalValue(vocab Vocabulary, v ClassifiedValuePayload) ClassifiedValuePayload {
+	label, ok := CanonicalLabel(vocab, v.Code)
+	if !ok {
+		label, ok = labelAliases[vocab][strings.TrimSpace(v.Label)]
+	}
+	if !ok || v.Label == label {
+		return v
+	}
+	if v.RawLabel == "" && v.Label != "" {
+		v.RawLabel = v.Label
+	}
+	v.Label = label
+	return v
+}
+
+// NormalizeVocabulary replaces the labels of every classified value in table
+// with the canonical label for its code. The converter and importers call it
+// before encoding; it is idempotent.
+func NormalizeVocabulary(table *ConvertedTable) {
+	if table.Classification != nil {
+		table.Classification.ContentType = canonicalValue(VocabularyContentType, table.Classification.ContentType)
+	}
+	for i := range table.Tables {
+		if meta := table.Tables[i].Metadata; meta != nil {
+			normalizeMetaVocabulary(meta)
+		}
+	}
+}
+
+func normalizeMetaVocabulary(meta *TableMetaPayload) {
+	meta.Nation = canonicalValue(VocabularyNation, meta.Nation)
+	meta.DataType = canonicalValue(VocabularyDataType, meta.DataType)
+	for i := range meta.Axes {
+		meta.Axes[i].ScaleType = canonicalValue(VocabularyScaleType, meta.Axes[i].ScaleType)
+	}
+}
+
+// UnknownCode is a classified value whose code is not in the registry. Path
+// locates it in the payload, e.g. "tables[0].metadata.axes[1].scaleType".
+type UnknownCode struct {
+	Vocabulary Vocabulary `json:"vocabulary"`
+	Path       string     `json:"path"`
+	Code       string     `json:"code"`
+	Label      string     `json:"label"`
+}
+
+// UnknownCodes lists the classified values in table with a code the registry
+// does not know. Values without a code are not reported.
+func UnknownCodes(table *ConvertedTable) []UnknownCode {
+	var unknown []UnknownCode
+	check := func(vocab Vocabulary, path string, v ClassifiedValuePayload) {
+		if strings.TrimSpace(v.Code) == "" {
+			return
+		}
+		if !knownCode(vocab, v.Code) {
+			unknown = append(unknown, UnknownCode{Vocabulary: vocab, Path: path, Code: v.Code, Label: v.Label})
+		}
+	}
+	if table.Classification != nil {
+		check(VocabularyContentType, "classification.contentType", table.Classification.ContentType)
+	}
+	for i, tp := range table.Tables {
+		if tp.Metadata == nil {
+			continue
+		}
+		prefix := fmt.Sprintf("tables[%d].metadata.", i)
+		check(VocabularyNation, prefix+"nation", tp.Metadata.Nation)
+		check(VocabularyDataType, prefix+"dataType", tp.Metadata.DataType)
+		for j, axis := range tp.Metadata.Axes {
+			check(VocabularyScaleType, fmt.Sprintf("%saxes[%d].scaleType", prefix, j), axis.ScaleType)
+		}
+	}
+	return unknown
+}
+
+// normalizeVocabularyDoc applies NormalizeVocabulary to a decoded payload
+// document during migration.
+func normalizeVocabularyDoc(doc map[string]any) error {
+	relabel := func(vocab Vocabulary, node any) {
+		obj, ok := node.(map[string]any)
+		if !ok {
+			return
+		}
+		code, _ := obj["code"].(string)
+		rawLabel, _ := obj["label"].(string)
+		existing, _ := obj["rawLabel"].(string)
+		v := canonicalValue(vocab, ClassifiedValuePayload{Code: code, Label: rawLabel, RawLabel: existing})
+		if v.Label != rawLabel {
+			obj["label"] = v.Label
+		}
+		if v.RawLabel != existing {
+			obj["rawLabel"] = v.RawLabel
+		}
+	}
+	if class, ok := doc["classification"].(map[string]any); ok {
+		relabel(VocabularyContentType, class["contentType"])
+	}
+	tables, _ := doc["tables"].([]any)
+	for _, t := range tables {
+		table, _ := t.(map[string]any)
+		meta, ok := table["metadata"].(map[string]any)
+		if !ok {
+			continue
+		}
+		relabel(VocabularyNation, meta["nation"])
+		relabel(VocabularyDataType, meta["dataType"])
+		axes, _ := meta["axes"].([]any)
+		for _, a := range axes {
+			if axis, ok := a.(map[string]any); ok {
+				relabel(VocabularyScaleType, axis["scaleType"])
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package xtbml
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeVocabulary(t *testing.T) {
+	table := &ConvertedTable{
+		Classification: &ClassificationPayload{ContentType: ClassifiedValuePayload{Code: "85", Label: "CSO / CET"}},
+		Tables: []TablePayload{{Metadata: &TableMetaPayload{
+			Nation:   ClassifiedValuePayload{Code: "1", Label: "United States of America"},
+			DataType: ClassifiedValuePayload{Code: "99", Label: "Fixed Point"},
+			Axes: []AxisDefinitionPayload{
+				{ScaleType: ClassifiedValuePayload{Code: "0", Label: "Ordinali Date"}},
+				{ScaleType: ClassifiedValuePayload{Code: "2", Label: ""}},
+				{ScaleType: ClassifiedValuePayload{Code: "0", Label: "Month"}},
+			},
+		}}},
+	}
+	NormalizeVocabulary(table)
+	NormalizeVocabulary(table)
+
+	meta := table.Tables[0].Metadata
+	got := []ClassifiedValuePayload{table.Classification.ContentType, meta.Nation, meta.DataType, meta.Axes[0].ScaleType, meta.Axes[1].ScaleType, meta.Axes[2].ScaleType}
+	want := []ClassifiedValuePayload{
+		{Code: "85", Label: "CSO/CET", RawLabel: "CSO / CET"},
+		{Code: "1", Label: "United States of America"},
+		{Code: "99", Label: "Fixed Point"},
+		{Code: "0", Label: "Ordinal Date", RawLabel: "Ordinali Date"},
+		{Code: "2", Label: "Ordinal Date"},
+		{Code: "0", Label: "Month"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("NormalizeVocabulary() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	unknown := UnknownCodes(table)
+	if len(unknown) != 1 || unknown[0] != (UnknownCode{Vocabulary: VocabularyDataType, Path: "tables[0].metadata.dataType", Code: "99", Label: "Fixed Point"}) {
+		t.Fatalf("UnknownCodes() = %+v", unknown)
+	}
+}
+
+func TestMigrateVersion2CanonicalizesLabels(t *testing.T) {
+	v2 := `{"schemaVersion": 2, "identifier": "cso", "version": "1.3",
+  "classification": { "tableIdentity": "7", "tableName": "CSO", "contentType": { "code": "85", "label": "CSO / CET" } },
+  "tables": [ { "index": 0, "metadata": { "axes": [ { "id": "Age", "axisName": "Age", "scaleType": { "code": "3", "label": "Age" } },
+    { "id": "Duration", "axisName": "Duration", "scaleType": { "code": "0", "label": "Month" } } ] } } ] }`
+	table, err := DecodeTable([]byte(v2))
+	if err != nil {
+		t.Fatalf("DecodeTable() error = %v", err)
+	}
+	if ct := table.Classification.ContentType; ct.Label != "CSO/CET" || ct.RawLabel != "CSO / CET" {
+		t.Fatalf("content type = %+v", ct)
+	}
+	if st := table.Tables[0].Metadata.Axes[0].ScaleType; st.RawLabel != "" {
+		t.Fatalf("scale type = %+v, want no raw label", st)
+	}
+	if st := table.Tables[0].Metadata.Axes[1].ScaleType; st.Label != "Month" || st.RawLabel != "" {
+		t.Fatalf("catch-all scale type = %+v, want the source label kept", st)
+	}
+}
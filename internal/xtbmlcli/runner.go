@@ -1,11 +1,14 @@
 package xtbmlcli
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
+	"os"
 	"path/filepath"
 
+	"mort/internal/tableimport"
 	"mort/internal/xtbml"
 )
 
@@ -16,16 +19,71 @@ func Run(args []string, stdout, stderr io.Writer) int {
 
 	src := fs.String("src", "xml", "directory containing XTbML XML files")
 	dst := fs.String("dst", "json", "directory for JSON output")
+	from := fs.String("from", "xtbml", "input format: xtbml, csv or fixed (csv/fixed read a <name>.meta.json sidecar)")
+
+	canonical := fs.Bool("canonical", false, "write canonical JSON (stable key order and float formatting)")
+	extensions := fs.Bool("extensions", false, "keep unmodelled XTbML elements and attributes under \"extensions\" in the output")
+	collisions := fs.Bool("collisions", false, "list every identifier collision and the identifiers assigned to resolve it")
+	migrate := fs.Bool("migrate", false, "upgrade JSON payloads in -dst to the current schema version in place and exit")
+	stream := fs.Bool("stream", false, "convert with bounded memory, writing JSON while reading XML (cannot be combined with -validate)")
+	validate := fs.Bool("validate", false, "check every converted file against the JSON schema before writing it")
+	strict := fs.Bool("strict", false, "fail on the first XTbML anomaly instead of converting with warnings")
+	warnings := fs.String("warnings", "", "write lenient-mode parse warnings as JSON to this file")
 
 	if err := fs.Parse(args); err != nil {
 		return 2
 	}
+	if *stream && *validate {
+		fmt.Fprintln(stderr, "-stream output cannot be schema-validated; drop -validate or -stream")
+		return 2
+	}
+
+	if *migrate {
+		var migrated int
+		err := xtbml.MigrateDirectory(*dst, func(path string) {
+			fmt.Fprintf(stdout, "Migrated %s\n", path)
+			migrated++
+		})
+		if err != nil {
+			fmt.Fprintf(stderr, "migration failed: %v\n", err)
+			return 1
+		}
+		if migrated == 0 {
+			fmt.Fprintln(stdout, "All JSON payloads are current.")
+		}
+		return 0
+	}
 
 	var converted int
-	err := xtbml.ConvertDirectoryWithObserver(*src, *dst, func(srcPath, dstPath string) {
+	observer := func(srcPath, dstPath string) {
 		fmt.Fprintf(stdout, "Converted %s -> %s\n", filepath.Base(srcPath), dstPath)
 		converted++
-	})
+	}
+
+	opts := xtbml.ConvertOptions{Canonical: *canonical, Extensions: *extensions, Stream: *stream, Validate: *validate}
+	if *strict {
+		opts.Mode = xtbml.ParseStrict
+	}
+	var err error
+	if *from == "xtbml" || *from == "xml" {
+		var report *xtbml.DirectoryReport
+		report, err = xtbml.ConvertDirectoryReport(*src, *dst, opts, observer)
+		if err == nil {
+			writeIdentifierReport(stdout, report, *collisions)
+			writeParseWarnings(stderr, report.Warnings)
+			writeUnknownCodes(stderr, report.UnknownCodes)
+			if *warnings != "" {
+				err = saveParseWarnings(*warnings, report.Warnings)
+			}
+		}
+	} else {
+		format, parseErr := tableimport.ParseFormat(*from)
+		if parseErr != nil {
+			fmt.Fprintln(stderr, parseErr)
+			return 2
+		}
+		err = tableimport.ImportDirectory(*src, *dst, format, opts, observer)
+	}
 	if err != nil {
 		fmt.Fprintf(stderr, "conversion failed: %v\n", err)
 		return 1
@@ -36,3 +94,61 @@ func Run(args []string, stdout, stderr io.Writer) int {
 	}
 	return 0
 }
+
+// writeIdentifierReport summarises identifier collisions and renames, listing
+// each collision when verbose is set.
+func writeIdentifierReport(w io.Writer, report *xtbml.DirectoryReport, verbose bool) {
+	if len(report.Collisions) > 0 {
+		tables := 0
+		for _, c := range report.Collisions {
+			tables += len(c.Tables)
+		}
+		fmt.Fprintf(w, "Resolved %d identifier collisions across %d tables.\n", len(report.Collisions), tables)
+	}
+	if verbose {
+		for _, c := range report.Collisions {
+			fmt.Fprintf(w, "%s:\n", c.Identifier)
+			for _, member := range c.Tables {
+				fmt.Fprintf(w, "  %s (table %s) -> %s\n", filepath.Base(member.File), member.TableIdentity, member.Identifier)
+			}
+		}
+	}
+	if len(report.Renamed) > 0 {
+		fmt.Fprintf(w, "Recorded %d renamed identifiers in %s.\n", len(report.Renamed), xtbml.AliasFileName)
+	}
+}
+
+// writeParseWarnings prints each anomaly tolerated in lenient mode.
+func writeParseWarnings(w io.Writer, warnings []xtbml.FileWarnings) {
+	for _, fw := range warnings {
+		for _, a := range fw.Warnings {
+			fmt.Fprintf(w, "warning: %s:%d: %s\n", filepath.Base(fw.File), a.Line, a.Message)
+		}
+	}
+}
+
+// writeUnknownCodes prints each classified value whose code is not in the
+// registry, as mort lint reports it.
+func writeUnknownCodes(w io.Writer, unknown []xtbml.FileUnknownCodes) {
+	for _, fu := range unknown {
+		for _, u := range fu.Unknown {
+			fmt.Fprintf(w, "warning: %s: %s code %q (%q) is not a registered %s code\n", filepath.Base(fu.File), u.Path, u.Code, u.Label, u.Vocabulary)
+		}
+	}
+}
+
+// saveParseWarnings writes the parse warnings report to path. An empty report
+// is written as an empty list so CI can check it unconditionally.
+func saveParseWarnings(path string, warnings []xtbml.FileWarnings) error {
+	if warnings == nil {
+		warnings = []xtbml.FileWarnings{}
+	}
+	data, err := json.MarshalIndent(warnings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode warnings: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write warnings: %w", err)
+	}
+	return nil
+}
@@ -2,9 +2,13 @@ package xtbmlcli
 
 import (
 	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"mort/internal/xtbml"
 )
 
 func TestRunSuccess(t *testing.T) {
@@ -52,3 +56,143 @@ func TestRunFailure(t *testing.T) {
 		t.Fatalf("expected stderr output")
 	}
 }
+
+func TestRunValidate(t *testing.T) {
+	src := t.TempDir()
+	doc := `<XTbML version="1.3"><ContentClassification><TableName>!!!</TableName></ContentClassification>` +
+		`<Table><Values><Axis><Y t="40">0.1</Y></Axis></Values></Table></XTbML>`
+	if err := os.WriteFile(filepath.Join(src, "bad.xml"), []byte(doc), 0o644); err != nil {
+		t.Fatalf("write src: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"-src", src, "-dst", t.TempDir()}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Run() without -validate exit code = %d, stderr = %s", code, stderr.String())
+	}
+	stderr.Reset()
+	if code := Run([]string{"-src", src, "-dst", t.TempDir(), "-validate"}, &stdout, &stderr); code != 1 {
+		t.Fatalf("Run() -validate exit code = %d, want 1", code)
+	}
+	if !strings.Contains(stderr.String(), "/identifier") {
+		t.Fatalf("stderr missing schema violation: %s", stderr.String())
+	}
+	stderr.Reset()
+	if code := Run([]string{"-src", src, "-dst", t.TempDir(), "-validate", "-stream"}, &stdout, &stderr); code != 2 {
+		t.Fatalf("Run() -validate -stream exit code = %d, want 2", code)
+	}
+}
+
+func TestRunFromCSV(t *testing.T) {
+	src := t.TempDir()
+	dst := t.TempDir()
+
+	for _, name := range []string{"select_long.csv", "select_long.meta.json"} {
+		data, err := os.ReadFile(filepath.Join("..", "tableimport", "testdata", name))
+		if err != nil {
+			t.Fatalf("read fixture: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(src, name), data, 0o644); err != nil {
+			t.Fatalf("write src: %v", err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"--src", src, "--dst", dst, "-from", "csv"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("Run() exit code = %d, stderr = %s", code, stderr.String())
+	}
+	if _, err := os.Stat(filepath.Join(dst, "select_long.json")); err != nil {
+		t.Fatalf("expected output json: %v", err)
+	}
+}
+
+func TestRunCanonical(t *testing.T) {
+	src := t.TempDir()
+	dst := t.TempDir()
+
+	fixtures := filepath.Join("..", "xtbml", "testdata")
+	xmlBytes, err := os.ReadFile(filepath.Join(fixtures, "table_select.xml"))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "table_select.xml"), xmlBytes, 0o644); err != nil {
+		t.Fatalf("write src: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"--src", src, "--dst", dst, "-canonical"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("Run() exit code = %d, stderr = %s", code, stderr.String())
+	}
+	got, err := os.ReadFile(filepath.Join(dst, "table_select.json"))
+	if err != nil {
+		t.Fatalf("read output json: %v", err)
+	}
+	want, err := os.ReadFile(filepath.Join(fixtures, "json", "table_select.json"))
+	if err != nil {
+		t.Fatalf("read golden json: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("canonical output mismatch\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestRunParseModes(t *testing.T) {
+	src := t.TempDir()
+	doc := `<XTbML version="1.0"><ContentClassification><TableName>Odd</TableName></ContentClassification>` +
+		`<Table><Values><Axis><Y t="40">0.1</Y><Y t="41">n/a</Y></Axis></Values></Table></XTbML>`
+	if err := os.WriteFile(filepath.Join(src, "odd.xml"), []byte(doc), 0o644); err != nil {
+		t.Fatalf("write src: %v", err)
+	}
+
+	dst := t.TempDir()
+	report := filepath.Join(t.TempDir(), "warnings.json")
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"--src", src, "--dst", dst, "--warnings", report}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("lenient exit code = %d, stderr = %s", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), `warning: odd.xml:1: rate "n/a" is not a number`) {
+		t.Fatalf("stderr missing warning: %s", stderr.String())
+	}
+	data, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatalf("read warnings report: %v", err)
+	}
+	var warnings []xtbml.FileWarnings
+	if err := json.Unmarshal(data, &warnings); err != nil || len(warnings) != 1 || len(warnings[0].Warnings) != 1 {
+		t.Fatalf("warnings report = %s (err %v)", data, err)
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	code = Run([]string{"--src", src, "--dst", t.TempDir(), "--strict"}, &stdout, &stderr)
+	if code != 1 || !strings.Contains(stderr.String(), "not a number") {
+		t.Fatalf("strict exit code = %d, stderr = %s", code, stderr.String())
+	}
+}
+
+func TestRunReportsUnknownCodes(t *testing.T) {
+	src := t.TempDir()
+	doc := `<XTbML version="1.0"><ContentClassification><TableName>Bespoke</TableName><ContentType tc="999">Bespoke</ContentType></ContentClassification>` +
+		`<Table><MetaData><Nation tc="44">United Kingdom</Nation></MetaData><Values><Axis><Y t="40">0.1</Y></Axis></Values></Table></XTbML>`
+	if err := os.WriteFile(filepath.Join(src, "bespoke.xml"), []byte(doc), 0o644); err != nil {
+		t.Fatalf("write src: %v", err)
+	}
+
+	for _, args := range [][]string{nil, {"--stream"}} {
+		var stdout, stderr bytes.Buffer
+		code := Run(append([]string{"--src", src, "--dst", t.TempDir()}, args...), &stdout, &stderr)
+		if code != 0 {
+			t.Fatalf("%v: exit code = %d, stderr = %s", args, code, stderr.String())
+		}
+		for _, want := range []string{
+			`warning: bespoke.xml: classification.contentType code "999" ("Bespoke") is not a registered contentType code`,
+			`warning: bespoke.xml: tables[0].metadata.nation code "44" ("United Kingdom") is not a registered nation code`,
+		} {
+			if !strings.Contains(stderr.String(), want) {
+				t.Fatalf("%v: stderr missing %q:\n%s", args, want, stderr.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,12 @@
+// Package schemas embeds the JSON Schemas that describe mort's data files.
+package schemas
+
+import _ "embed"
+
+// XTbMLURL identifies the converted-table schema when it is compiled.
+const XTbMLURL = "https://mort.local/schemas/xtbml.schema.json"
+
+// XTbML is the JSON Schema for converted XTbML tables.
+//
+//go:embed xtbml.schema.json
+var XTbML []byte
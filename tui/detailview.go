@@ -433,7 +433,7 @@ func renderClassification(detail *tuiapp.TableDetail, width int) string {
 	appendKV(&b, "Provider Domain", c.ProviderDomain, width)
 	appendKV(&b, "Provider Name", c.ProviderName, width)
 	appendKV(&b, "Reference", c.TableReference, width)
-	appendKV(&b, "Content Type", fmt.Sprintf("%s (%s)", c.ContentType.Label, c.ContentType.Code), width)
+	appendKV(&b, "Content Type", classifiedLabel(c.ContentType), width)
 	appendKV(&b, "Description", c.TableDescription, width)
 	if c.Comments != "" {
 		appendKV(&b, "Comments", c.Comments, width)
@@ -444,6 +444,16 @@ func renderClassification(detail *tuiapp.TableDetail, width int) string {
 	return strings.TrimSpace(b.String())
 }
 
+// classifiedLabel renders a coded value as "Label (code)", noting the source
+// label when the converter replaced it with the canonical one.
+func classifiedLabel(v xtbml.ClassifiedValuePayload) string {
+	text := fmt.Sprintf("%s (%s)", v.Label, v.Code)
+	if v.RawLabel != "" {
+		text += fmt.Sprintf(", source label %q", v.RawLabel)
+	}
+	return text
+}
+
 func renderMetadata(detail *tuiapp.TableDetail, tableIdx int, width int) string {
 	if detail == nil || tableIdx >= len(detail.Tables) {
 		return "No metadata."
@@ -457,8 +467,8 @@ func renderMetadata(detail *tuiapp.TableDetail, tableIdx int, width int) string
 	b.WriteString(sectionTitleStyle.Render("Table Metadata"))
 	b.WriteString("\n\n")
 	appendKV(&b, "Scaling Factor", meta.ScalingFactor, width)
-	appendKV(&b, "Data Type", fmt.Sprintf("%s (%s)", meta.DataType.Label, meta.DataType.Code), width)
-	appendKV(&b, "Nation", fmt.Sprintf("%s (%s)", meta.Nation.Label, meta.Nation.Code), width)
+	appendKV(&b, "Data Type", classifiedLabel(meta.DataType), width)
+	appendKV(&b, "Nation", classifiedLabel(meta.Nation), width)
 	appendKV(&b, "Description", meta.TableDescription, width)
 	if len(meta.Axes) > 0 {
 		b.WriteString("\n")
@@ -0,0 +1,282 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"mort/internal/tablediff"
+	"mort/internal/xtbml"
+)
+
+// maxDiffFieldLines caps the field-change panel so the rate grid keeps most
+// of the screen.
+const maxDiffFieldLines = 8
+
+var (
+	removedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	addedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+)
+
+// DiffModel shows two versions of a table side by side: the changed fields
+// and, per rate table, both rates for every cell with their delta.
+type DiffModel struct {
+	a, b        *xtbml.ConvertedTable
+	labelA      string
+	labelB      string
+	result      *tablediff.Result
+	index       int
+	tables      int
+	changedOnly bool
+	rates       table.Model
+	width       int
+	height      int
+}
+
+// NewDiffModel builds the side-by-side view of result, the comparison of a
+// (labelled labelA) with b.
+func NewDiffModel(a, b *xtbml.ConvertedTable, labelA, labelB string, result *tablediff.Result) DiffModel {
+	m := DiffModel{
+		a:      a,
+		b:      b,
+		labelA: labelA,
+		labelB: labelB,
+		result: result,
+		tables: max(len(a.Tables), len(b.Tables)),
+		rates:  newRatesTableWithColumns(diffColumns()),
+		width:  80,
+		height: 24,
+	}
+	m.refreshRows()
+	return m
+}
+
+// Init requests the terminal size.
+func (m DiffModel) Init() tea.Cmd {
+	return tea.WindowSize()
+}
+
+// Update handles Bubble Tea messages.
+func (m DiffModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q", "esc":
+			return m, tea.Quit
+		case "left", "h":
+			if m.index > 0 {
+				m.index--
+				m.refreshRows()
+			}
+			return m, nil
+		case "right", "l":
+			if m.index < m.tables-1 {
+				m.index++
+				m.refreshRows()
+			}
+			return m, nil
+		case "c":
+			m.changedOnly = !m.changedOnly
+			m.refreshRows()
+			return m, nil
+		}
+	}
+	var cmd tea.Cmd
+	m.rates, cmd = m.rates.Update(msg)
+	return m, cmd
+}
+
+// View renders the comparison.
+func (m DiffModel) View() string {
+	width := max(20, m.width-4)
+	header := lipgloss.NewStyle().Width(width).Render(lipgloss.JoinVertical(lipgloss.Left,
+		removedStyle.Render("− "+m.labelA),
+		addedStyle.Render("+ "+m.labelB),
+		helperTextStyle.Render(m.result.Verdict()),
+	))
+	fields := lipgloss.NewStyle().Width(width).Render(m.renderFields(width))
+	footer := helperTextStyle.Width(width).Render("←/→ table • j/k scroll • c changed cells only • q quit")
+
+	bodyHeight := availableBodyHeight(m.height, header, fields, footer)
+	m.rates.SetWidth(max(10, width-4))
+	m.rates.SetHeight(max(1, bodyHeight-3))
+	title := sectionTitleStyle.Render(m.tableTitle())
+	body := ratesPanelStyle.Width(width).Height(bodyHeight).Render(
+		lipgloss.JoinVertical(lipgloss.Left, title, m.rates.View()))
+
+	content := lipgloss.JoinVertical(lipgloss.Left, header, fields, body, footer)
+	return lipgloss.NewStyle().Width(max(1, m.width)).Height(max(1, m.height)).Render(content)
+}
+
+func (m DiffModel) tableTitle() string {
+	if m.tables == 0 {
+		return "No rate tables"
+	}
+	title := fmt.Sprintf("Table %d of %d", m.index+1, m.tables)
+	for _, stats := range m.result.Tables {
+		if stats.Table != m.index {
+			continue
+		}
+		switch stats.Status {
+		case tablediff.CellAdded:
+			title += " • added"
+		case tablediff.CellRemoved:
+			title += " • removed"
+		default:
+			title += fmt.Sprintf(" • %d of %d cells changed, %d added, %d removed", stats.Changed, stats.Compared, stats.Added, stats.Removed)
+		}
+	}
+	if m.changedOnly {
+		title += " • changed only"
+	}
+	return title
+}
+
+func (m DiffModel) renderFields(width int) string {
+	if len(m.result.Fields) == 0 {
+		return helperTextStyle.Render("No classification or metadata changes.")
+	}
+	var b strings.Builder
+	for i, f := range m.result.Fields {
+		if i == maxDiffFieldLines {
+			b.WriteString(helperTextStyle.Render(fmt.Sprintf("… %d more field changes", len(m.result.Fields)-i)))
+			break
+		}
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		text := fmt.Sprintf("%s: %s → %s", f.Path, fieldValue(f.Before), fieldValue(f.After))
+		b.WriteString(labelStyle.Render("• "))
+		b.WriteString(valueStyle.Render(truncate(text, max(10, width-2))))
+	}
+	return b.String()
+}
+
+func fieldValue(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if s == "" {
+		return "—"
+	}
+	return s
+}
+
+func diffColumns() []table.Column {
+	return []table.Column{
+		{Title: "", Width: 2},
+		{Title: "Age", Width: 6},
+		{Title: "Dur", Width: 5},
+		{Title: "Before", Width: 14},
+		{Title: "After", Width: 14},
+		{Title: "Δ", Width: 14},
+		{Title: "Δ%", Width: 9},
+	}
+}
+
+type diffCellKey struct {
+	age      int
+	duration int
+	hasDur   bool
+}
+
+func diffKey(age int, duration *int) diffCellKey {
+	if duration == nil {
+		return diffCellKey{age: age}
+	}
+	return diffCellKey{age: age, duration: *duration, hasDur: true}
+}
+
+func (k diffCellKey) less(o diffCellKey) bool {
+	if k.age != o.age {
+		return k.age < o.age
+	}
+	if k.hasDur != o.hasDur {
+		return !k.hasDur
+	}
+	return k.duration < o.duration
+}
+
+// refreshRows lists every cell of the current table in age and duration
+// order, marking changed (~), added (+) and removed (−) cells.
+func (m *DiffModel) refreshRows() {
+	changes := make(map[diffCellKey]tablediff.CellChange)
+	for _, c := range m.result.Cells {
+		if c.Table == m.index {
+			changes[diffKey(c.Age, c.Duration)] = c
+		}
+	}
+
+	type diffRow struct {
+		key diffCellKey
+		row table.Row
+	}
+	var rows []diffRow
+	seen := make(map[diffCellKey]bool)
+	add := func(entry xtbml.RateEntryPayload) {
+		key := diffKey(entry.Age, entry.Duration)
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		change, changed := changes[key]
+		if m.changedOnly && !changed {
+			return
+		}
+		dur := ""
+		if entry.Duration != nil {
+			dur = strconv.Itoa(*entry.Duration)
+		}
+		before, after := diffRate(entry.Rate), diffRate(entry.Rate)
+		mark, delta, rel := "", "", ""
+		if changed {
+			before, after = diffRate(change.Before), diffRate(change.After)
+			switch change.Kind {
+			case tablediff.CellAdded:
+				mark, before = "+", ""
+			case tablediff.CellRemoved:
+				mark, after = "−", ""
+			default:
+				mark = "~"
+			}
+			if change.Abs != nil {
+				delta = tablediff.FormatRate(*change.Abs)
+			}
+			if change.Rel != nil {
+				rel = tablediff.FormatPercent(*change.Rel)
+			}
+		}
+		rows = append(rows, diffRow{key: key, row: table.Row{mark, strconv.Itoa(entry.Age), dur, before, after, delta, rel}})
+	}
+	if m.index < len(m.a.Tables) {
+		for _, entry := range m.a.Tables[m.index].Rates {
+			add(entry)
+		}
+	}
+	if m.index < len(m.b.Tables) {
+		for _, entry := range m.b.Tables[m.index].Rates {
+			add(entry)
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].key.less(rows[j].key) })
+
+	tableRows := make([]table.Row, len(rows))
+	for i, r := range rows {
+		tableRows[i] = r.row
+	}
+	m.rates.SetRows(tableRows)
+	m.rates.GotoTop()
+}
+
+func diffRate(v *float64) string {
+	if v == nil {
+		return "blank"
+	}
+	return tablediff.FormatRate(*v)
+}
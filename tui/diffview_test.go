@@ -0,0 +1,41 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"mort/internal/tablediff"
+	"mort/internal/xtbml"
+)
+
+func diffRatePtr(v float64) *float64 { return &v }
+
+func TestDiffModel(t *testing.T) {
+	a := &xtbml.ConvertedTable{Tables: []xtbml.TablePayload{{Rates: []xtbml.RateEntryPayload{
+		{Age: 40, Rate: diffRatePtr(0.01)},
+		{Age: 41, Rate: diffRatePtr(0.02)},
+	}}}}
+	b := &xtbml.ConvertedTable{Tables: []xtbml.TablePayload{{Rates: []xtbml.RateEntryPayload{
+		{Age: 40, Rate: diffRatePtr(0.01)},
+		{Age: 41, Rate: diffRatePtr(0.03)},
+		{Age: 42, Rate: diffRatePtr(0.04)},
+	}}}}
+	m := NewDiffModel(a, b, "old", "new", tablediff.Compare(a, b, tablediff.Options{}))
+
+	if rows := m.rates.Rows(); len(rows) != 3 || rows[1][0] != "~" || rows[1][3] != "0.02" || rows[1][4] != "0.03" || rows[2][0] != "+" {
+		t.Fatalf("rows = %v", rows)
+	}
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	m = updated.(DiffModel)
+	if rows := m.rates.Rows(); len(rows) != 2 || rows[0][1] != "41" {
+		t.Fatalf("changed-only rows = %v", rows)
+	}
+	view := m.View()
+	for _, want := range []string{"old", "new", "1 of 2 cells changed, 1 added", "changed only"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("view missing %q:\n%s", want, view)
+		}
+	}
+}
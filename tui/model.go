@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -13,6 +14,7 @@ import (
 	"github.com/muesli/reflow/wordwrap"
 
 	"mort/internal/tuiapp"
+	"mort/internal/xtbml"
 )
 
 const summariesChunkSize = 150
@@ -347,6 +349,9 @@ func loadSummariesCmd(dir string, files []string, start int) tea.Cmd {
 		for _, name := range names[start:end] {
 			path := filepath.Join(dir, name)
 			summary, err := tuiapp.LoadTableSummary(path)
+			if errors.Is(err, xtbml.ErrNotTable) {
+				continue
+			}
 			if err != nil {
 				return summariesChunkMsg{err: err}
 			}
@@ -7,6 +7,8 @@ import type { ConvertedTable, TableIndexEntry, TableSummary } from './types';
 const jsonDirUrl = new URL('../../../json/', import.meta.url);
 const jsonDirPath = fileURLToPath(jsonDirUrl);
 
+const aliasFileName = 'identifier_aliases.json';
+
 let cachedIndex: TableIndexEntry[] | null = null;
 
 export async function loadTableIndex(): Promise<TableIndexEntry[]> {
@@ -17,18 +19,38 @@ export async function loadTableIndex(): Promise<TableIndexEntry[]> {
   const entries = await fs.readdir(jsonDirPath, { withFileTypes: true });
   const files = entries.filter((entry) => entry.isFile() && entry.name.endsWith('.json'));
 
-  const tables = await Promise.all(files.map(async (entry) => {
+  const loaded = await Promise.all(files.map(async (entry) => {
     const absolutePath = path.join(jsonDirPath, entry.name);
     const raw = await fs.readFile(absolutePath, 'utf-8');
     const detail = JSON.parse(raw) as ConvertedTable;
+    // Skip files such as the identifier alias map that are not tables.
+    if (!detail.identifier || !Array.isArray(detail.tables)) {
+      return null;
+    }
     return toIndexEntry(detail, absolutePath, entry.name);
   }));
+  const tables = loaded.filter((entry): entry is TableIndexEntry => entry !== null);
 
   tables.sort((a, b) => compareIdentities(a.tableIdentity, b.tableIdentity, a.name, b.name));
   cachedIndex = tables;
   return tables;
 }
 
+// loadIdentifierAliases returns the map of renamed identifiers to their
+// current identifier kept by the converter, or an empty map.
+export async function loadIdentifierAliases(): Promise<Record<string, string>> {
+  try {
+    const raw = await fs.readFile(path.join(jsonDirPath, aliasFileName), 'utf-8');
+    const parsed = JSON.parse(raw) as { aliases?: Record<string, string> };
+    return parsed.aliases ?? {};
+  } catch (error) {
+    if ((error as { code?: string }).code === 'ENOENT') {
+      return {};
+    }
+    throw error;
+  }
+}
+
 export async function loadTableDetail(filePath: string): Promise<ConvertedTable> {
   const raw = await fs.readFile(filePath, 'utf-8');
   return JSON.parse(raw) as ConvertedTable;
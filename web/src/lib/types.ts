@@ -1,6 +1,28 @@
 export interface ClassifiedValue {
   code: string;
+  // Canonical label for the code; rawLabel keeps the source label when it differs.
   label: string;
+  rawLabel?: string;
+}
+
+// Unmodelled XTbML attributes and elements, present when converted with -extensions.
+export interface ExtensionAttribute {
+  name: string;
+  namespace?: string;
+  value: string;
+}
+
+export interface ExtensionNode {
+  name: string;
+  namespace?: string;
+  attributes?: ExtensionAttribute[];
+  text?: string;
+  children?: ExtensionNode[];
+}
+
+export interface Extensions {
+  attributes?: ExtensionAttribute[];
+  elements?: ExtensionNode[];
 }
 
 export interface AxisDefinition {
@@ -10,6 +32,7 @@ export interface AxisDefinition {
   minValue: string;
   maxValue: string;
   increment: string;
+  extensions?: Extensions;
 }
 
 export interface TableMeta {
@@ -18,6 +41,7 @@ export interface TableMeta {
   nation?: ClassifiedValue;
   tableDescription?: string;
   axes?: AxisDefinition[];
+  extensions?: Extensions;
 }
 
 export interface RateEntry {
@@ -30,6 +54,7 @@ export interface TablePayload {
   index: number;
   metadata?: TableMeta;
   rates?: RateEntry[];
+  extensions?: Extensions;
 }
 
 export interface ClassificationPayload {
@@ -42,13 +67,16 @@ export interface ClassificationPayload {
   tableDescription?: string;
   comments?: string;
   keywords?: string[];
+  extensions?: Extensions;
 }
 
 export interface ConvertedTable {
+  schemaVersion?: number;
   identifier: string;
   version?: string;
   classification?: ClassificationPayload;
   tables?: TablePayload[];
+  extensions?: Extensions;
 }
 
 export interface TableSummary {
@@ -1,14 +1,25 @@
 import type { APIRoute, GetStaticPaths } from 'astro';
-import { loadTableDetail, loadTableIndex } from '../../lib/loadTables';
+import { loadIdentifierAliases, loadTableDetail, loadTableIndex } from '../../lib/loadTables';
 
 export const prerender = true;
 
 export const getStaticPaths: GetStaticPaths = async () => {
   const index = await loadTableIndex();
-  return index.map((entry) => ({
+  const paths = index.map((entry) => ({
     params: { identifier: entry.identifier },
     props: { filePath: entry.filePath },
   }));
+
+  // Renamed identifiers keep serving the table they now point at.
+  const byIdentifier = new Map(index.map((entry) => [entry.identifier, entry.filePath]));
+  const aliases = await loadIdentifierAliases();
+  for (const [alias, identifier] of Object.entries(aliases)) {
+    const filePath = byIdentifier.get(identifier);
+    if (filePath && !byIdentifier.has(alias)) {
+      paths.push({ params: { identifier: alias }, props: { filePath } });
+    }
+  }
+  return paths;
 };
 
 export const GET: APIRoute = async ({ props }) => {